@@ -0,0 +1,263 @@
+package amqp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/flywave/go-twins/client"
+	"github.com/flywave/go-twins/protocol"
+	"github.com/flywave/go-twins/protocol/signals"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+var (
+	ErrNotConnected   = errors.New("amqp: not connected")
+	ErrUnknownRequest = errors.New("amqp: unknown request id")
+	ErrNacked         = fmt.Errorf("amqp: publish not confirmed by broker: %w", client.ErrRejected)
+)
+
+type Client struct {
+	client.Dispatcher
+	opts       *Options
+	mu         sync.Mutex
+	conn       Connection
+	pub        Channel
+	sub        Channel
+	confirms   *confirmer
+	replyQueue string
+	routes     *client.Routes
+	lost       client.ConnectionLostHandler
+	pubMu      sync.Mutex
+}
+
+var (
+	_ client.Client             = (*Client)(nil)
+	_ client.ConnectionNotifier = (*Client)(nil)
+)
+
+func NewClient(opts *Options) *Client {
+	if opts == nil {
+		opts = NewOptions()
+	}
+	if opts.Dialer == nil {
+		opts.Dialer = Dial
+	}
+	return &Client{opts: opts, routes: client.NewRoutes(opts.ReplyTTL)}
+}
+
+func (c *Client) Options() *Options {
+	return c.opts
+}
+
+func (c *Client) SetConnectionLostHandler(handler client.ConnectionLostHandler) {
+	c.mu.Lock()
+	c.lost = handler
+	c.mu.Unlock()
+}
+
+func (c *Client) Connect() error {
+	conn, err := c.opts.Dialer(c.opts.URL, c.opts.TLSConfig)
+	if err != nil {
+		return err
+	}
+	if err := c.setup(conn); err != nil {
+		conn.Close()
+		return err
+	}
+	return nil
+}
+
+func (c *Client) setup(conn Connection) error {
+	pub, err := conn.Channel()
+	if err != nil {
+		return err
+	}
+	if err := pub.Confirm(false); err != nil {
+		return err
+	}
+	confirms := newConfirmer(pub.NotifyPublish(make(chan amqp.Confirmation, 1)))
+	if err := pub.ExchangeDeclare(c.opts.Exchange, amqp.ExchangeTopic, true, false, false, false, nil); err != nil {
+		return err
+	}
+
+	sub, err := conn.Channel()
+	if err != nil {
+		return err
+	}
+	if err := sub.Qos(c.opts.Prefetch, 0, false); err != nil {
+		return err
+	}
+	shared := c.opts.Queue != ""
+	queue, err := sub.QueueDeclare(c.opts.Queue, shared, !shared, !shared, false, nil)
+	if err != nil {
+		return err
+	}
+	bindings := c.opts.Bindings
+	if len(bindings) == 0 {
+		bindings = []string{BindingKey(&protocol.Topic{})}
+	}
+	for _, key := range bindings {
+		if err := sub.QueueBind(queue.Name, key, c.opts.Exchange, false, nil); err != nil {
+			return err
+		}
+	}
+	replies, err := sub.QueueDeclare("", false, true, true, false, nil)
+	if err != nil {
+		return err
+	}
+	deliveries, err := sub.Consume(queue.Name, "", false, false, false, false, nil)
+	if err != nil {
+		return err
+	}
+	responses, err := sub.Consume(replies.Name, "", false, true, false, false, nil)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.conn = conn
+	c.pub = pub
+	c.sub = sub
+	c.confirms = confirms
+	c.replyQueue = replies.Name
+	c.mu.Unlock()
+
+	go c.consume(deliveries, false)
+	go c.consume(responses, true)
+	go c.watch(conn, conn.NotifyClose(make(chan *amqp.Error, 1)))
+	return nil
+}
+
+func (c *Client) Disconnect() {
+	c.mu.Lock()
+	conn := c.conn
+	c.conn = nil
+	c.pub = nil
+	c.sub = nil
+	c.mu.Unlock()
+	c.routes.Reset()
+	if conn != nil {
+		conn.Close()
+	}
+}
+
+func (c *Client) watch(conn Connection, closed chan *amqp.Error) {
+	err, ok := <-closed
+	if !ok || err == nil {
+		return
+	}
+	c.mu.Lock()
+	current := c.conn == conn
+	if current {
+		c.conn = nil
+		c.pub = nil
+		c.sub = nil
+	}
+	lost := c.lost
+	c.mu.Unlock()
+	if current && lost != nil {
+		lost(err)
+	}
+}
+
+func (c *Client) Send(message *protocol.Envelope) error {
+	if message.Topic == nil {
+		return errors.New("amqp: envelope without topic")
+	}
+	replyTo := client.ReplyTo(message)
+	if replyTo == "" && client.IsResponseRequired(message) {
+		c.mu.Lock()
+		replyTo = c.replyQueue
+		c.mu.Unlock()
+	}
+	return c.publish(c.opts.Exchange, RoutingKey(message.Topic), replyTo, message)
+}
+
+// Reply publishes to the requester's reply queue through the default
+// exchange, which routes by queue name.
+func (c *Client) Reply(requestId string, message *protocol.Envelope) error {
+	var replyTo string
+	if route, ok := c.routes.Take(requestId); ok {
+		replyTo = route.(string)
+	} else {
+		replyTo = client.ReplyTo(message)
+	}
+	if replyTo == "" {
+		return ErrUnknownRequest
+	}
+	message = client.WithHeaders(message, signals.WithCorrelationId(requestId))
+	return c.publish("", replyTo, "", message)
+}
+
+func (c *Client) publish(exchange, key, replyTo string, message *protocol.Envelope) error {
+	codec := c.opts.Codec
+	if codec == nil {
+		codec = protocol.DefaultCodec()
+	}
+	body := *message
+	body.Headers = nil
+	payload, err := codec.Marshal(&body)
+	if err != nil {
+		return client.Rejected(err)
+	}
+	msg := amqp.Publishing{
+		ContentType:   codec.ContentType(),
+		DeliveryMode:  amqp.Persistent,
+		CorrelationId: client.CorrelationId(message),
+		ReplyTo:       replyTo,
+		Headers:       headersTable(message.Headers),
+		Body:          payload,
+	}
+
+	c.pubMu.Lock()
+	defer c.pubMu.Unlock()
+	c.mu.Lock()
+	pub, confirms := c.pub, c.confirms
+	c.mu.Unlock()
+	if pub == nil {
+		return ErrNotConnected
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.opts.PublishTimeout)
+	defer cancel()
+	tag, wait := confirms.expect()
+	if err := pub.PublishWithContext(ctx, exchange, key, false, false, msg); err != nil {
+		confirms.cancel(tag, true)
+		return err
+	}
+	select {
+	case ack, ok := <-wait:
+		if !ok {
+			return ErrNotConnected
+		}
+		if !ack {
+			return ErrNacked
+		}
+		return nil
+	case <-ctx.Done():
+		confirms.cancel(tag, false)
+		return ctx.Err()
+	}
+}
+
+func (c *Client) consume(deliveries <-chan amqp.Delivery, replies bool) {
+	for d := range deliveries {
+		message, err := protocol.DecodeEnvelope(d.ContentType, d.Body)
+		if err != nil {
+			d.Reject(false)
+			continue
+		}
+		message.Headers = tableHeaders(d.Headers, d.CorrelationId, d.ReplyTo)
+		requestId := d.CorrelationId
+		if requestId == "" && d.ReplyTo != "" {
+			requestId = client.NewRequestId()
+			message = client.WithHeaders(message, signals.WithCorrelationId(requestId))
+		}
+		if !replies && d.ReplyTo != "" {
+			c.routes.Put(requestId, message, d.ReplyTo)
+		}
+		c.Dispatch(requestId, message)
+		d.Ack(false)
+	}
+}
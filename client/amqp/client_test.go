@@ -0,0 +1,124 @@
+package amqp
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/flywave/go-twins/client"
+	"github.com/flywave/go-twins/protocol"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+func newTestClient(t *testing.T, broker *MemoryBroker) *Client {
+	t.Helper()
+	c := NewClient(NewOptions().WithDialer(broker.Dial).WithPublishTimeout(time.Second))
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(c.Disconnect)
+	return c
+}
+
+func commandTopic(action protocol.TopicAction) *protocol.Topic {
+	return &protocol.Topic{TenantName: "t", ChannelName: "c", Entity: protocol.EntityThings, Criterion: protocol.CriterionCommands, Action: action}
+}
+
+func TestRequestReplyRoundTrip(t *testing.T) {
+	broker := NewMemoryBroker()
+	responder := newTestClient(t, broker)
+	requester := newTestClient(t, broker)
+
+	responder.Subscribe(func(requestId string, message *protocol.Envelope) {
+		if message.Topic.Action != protocol.ActionCreateOrModify || !client.IsResponseRequired(message) {
+			return
+		}
+		res := &protocol.Envelope{Topic: commandTopic(protocol.ActionCreateOrModify), Path: message.Path, Status: http.StatusNoContent}
+		if err := responder.Reply(requestId, res); err != nil {
+			t.Error(err)
+		}
+	})
+
+	r := client.NewRequester(requester)
+	defer r.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	res, err := r.Request(ctx, &protocol.Envelope{
+		Topic: commandTopic(protocol.ActionCreateOrModify),
+		Path:  (&protocol.Path{}).WithThingAttribute("thing1", "location"),
+		Value: "here",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != http.StatusNoContent {
+		t.Fatalf("status %d, want %d", res.Status, http.StatusNoContent)
+	}
+}
+
+func TestConfirmerMatchesDeliveryTag(t *testing.T) {
+	confirms := make(chan amqp.Confirmation)
+	cf := newConfirmer(confirms)
+
+	// the first publish gives up before its ack arrives
+	first, _ := cf.expect()
+	cf.cancel(first, false)
+	second, wait := cf.expect()
+
+	confirms <- amqp.Confirmation{DeliveryTag: first, Ack: true}
+	confirms <- amqp.Confirmation{DeliveryTag: second, Ack: false}
+	if ack := <-wait; ack {
+		t.Fatal("late ack of the first publish was credited to the second")
+	}
+
+	// a publish that never reached the broker gives its tag back
+	unsent, _ := cf.expect()
+	cf.cancel(unsent, true)
+	if next, _ := cf.expect(); next != unsent {
+		t.Fatalf("tag %d after unsent publish, want %d", next, unsent)
+	}
+
+	close(confirms)
+	_, wait = cf.expect()
+	if _, ok := <-wait; ok {
+		t.Fatal("confirm pending on a closed channel")
+	}
+}
+
+func TestNackRequeuesInDeliveryOrder(t *testing.T) {
+	broker := NewMemoryBroker()
+	conn, _ := broker.Dial("", nil)
+	ch, err := conn.Channel()
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := ch.QueueDeclare("q", false, false, false, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, body := range []string{"1", "2", "3", "4"} {
+		if err := ch.PublishWithContext(context.Background(), "", q.Name, false, false, amqp.Publishing{Body: []byte(body)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deliveries, err := ch.Consume(q.Name, "", false, false, false, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var last amqp.Delivery
+	for i := 0; i < 4; i++ {
+		last = <-deliveries
+	}
+	if err := last.Nack(true, true); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"1", "2", "3", "4"} {
+		d := <-deliveries
+		if string(d.Body) != want || !d.Redelivered {
+			t.Fatalf("redelivered %q (%v), want %q", d.Body, d.Redelivered, want)
+		}
+		d.Ack(false)
+	}
+	conn.Close()
+}
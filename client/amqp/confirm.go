@@ -0,0 +1,68 @@
+package amqp
+
+import (
+	"sync"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// confirmer pairs publisher confirms with publishings by delivery tag, so a
+// confirm arriving after its publish gave up is not credited to the next
+// one. Tags count publishings on a channel from 1, as the broker does.
+type confirmer struct {
+	mu      sync.Mutex
+	tag     uint64
+	pending map[uint64]chan bool
+	done    bool
+}
+
+func newConfirmer(confirms chan amqp.Confirmation) *confirmer {
+	cf := &confirmer{pending: make(map[uint64]chan bool)}
+	go cf.run(confirms)
+	return cf
+}
+
+func (cf *confirmer) run(confirms chan amqp.Confirmation) {
+	for confirm := range confirms {
+		cf.mu.Lock()
+		if wait, ok := cf.pending[confirm.DeliveryTag]; ok {
+			delete(cf.pending, confirm.DeliveryTag)
+			wait <- confirm.Ack
+		}
+		cf.mu.Unlock()
+	}
+	cf.mu.Lock()
+	cf.done = true
+	for tag, wait := range cf.pending {
+		delete(cf.pending, tag)
+		close(wait)
+	}
+	cf.mu.Unlock()
+}
+
+// expect reserves the tag of the next publishing. Callers serialize
+// expect, publish and cancel so tags follow publish order. The returned
+// channel yields the ack flag, or is closed when the channel goes away.
+func (cf *confirmer) expect() (uint64, chan bool) {
+	cf.mu.Lock()
+	defer cf.mu.Unlock()
+	cf.tag++
+	wait := make(chan bool, 1)
+	if cf.done {
+		close(wait)
+	} else {
+		cf.pending[cf.tag] = wait
+	}
+	return cf.tag, wait
+}
+
+// cancel forgets a publishing; unsent reports that it never reached the
+// broker, which then did not use its tag.
+func (cf *confirmer) cancel(tag uint64, unsent bool) {
+	cf.mu.Lock()
+	defer cf.mu.Unlock()
+	delete(cf.pending, tag)
+	if unsent && tag == cf.tag {
+		cf.tag--
+	}
+}
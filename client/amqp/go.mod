@@ -0,0 +1,17 @@
+module github.com/flywave/go-twins/client/amqp
+
+go 1.24.0
+
+require (
+	github.com/flywave/go-twins v0.0.0-00010101000000-000000000000
+	github.com/rabbitmq/amqp091-go v1.10.0
+)
+
+require (
+	github.com/fxamacker/cbor/v2 v2.9.0 // indirect
+	github.com/vmihailenco/msgpack/v5 v5.4.1 // indirect
+	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
+	github.com/x448/float16 v0.8.4 // indirect
+)
+
+replace github.com/flywave/go-twins => ../..
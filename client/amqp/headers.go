@@ -0,0 +1,77 @@
+package amqp
+
+import (
+	"github.com/flywave/go-twins/protocol"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Correlation id and reply-to travel as native AMQP properties and are left
+// out of the header table.
+func headersTable(headers *protocol.Headers) amqp.Table {
+	if headers == nil || len(headers.Values) == 0 {
+		return nil
+	}
+	table := make(amqp.Table, len(headers.Values))
+	for k, v := range headers.Values {
+		if k == protocol.HeaderCorrelationId || k == protocol.HeaderReplyTo {
+			continue
+		}
+		table[k] = tableValue(v)
+	}
+	return table
+}
+
+func tableValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		res := make(amqp.Table, len(t))
+		for k, e := range t {
+			res[k] = tableValue(e)
+		}
+		return res
+	case []interface{}:
+		res := make([]interface{}, len(t))
+		for i, e := range t {
+			res[i] = tableValue(e)
+		}
+		return res
+	case int:
+		return int64(t)
+	}
+	return v
+}
+
+func tableHeaders(table amqp.Table, correlationId, replyTo string) *protocol.Headers {
+	values := make(map[string]interface{}, len(table)+2)
+	for k, v := range table {
+		values[k] = headerValue(v)
+	}
+	if correlationId != "" {
+		values[protocol.HeaderCorrelationId] = correlationId
+	}
+	if replyTo != "" {
+		values[protocol.HeaderReplyTo] = replyTo
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	return protocol.NewHeadersWithValues(values)
+}
+
+func headerValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case amqp.Table:
+		res := make(map[string]interface{}, len(t))
+		for k, e := range t {
+			res[k] = headerValue(e)
+		}
+		return res
+	case []interface{}:
+		res := make([]interface{}, len(t))
+		for i, e := range t {
+			res[i] = headerValue(e)
+		}
+		return res
+	}
+	return v
+}
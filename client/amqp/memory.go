@@ -0,0 +1,516 @@
+package amqp
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+var errMemoryClosed = errors.New("amqp: memory broker closed")
+
+// MemoryBroker is an in-process stand-in for a broker with topic exchanges,
+// the default exchange, publisher confirms and consumer acknowledgements.
+// Pass its Dial method as Options.Dialer to run clients without RabbitMQ.
+type MemoryBroker struct {
+	mu        sync.Mutex
+	exchanges map[string]string
+	queues    map[string]*memoryQueue
+	bindings  []memoryBinding
+	conns     map[*memoryConnection]struct{}
+	seq       uint64
+}
+
+type memoryBinding struct {
+	exchange string
+	key      string
+	queue    string
+}
+
+type memoryMessage struct {
+	msg         amqp.Publishing
+	exchange    string
+	key         string
+	redelivered bool
+}
+
+type memoryQueue struct {
+	name     string
+	owner    *memoryConnection
+	cond     *sync.Cond
+	messages []*memoryMessage
+	deleted  bool
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{
+		exchanges: map[string]string{"": amqp.ExchangeDirect},
+		queues:    make(map[string]*memoryQueue),
+		conns:     make(map[*memoryConnection]struct{}),
+	}
+}
+
+func (b *MemoryBroker) Dial(url string, config *tls.Config) (Connection, error) {
+	conn := &memoryConnection{broker: b, channels: make(map[*memoryChannel]struct{})}
+	b.mu.Lock()
+	b.conns[conn] = struct{}{}
+	b.mu.Unlock()
+	return conn, nil
+}
+
+// Close drops every connection as a broker failure would.
+func (b *MemoryBroker) Close() {
+	b.mu.Lock()
+	conns := make([]*memoryConnection, 0, len(b.conns))
+	for conn := range b.conns {
+		conns = append(conns, conn)
+	}
+	b.mu.Unlock()
+	for _, conn := range conns {
+		conn.shutdown(&amqp.Error{Code: amqp.ConnectionForced, Reason: errMemoryClosed.Error(), Server: true})
+	}
+}
+
+func (b *MemoryBroker) Queue(name string) (int, bool) {
+	b.mu.Lock()
+	q, ok := b.queues[name]
+	b.mu.Unlock()
+	if !ok {
+		return 0, false
+	}
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+	return len(q.messages), true
+}
+
+func (b *MemoryBroker) route(exchange, key string) ([]*memoryQueue, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.exchanges[exchange]; !ok {
+		return nil, fmt.Errorf("amqp: no exchange %q", exchange)
+	}
+	if exchange == "" {
+		if q, ok := b.queues[key]; ok {
+			return []*memoryQueue{q}, nil
+		}
+		return nil, nil
+	}
+	var res []*memoryQueue
+	seen := make(map[string]bool)
+	for _, bd := range b.bindings {
+		if bd.exchange != exchange || seen[bd.queue] || !matchBindingKey(bd.key, key) {
+			continue
+		}
+		if q, ok := b.queues[bd.queue]; ok {
+			seen[bd.queue] = true
+			res = append(res, q)
+		}
+	}
+	return res, nil
+}
+
+func (q *memoryQueue) push(m *memoryMessage, front bool) {
+	q.cond.L.Lock()
+	if front {
+		q.messages = append([]*memoryMessage{m}, q.messages...)
+	} else {
+		q.messages = append(q.messages, m)
+	}
+	q.cond.L.Unlock()
+	q.cond.Broadcast()
+}
+
+func (q *memoryQueue) pop(stop func() bool) *memoryMessage {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+	for len(q.messages) == 0 && !q.deleted && !stop() {
+		q.cond.Wait()
+	}
+	if len(q.messages) == 0 || q.deleted || stop() {
+		return nil
+	}
+	m := q.messages[0]
+	q.messages = q.messages[1:]
+	return m
+}
+
+type memoryConnection struct {
+	broker   *MemoryBroker
+	mu       sync.Mutex
+	channels map[*memoryChannel]struct{}
+	notify   []chan *amqp.Error
+	closed   bool
+}
+
+func (c *memoryConnection) Channel() (Channel, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil, amqp.ErrClosed
+	}
+	ch := &memoryChannel{conn: c, unacked: make(map[uint64]*memoryUnacked), done: make(chan struct{})}
+	c.channels[ch] = struct{}{}
+	return ch, nil
+}
+
+func (c *memoryConnection) NotifyClose(receiver chan *amqp.Error) chan *amqp.Error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		close(receiver)
+		return receiver
+	}
+	c.notify = append(c.notify, receiver)
+	return receiver
+}
+
+func (c *memoryConnection) Close() error {
+	c.shutdown(nil)
+	return nil
+}
+
+func (c *memoryConnection) shutdown(err *amqp.Error) {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return
+	}
+	c.closed = true
+	channels := c.channels
+	notify := c.notify
+	c.channels = nil
+	c.notify = nil
+	c.mu.Unlock()
+
+	for ch := range channels {
+		ch.Close()
+	}
+	b := c.broker
+	b.mu.Lock()
+	delete(b.conns, c)
+	for name, q := range b.queues {
+		if q.owner == c {
+			delete(b.queues, name)
+			q.cond.L.Lock()
+			q.deleted = true
+			q.cond.L.Unlock()
+			q.cond.Broadcast()
+		}
+	}
+	b.mu.Unlock()
+	for _, n := range notify {
+		if err != nil {
+			n <- err
+		}
+		close(n)
+	}
+}
+
+type memoryUnacked struct {
+	queue   *memoryQueue
+	message *memoryMessage
+}
+
+type memoryChannel struct {
+	conn      *memoryConnection
+	mu        sync.Mutex
+	confirm   bool
+	published uint64
+	confirmMu sync.Mutex
+	confirms  []chan amqp.Confirmation
+	tag       uint64
+	unacked   map[uint64]*memoryUnacked
+	consumers []chan amqp.Delivery
+	done      chan struct{}
+	closed    bool
+	wg        sync.WaitGroup
+}
+
+func (ch *memoryChannel) broker() *MemoryBroker {
+	return ch.conn.broker
+}
+
+func (ch *memoryChannel) isClosed() bool {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	return ch.closed
+}
+
+func (ch *memoryChannel) ExchangeDeclare(name, kind string, durable, autoDelete, internal, noWait bool, args amqp.Table) error {
+	if ch.isClosed() {
+		return amqp.ErrClosed
+	}
+	b := ch.broker()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if k, ok := b.exchanges[name]; ok && k != kind {
+		return fmt.Errorf("amqp: exchange %q already declared as %s", name, k)
+	}
+	b.exchanges[name] = kind
+	return nil
+}
+
+func (ch *memoryChannel) QueueDeclare(name string, durable, autoDelete, exclusive, noWait bool, args amqp.Table) (amqp.Queue, error) {
+	if ch.isClosed() {
+		return amqp.Queue{}, amqp.ErrClosed
+	}
+	b := ch.broker()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if name == "" {
+		b.seq++
+		name = fmt.Sprintf("amq.gen-%d-%d", time.Now().UnixNano(), b.seq)
+	}
+	q, ok := b.queues[name]
+	if !ok {
+		q = &memoryQueue{name: name, cond: sync.NewCond(&sync.Mutex{})}
+		if exclusive {
+			q.owner = ch.conn
+		}
+		b.queues[name] = q
+	}
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+	return amqp.Queue{Name: name, Messages: len(q.messages)}, nil
+}
+
+func (ch *memoryChannel) QueueBind(name, key, exchange string, noWait bool, args amqp.Table) error {
+	if ch.isClosed() {
+		return amqp.ErrClosed
+	}
+	b := ch.broker()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.queues[name]; !ok {
+		return fmt.Errorf("amqp: no queue %q", name)
+	}
+	if _, ok := b.exchanges[exchange]; !ok {
+		return fmt.Errorf("amqp: no exchange %q", exchange)
+	}
+	for _, bd := range b.bindings {
+		if bd.exchange == exchange && bd.key == key && bd.queue == name {
+			return nil
+		}
+	}
+	b.bindings = append(b.bindings, memoryBinding{exchange: exchange, key: key, queue: name})
+	return nil
+}
+
+func (ch *memoryChannel) Qos(prefetchCount, prefetchSize int, global bool) error {
+	return nil
+}
+
+func (ch *memoryChannel) Confirm(noWait bool) error {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	if ch.closed {
+		return amqp.ErrClosed
+	}
+	ch.confirm = true
+	return nil
+}
+
+func (ch *memoryChannel) NotifyPublish(confirm chan amqp.Confirmation) chan amqp.Confirmation {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	if ch.closed {
+		close(confirm)
+		return confirm
+	}
+	ch.confirms = append(ch.confirms, confirm)
+	return confirm
+}
+
+func (ch *memoryChannel) PublishWithContext(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	if ch.isClosed() {
+		return amqp.ErrClosed
+	}
+	queues, err := ch.broker().route(exchange, key)
+	if err != nil {
+		return err
+	}
+	for _, q := range queues {
+		q.push(&memoryMessage{msg: msg, exchange: exchange, key: key}, false)
+	}
+	// like amqp091, confirmations are delivered synchronously, so listeners
+	// must drain them; confirmMu rather than mu is held while sending so a
+	// slow listener does not stall consumers and acks on the channel
+	ch.confirmMu.Lock()
+	defer ch.confirmMu.Unlock()
+	ch.mu.Lock()
+	confirm := ch.confirm
+	if confirm {
+		ch.published++
+	}
+	confirmation := amqp.Confirmation{DeliveryTag: ch.published, Ack: true}
+	confirms := ch.confirms
+	ch.mu.Unlock()
+	if confirm {
+		for _, c := range confirms {
+			c <- confirmation
+		}
+	}
+	return nil
+}
+
+func (ch *memoryChannel) Consume(queue, consumer string, autoAck, exclusive, noLocal, noWait bool, args amqp.Table) (<-chan amqp.Delivery, error) {
+	b := ch.broker()
+	b.mu.Lock()
+	q, ok := b.queues[queue]
+	b.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("amqp: no queue %q", queue)
+	}
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	if ch.closed {
+		return nil, amqp.ErrClosed
+	}
+	deliveries := make(chan amqp.Delivery)
+	ch.consumers = append(ch.consumers, deliveries)
+	ch.wg.Add(1)
+	go func() {
+		defer ch.wg.Done()
+		for {
+			m := q.pop(ch.isClosed)
+			if m == nil {
+				return
+			}
+			ch.mu.Lock()
+			if ch.closed {
+				ch.mu.Unlock()
+				q.push(m, true)
+				return
+			}
+			ch.tag++
+			tag := ch.tag
+			if !autoAck {
+				ch.unacked[tag] = &memoryUnacked{queue: q, message: m}
+			}
+			ch.mu.Unlock()
+			d := amqp.Delivery{
+				Acknowledger:  ch,
+				Headers:       m.msg.Headers,
+				ContentType:   m.msg.ContentType,
+				DeliveryMode:  m.msg.DeliveryMode,
+				CorrelationId: m.msg.CorrelationId,
+				ReplyTo:       m.msg.ReplyTo,
+				MessageId:     m.msg.MessageId,
+				Timestamp:     m.msg.Timestamp,
+				Type:          m.msg.Type,
+				AppId:         m.msg.AppId,
+				ConsumerTag:   consumer,
+				DeliveryTag:   tag,
+				Redelivered:   m.redelivered,
+				Exchange:      m.exchange,
+				RoutingKey:    m.key,
+				Body:          m.msg.Body,
+			}
+			select {
+			case deliveries <- d:
+			case <-ch.done:
+				return
+			}
+		}
+	}()
+	return deliveries, nil
+}
+
+func (ch *memoryChannel) Ack(tag uint64, multiple bool) error {
+	ch.settle(tag, multiple, false)
+	return nil
+}
+
+func (ch *memoryChannel) Nack(tag uint64, multiple bool, requeue bool) error {
+	ch.settle(tag, multiple, requeue)
+	return nil
+}
+
+func (ch *memoryChannel) Reject(tag uint64, requeue bool) error {
+	ch.settle(tag, false, requeue)
+	return nil
+}
+
+func (ch *memoryChannel) settle(tag uint64, multiple, requeue bool) {
+	ch.mu.Lock()
+	settled := make(map[uint64]*memoryUnacked)
+	for t, u := range ch.unacked {
+		if t == tag || (multiple && t < tag) {
+			settled[t] = u
+			delete(ch.unacked, t)
+		}
+	}
+	ch.mu.Unlock()
+	if requeue {
+		requeueUnacked(settled)
+	}
+}
+
+// requeueUnacked puts deliveries back at the head of their queues in
+// delivery order, so they are redelivered in the order they were sent.
+func requeueUnacked(unacked map[uint64]*memoryUnacked) {
+	tags := make([]uint64, 0, len(unacked))
+	for tag := range unacked {
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i] > tags[j] })
+	for _, tag := range tags {
+		u := unacked[tag]
+		u.message.redelivered = true
+		u.queue.push(u.message, true)
+	}
+}
+
+// Close requeues unacknowledged deliveries, as a broker does when a channel
+// goes away.
+func (ch *memoryChannel) Close() error {
+	ch.mu.Lock()
+	if ch.closed {
+		ch.mu.Unlock()
+		return nil
+	}
+	ch.closed = true
+	close(ch.done)
+	consumers := ch.consumers
+	ch.consumers = nil
+	confirms := ch.confirms
+	ch.confirms = nil
+	ch.mu.Unlock()
+
+	ch.confirmMu.Lock()
+	for _, c := range confirms {
+		close(c)
+	}
+	ch.confirmMu.Unlock()
+
+	// wake consumers parked on a queue; taking the queue lock orders the
+	// wakeup after their closed check
+	b := ch.broker()
+	b.mu.Lock()
+	queues := make([]*memoryQueue, 0, len(b.queues))
+	for _, q := range b.queues {
+		queues = append(queues, q)
+	}
+	b.mu.Unlock()
+	for _, q := range queues {
+		q.cond.L.Lock()
+		q.cond.L.Unlock()
+		q.cond.Broadcast()
+	}
+	ch.wg.Wait()
+	for _, c := range consumers {
+		close(c)
+	}
+
+	ch.mu.Lock()
+	unacked := ch.unacked
+	ch.unacked = make(map[uint64]*memoryUnacked)
+	ch.mu.Unlock()
+	requeueUnacked(unacked)
+	return nil
+}
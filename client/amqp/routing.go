@@ -0,0 +1,72 @@
+package amqp
+
+import (
+	"strings"
+
+	"github.com/flywave/go-twins/protocol"
+)
+
+const (
+	singleWordWildcard = "*"
+	multiWordWildcard  = "#"
+)
+
+func RoutingKey(topic *protocol.Topic) string {
+	words := []string{topic.TenantName, topic.ChannelName, string(topic.Entity), string(topic.Criterion)}
+	if topic.Action != "" {
+		words = append(words, string(topic.Action))
+	}
+	return strings.Join(words, ".")
+}
+
+func BindingKey(topic *protocol.Topic) string {
+	words := []string{
+		bindingWord(topic.TenantName),
+		bindingWord(topic.ChannelName),
+		bindingWord(string(topic.Entity)),
+		bindingWord(string(topic.Criterion)),
+	}
+	if topic.Action == "" {
+		words = append(words, multiWordWildcard)
+	} else {
+		words = append(words, bindingWord(string(topic.Action)))
+	}
+	return strings.Join(words, ".")
+}
+
+func bindingWord(word string) string {
+	if word == "" || word == protocol.TopicPlaceholder || word == singleWordWildcard {
+		return singleWordWildcard
+	}
+	return word
+}
+
+// matchBindingKey implements topic exchange matching: "*" matches exactly
+// one word and "#" zero or more.
+func matchBindingKey(pattern, key string) bool {
+	return matchWords(strings.Split(pattern, "."), strings.Split(key, "."))
+}
+
+func matchWords(pattern, words []string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case multiWordWildcard:
+			for i := 0; i <= len(words); i++ {
+				if matchWords(pattern[1:], words[i:]) {
+					return true
+				}
+			}
+			return false
+		case singleWordWildcard:
+			if len(words) == 0 {
+				return false
+			}
+		default:
+			if len(words) == 0 || pattern[0] != words[0] {
+				return false
+			}
+		}
+		pattern, words = pattern[1:], words[1:]
+	}
+	return len(words) == 0
+}
@@ -0,0 +1,52 @@
+package amqp
+
+import (
+	"context"
+	"crypto/tls"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Channel is the part of *amqp091.Channel the client relies on. It lets the
+// client run against MemoryBroker as well as a real broker.
+type Channel interface {
+	ExchangeDeclare(name, kind string, durable, autoDelete, internal, noWait bool, args amqp.Table) error
+	QueueDeclare(name string, durable, autoDelete, exclusive, noWait bool, args amqp.Table) (amqp.Queue, error)
+	QueueBind(name, key, exchange string, noWait bool, args amqp.Table) error
+	Qos(prefetchCount, prefetchSize int, global bool) error
+	Confirm(noWait bool) error
+	NotifyPublish(confirm chan amqp.Confirmation) chan amqp.Confirmation
+	Consume(queue, consumer string, autoAck, exclusive, noLocal, noWait bool, args amqp.Table) (<-chan amqp.Delivery, error)
+	PublishWithContext(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error
+	Close() error
+}
+
+type Connection interface {
+	Channel() (Channel, error)
+	NotifyClose(receiver chan *amqp.Error) chan *amqp.Error
+	Close() error
+}
+
+type Dialer func(url string, config *tls.Config) (Connection, error)
+
+func Dial(url string, config *tls.Config) (Connection, error) {
+	var conn *amqp.Connection
+	var err error
+	if config != nil {
+		conn, err = amqp.DialTLS(url, config)
+	} else {
+		conn, err = amqp.Dial(url)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &connection{conn}, nil
+}
+
+type connection struct {
+	*amqp.Connection
+}
+
+func (c *connection) Channel() (Channel, error) {
+	return c.Connection.Channel()
+}
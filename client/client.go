@@ -1,6 +1,26 @@
 package client
 
-import "github.com/flywave/go-twins/protocol"
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/flywave/go-twins/protocol"
+)
+
+// ErrRejected is wrapped by Send and Reply errors caused by the envelope
+// rather than the link: it cannot be encoded, it is too large or the broker
+// refused it. Sending it again fails the same way.
+var ErrRejected = errors.New("client: envelope rejected")
+
+// Rejected wraps err with ErrRejected.
+func Rejected(err error) error {
+	if err == nil || errors.Is(err, ErrRejected) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", ErrRejected, err)
+}
 
 type Handler func(requestId string, message *protocol.Envelope)
 
@@ -12,3 +32,17 @@ type Client interface {
 	Subscribe(handlers ...Handler)
 	Unsubscribe(handlers ...Handler)
 }
+
+type ConnectionLostHandler func(err error)
+
+type ConnectionNotifier interface {
+	SetConnectionLostHandler(handler ConnectionLostHandler)
+}
+
+func NewRequestId() string {
+	var buf [16]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(buf[:])
+}
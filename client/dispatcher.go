@@ -0,0 +1,122 @@
+package client
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/flywave/go-twins/protocol"
+)
+
+type Subscriber interface {
+	SubscribeFilter(filter *Filter, handler Handler) (*Subscription, error)
+}
+
+type Subscription struct {
+	dispatcher *Dispatcher
+	filter     *Filter
+	handler    Handler
+	seq        uint64
+}
+
+// Filter returns a copy of the filter the subscription was made with.
+func (s *Subscription) Filter() *Filter {
+	return s.filter.Clone()
+}
+
+func (s *Subscription) Unsubscribe() {
+	s.dispatcher.remove(func(sub *Subscription) bool { return sub == s })
+}
+
+// Dispatcher routes envelopes to the handlers whose filter matches, in
+// subscription order. Filters are looked up through a SubscriptionIndex.
+type Dispatcher struct {
+	mu            sync.RWMutex
+	subscriptions []*Subscription
+	index         *SubscriptionIndex
+}
+
+var _ Subscriber = (*Dispatcher)(nil)
+
+func (d *Dispatcher) Subscribe(handlers ...Handler) {
+	for _, h := range handlers {
+		if h != nil {
+			d.SubscribeFilter(nil, h)
+		}
+	}
+}
+
+// SubscribeFilter registers handler for the envelopes filter matches. The
+// filter is copied, so changing it afterwards does not affect the
+// subscription. An invalid path pattern is an error.
+func (d *Dispatcher) SubscribeFilter(filter *Filter, handler Handler) (*Subscription, error) {
+	filter = filter.Clone()
+	if err := filter.compile(); err != nil {
+		return nil, err
+	}
+	sub := &Subscription{dispatcher: d, filter: filter, handler: handler}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.index == nil {
+		d.index = NewSubscriptionIndex()
+	}
+	if err := d.index.Add(sub); err != nil {
+		return nil, err
+	}
+	d.subscriptions = append(d.subscriptions, sub)
+	return sub, nil
+}
+
+// Unsubscribe removes every registration of the given handlers, filtered or
+// not. Handlers are compared by code pointer, so closures created by the same
+// function literal and method values of the same method are
+// indistinguishable.
+//
+// Deprecated: func values have no identity. Keep the *Subscription returned
+// by SubscribeFilter and call its Unsubscribe method instead.
+func (d *Dispatcher) Unsubscribe(handlers ...Handler) {
+	for _, h := range handlers {
+		ptr := handlerPointer(h)
+		d.remove(func(sub *Subscription) bool { return handlerPointer(sub.handler) == ptr })
+	}
+}
+
+func (d *Dispatcher) remove(match func(sub *Subscription) bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	res := make([]*Subscription, 0, len(d.subscriptions))
+	for _, sub := range d.subscriptions {
+		if !match(sub) {
+			res = append(res, sub)
+		} else if d.index != nil {
+			d.index.Remove(sub)
+		}
+	}
+	d.subscriptions = res
+}
+
+func (d *Dispatcher) Subscriptions() []*Subscription {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	res := make([]*Subscription, len(d.subscriptions))
+	copy(res, d.subscriptions)
+	return res
+}
+
+func (d *Dispatcher) Dispatch(requestId string, message *protocol.Envelope) {
+	d.mu.RLock()
+	var subs []*Subscription
+	if d.index != nil {
+		subs = d.index.Match(message)
+	}
+	d.mu.RUnlock()
+	for _, sub := range subs {
+		sub.handler(requestId, message)
+	}
+}
+
+func handlerPointer(h Handler) uintptr {
+	if h == nil {
+		return 0
+	}
+	return reflect.ValueOf(h).Pointer()
+}
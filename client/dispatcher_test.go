@@ -0,0 +1,191 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/flywave/go-twins/protocol"
+)
+
+func benchmarkFilters(n int) []*Filter {
+	criteria := []protocol.TopicCriterion{protocol.CriterionCommands, protocol.CriterionEvents, protocol.CriterionMessages}
+	filters := make([]*Filter, 0, n)
+	for i := 0; i < n; i++ {
+		topic := &protocol.Topic{
+			TenantName:  fmt.Sprintf("tenant%d", i%10),
+			ChannelName: "twin",
+			Entity:      protocol.EntityThings,
+			Criterion:   criteria[i%len(criteria)],
+		}
+		var path string
+		switch i % 4 {
+		case 0:
+			path = fmt.Sprintf("@things/thing%d", i)
+		case 1:
+			path = fmt.Sprintf("@things/thing%d/features/+/properties/#", i)
+		case 2:
+			path = fmt.Sprintf("@things/thing%d/attributes/#", i)
+		case 3:
+			path = fmt.Sprintf("@things/thing%d/features/f*", i)
+		}
+		filters = append(filters, NewFilter(topic).WithPath(path))
+	}
+	return filters
+}
+
+// benchmarkMessages returns events that each match one of the
+// benchmarkFilters: thing 12i+1 a feature property and thing 12i+7 a
+// feature.
+func benchmarkMessages() []*protocol.Envelope {
+	var res []*protocol.Envelope
+	for i := 0; i < 16; i++ {
+		j := 12*i + 1
+		path := (&protocol.Path{}).WithThingFeaturePropertie(fmt.Sprintf("thing%d", j), "f1", "temperature/value")
+		if i%2 == 1 {
+			j = 12*i + 7
+			path = (&protocol.Path{}).WithThingFeature(fmt.Sprintf("thing%d", j), "f1")
+		}
+		res = append(res, &protocol.Envelope{
+			Topic: &protocol.Topic{
+				TenantName:  fmt.Sprintf("tenant%d", j%10),
+				ChannelName: "twin",
+				Entity:      protocol.EntityThings,
+				Criterion:   protocol.CriterionEvents,
+				Action:      protocol.ActionModified,
+			},
+			Path: path,
+		})
+	}
+	return res
+}
+
+// BenchmarkLinearMatch is the matching the dispatcher did before the index:
+// every filter is matched against every envelope, the path through the
+// wildcard regexp of Path.Match as it was then, compiled on every call.
+func BenchmarkLinearMatch(b *testing.B) {
+	messages := benchmarkMessages()
+	for _, n := range []int{100, 1000, 10000} {
+		filters := benchmarkFilters(n)
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				message := messages[i%len(messages)]
+				for _, f := range filters {
+					if !message.Topic.MatchTopic(f.Topic) {
+						continue
+					}
+					regexp.MatchString(protocol.WildCardToRegexp(f.Path), message.Path.String())
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkIndexMatch(b *testing.B) {
+	messages := benchmarkMessages()
+	for _, n := range []int{100, 1000, 10000} {
+		var d Dispatcher
+		for _, f := range benchmarkFilters(n) {
+			if _, err := d.SubscribeFilter(f, func(string, *protocol.Envelope) {}); err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				d.index.Match(messages[i%len(messages)])
+			}
+		})
+	}
+}
+
+func TestIndexMatchesLinearScan(t *testing.T) {
+	var d Dispatcher
+	filters := benchmarkFilters(400)
+	subs := make([]*Subscription, len(filters))
+	for i, f := range filters {
+		sub, err := d.SubscribeFilter(f, func(string, *protocol.Envelope) {})
+		if err != nil {
+			t.Fatal(err)
+		}
+		subs[i] = sub
+	}
+	for _, message := range benchmarkMessages() {
+		var want []*Subscription
+		for _, sub := range subs {
+			if sub.filter.Match(message) {
+				want = append(want, sub)
+			}
+		}
+		got := d.index.Match(message)
+		if len(want) != 1 {
+			t.Fatalf("%s: scan matched %d subscriptions, want 1", message.Path, len(want))
+		}
+		if len(got) != len(want) {
+			t.Fatalf("%s: index matched %d subscriptions, scan %d", message.Path, len(got), len(want))
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Fatalf("%s: subscription %d differs", message.Path, i)
+			}
+		}
+	}
+}
+
+func TestSubscribeFilterRejectsInvalidPattern(t *testing.T) {
+	var d Dispatcher
+	_, err := d.SubscribeFilter(NewFilter(nil).WithPath("@things/#/features"), func(string, *protocol.Envelope) {})
+	if !errors.Is(err, protocol.ErrInvalidPattern) {
+		t.Fatalf("got %v, want ErrInvalidPattern", err)
+	}
+	if len(d.Subscriptions()) != 0 {
+		t.Fatal("invalid subscription was registered")
+	}
+}
+
+func TestSubscriptionUnsubscribeKeepsOtherClosures(t *testing.T) {
+	var d Dispatcher
+	var calls [2]int
+	subs := make([]*Subscription, 2)
+	for i := range subs {
+		var err error
+		if subs[i], err = d.SubscribeFilter(nil, func(string, *protocol.Envelope) { calls[i]++ }); err != nil {
+			t.Fatal(err)
+		}
+	}
+	subs[0].Unsubscribe()
+	d.Dispatch("r1", &protocol.Envelope{})
+	if calls != [2]int{0, 1} {
+		t.Fatalf("calls = %v, want [0 1]", calls)
+	}
+}
+
+func TestUnsubscribeRemovesEveryRegistrationOfAFunction(t *testing.T) {
+	var d Dispatcher
+	var calls int
+	handlers := make([]Handler, 2)
+	for i := range handlers {
+		handlers[i] = func(string, *protocol.Envelope) { calls++ }
+	}
+	other := func(string, *protocol.Envelope) { calls += 10 }
+	d.Subscribe(append(handlers, other)...)
+	d.Unsubscribe(handlers[0])
+	d.Dispatch("r1", &protocol.Envelope{})
+	if calls != 10 {
+		t.Fatalf("calls = %d, want only the other function", calls)
+	}
+}
+
+func TestSubscriptionIgnoresLaterFilterChanges(t *testing.T) {
+	var d Dispatcher
+	var calls int
+	filter := NewFilter(nil).WithPath("@things/t1")
+	if _, err := d.SubscribeFilter(filter, func(string, *protocol.Envelope) { calls++ }); err != nil {
+		t.Fatal(err)
+	}
+	filter.WithPath("@things/t2")
+	d.Dispatch("r1", &protocol.Envelope{Path: (&protocol.Path{}).WithThing("t1")})
+	if calls != 1 {
+		t.Fatalf("calls = %d, want 1", calls)
+	}
+}
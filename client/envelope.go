@@ -0,0 +1,33 @@
+package client
+
+import (
+	"github.com/flywave/go-twins/protocol"
+	"github.com/flywave/go-twins/protocol/signals"
+)
+
+func CorrelationId(message *protocol.Envelope) string {
+	if message == nil || message.Headers == nil {
+		return ""
+	}
+	return message.Headers.CorrelationId()
+}
+
+func ReplyTo(message *protocol.Envelope) string {
+	if message == nil || message.Headers == nil {
+		return ""
+	}
+	return message.Headers.ReplyTo()
+}
+
+func IsResponseRequired(message *protocol.Envelope) bool {
+	if message == nil || message.Headers == nil {
+		return false
+	}
+	return message.Headers.IsResponseRequired()
+}
+
+func WithHeaders(message *protocol.Envelope, opts ...signals.HeaderOpt) *protocol.Envelope {
+	res := *message
+	res.Headers = signals.NewHeadersFrom(message.Headers, opts...)
+	return &res
+}
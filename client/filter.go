@@ -0,0 +1,82 @@
+package client
+
+import (
+	"github.com/flywave/go-twins/protocol"
+)
+
+// Filter selects envelopes by topic and path pattern. Empty, "_" and "*"
+// topic segments match any value; the path pattern is matched segment by
+// segment as by protocol.Matcher, so "@things/+/features/#" selects the
+// features of every thing.
+type Filter struct {
+	Topic *protocol.Topic
+	Path  string
+	path  *protocol.Matcher
+}
+
+func NewFilter(topic *protocol.Topic) *Filter {
+	return &Filter{Topic: topic}
+}
+
+func (f *Filter) WithTopic(topic *protocol.Topic) *Filter {
+	f.Topic = topic
+	return f
+}
+
+func (f *Filter) WithPath(pattern string) *Filter {
+	f.Path = pattern
+	f.path = nil
+	return f
+}
+
+// Clone returns a copy of f that shares nothing mutable with it.
+func (f *Filter) Clone() *Filter {
+	if f == nil {
+		return nil
+	}
+	res := &Filter{Path: f.Path, path: f.path}
+	if f.Topic != nil {
+		topic := *f.Topic
+		res.Topic = &topic
+	}
+	return res
+}
+
+func (f *Filter) compile() error {
+	if f == nil || f.Path == "" || f.path != nil {
+		return nil
+	}
+	m, err := protocol.CompileMatcher(f.Path)
+	if err != nil {
+		return err
+	}
+	f.path = m
+	return nil
+}
+
+func (f *Filter) Match(message *protocol.Envelope) bool {
+	if f == nil {
+		return true
+	}
+	if f.Topic != nil {
+		if message.Topic == nil || !message.Topic.MatchTopic(f.Topic) {
+			return false
+		}
+	}
+	if f.Path != "" {
+		if message.Path == nil {
+			return false
+		}
+		m := f.path
+		if m == nil {
+			// Not subscribed, so never compiled; an invalid pattern, which
+			// SubscribeFilter rejects, matches nothing.
+			var err error
+			if m, err = protocol.CompileMatcher(f.Path); err != nil {
+				return false
+			}
+		}
+		return m.MatchPath(message.Path)
+	}
+	return true
+}
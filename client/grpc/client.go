@@ -0,0 +1,197 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/flywave/go-twins/client"
+	"github.com/flywave/go-twins/protocol"
+	"github.com/flywave/go-twins/protocol/pb"
+	"github.com/flywave/go-twins/protocol/signals"
+	gogrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+var ErrNotConnected = errors.New("grpc: not connected")
+
+// Client keeps one bidirectional stream open: Send and Reply write to it and
+// everything the server pushes is dispatched to the handlers. Request uses
+// the unary call instead, bounded by the context deadline.
+type Client struct {
+	client.Dispatcher
+	opts    *Options
+	mu      sync.Mutex
+	conn    *gogrpc.ClientConn
+	service pb.EnvelopeServiceClient
+	stream  pb.EnvelopeService_StreamClient
+	cancel  context.CancelFunc
+	sendMu  sync.Mutex
+	lost    client.ConnectionLostHandler
+}
+
+var (
+	_ client.Client             = (*Client)(nil)
+	_ client.ConnectionNotifier = (*Client)(nil)
+)
+
+func NewClient(opts *Options) *Client {
+	if opts == nil {
+		opts = NewOptions()
+	}
+	return &Client{opts: opts}
+}
+
+func (c *Client) Options() *Options {
+	return c.opts
+}
+
+func (c *Client) SetConnectionLostHandler(handler client.ConnectionLostHandler) {
+	c.mu.Lock()
+	c.lost = handler
+	c.mu.Unlock()
+}
+
+func (c *Client) Connect() error {
+	if c.opts.Target == "" {
+		return errors.New("grpc: no target configured")
+	}
+	dialOpts := append([]gogrpc.DialOption{gogrpc.WithTransportCredentials(insecure.NewCredentials())}, c.opts.DialOptions...)
+	conn, err := gogrpc.NewClient(c.opts.Target, dialOpts...)
+	if err != nil {
+		return err
+	}
+	service := pb.NewEnvelopeServiceClient(conn)
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := service.Stream(ctx)
+	if err != nil {
+		cancel()
+		conn.Close()
+		return err
+	}
+	c.mu.Lock()
+	c.conn = conn
+	c.service = service
+	c.stream = stream
+	c.cancel = cancel
+	c.mu.Unlock()
+	go c.receive(stream)
+	return nil
+}
+
+func (c *Client) Disconnect() {
+	c.mu.Lock()
+	conn, stream, cancel := c.conn, c.stream, c.cancel
+	c.conn = nil
+	c.service = nil
+	c.stream = nil
+	c.cancel = nil
+	c.mu.Unlock()
+	if conn == nil {
+		return
+	}
+	c.sendMu.Lock()
+	stream.CloseSend()
+	c.sendMu.Unlock()
+	cancel()
+	conn.Close()
+}
+
+func (c *Client) Send(message *protocol.Envelope) error {
+	if message.Topic == nil {
+		return errors.New("grpc: envelope without topic")
+	}
+	return c.write(message)
+}
+
+func (c *Client) Reply(requestId string, message *protocol.Envelope) error {
+	return c.write(client.WithHeaders(message, signals.WithCorrelationId(requestId)))
+}
+
+// Request performs the unary call. Error responses are returned as
+// *client.ResponseError, matching client.Requester, so the client can serve
+// as a REST gateway backend.
+func (c *Client) Request(ctx context.Context, message *protocol.Envelope) (*protocol.Envelope, error) {
+	c.mu.Lock()
+	service := c.service
+	c.mu.Unlock()
+	if service == nil {
+		return nil, ErrNotConnected
+	}
+	m, err := pb.FromEnvelope(message)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := ctx.Deadline(); !ok && c.opts.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.opts.RequestTimeout)
+		defer cancel()
+	}
+	reply, err := service.Request(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+	response, err := pb.ToEnvelope(reply)
+	if err != nil {
+		return nil, err
+	}
+	if response.Topic != nil && response.Topic.IsError() {
+		return response, &client.ResponseError{Response: response}
+	}
+	return response, nil
+}
+
+func (c *Client) write(message *protocol.Envelope) error {
+	m, err := pb.FromEnvelope(message)
+	if err != nil {
+		return client.Rejected(err)
+	}
+	c.mu.Lock()
+	stream := c.stream
+	c.mu.Unlock()
+	if stream == nil {
+		return ErrNotConnected
+	}
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	if err := stream.Send(m); err != nil {
+		switch status.Code(err) {
+		case codes.InvalidArgument, codes.ResourceExhausted:
+			return client.Rejected(err)
+		}
+		return err
+	}
+	return nil
+}
+
+func (c *Client) receive(stream pb.EnvelopeService_StreamClient) {
+	for {
+		m, err := stream.Recv()
+		if err != nil {
+			c.mu.Lock()
+			current := c.stream == stream
+			if current {
+				c.stream = nil
+				c.service = nil
+			}
+			lost := c.lost
+			c.mu.Unlock()
+			if current && lost != nil {
+				lost(err)
+			}
+			return
+		}
+		message, err := pb.ToEnvelope(m)
+		if err != nil {
+			continue
+		}
+		requestId := client.CorrelationId(message)
+		if requestId == "" && client.IsResponseRequired(message) {
+			requestId = client.NewRequestId()
+			message = client.WithHeaders(message, signals.WithCorrelationId(requestId))
+		}
+		c.Dispatch(requestId, message)
+	}
+}
@@ -0,0 +1,119 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/flywave/go-twins/client"
+	"github.com/flywave/go-twins/protocol"
+	gogrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func commandTopic() *protocol.Topic {
+	return &protocol.Topic{TenantName: "t", ChannelName: "c", Entity: protocol.EntityThings, Criterion: protocol.CriterionCommands, Action: protocol.ActionCreateOrModify}
+}
+
+// startServer serves a Server that answers every request over bufconn and
+// returns a client connected to it.
+func startServer(t *testing.T) (*Server, *Client) {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := NewServer(nil)
+	srv.Subscribe(func(requestId string, message *protocol.Envelope) {
+		if !client.IsResponseRequired(message) {
+			return
+		}
+		res := &protocol.Envelope{Topic: commandTopic(), Path: message.Path, Status: http.StatusNoContent}
+		if err := srv.Reply(requestId, res); err != nil {
+			t.Error(err)
+		}
+	})
+	gs := gogrpc.NewServer()
+	srv.Register(gs)
+	go gs.Serve(lis)
+	t.Cleanup(gs.Stop)
+
+	c := NewClient(NewOptions().WithTarget("passthrough:///bufnet").WithDialOption(gogrpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return lis.DialContext(ctx)
+	})))
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(c.Disconnect)
+	return srv, c
+}
+
+func testCommand() *protocol.Envelope {
+	return &protocol.Envelope{
+		Topic: commandTopic(),
+		Path:  (&protocol.Path{}).WithThingAttribute("thing1", "location"),
+		Value: "here",
+	}
+}
+
+func TestUnaryRequest(t *testing.T) {
+	_, c := startServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	res, err := c.Request(ctx, testCommand())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != http.StatusNoContent {
+		t.Fatalf("status %d, want %d", res.Status, http.StatusNoContent)
+	}
+}
+
+func TestStreamRequestReply(t *testing.T) {
+	srv, c := startServer(t)
+	r := client.NewRequester(c)
+	defer r.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	res, err := r.Request(ctx, testCommand())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != http.StatusNoContent {
+		t.Fatalf("status %d, want %d", res.Status, http.StatusNoContent)
+	}
+	if n := srv.routes.Len(); n != 0 {
+		t.Fatalf("%d reply routes left after replying", n)
+	}
+}
+
+func TestServerSendReachesStream(t *testing.T) {
+	srv, c := startServer(t)
+	received := make(chan *protocol.Envelope, 1)
+	c.Subscribe(func(_ string, message *protocol.Envelope) {
+		select {
+		case received <- message:
+		default:
+		}
+	})
+
+	// the stream registers on the server asynchronously
+	event := &protocol.Envelope{Topic: commandTopic().WithCriterion(protocol.CriterionEvents).WithAction(protocol.ActionModified)}
+	deadline := time.After(5 * time.Second)
+	tick := time.NewTicker(20 * time.Millisecond)
+	defer tick.Stop()
+	for {
+		if err := srv.Send(event); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case message := <-received:
+			if message.Topic.String() != event.Topic.String() {
+				t.Fatalf("received %s, want %s", message.Topic, event.Topic)
+			}
+			return
+		case <-tick.C:
+		case <-deadline:
+			t.Fatal("no envelope pushed to the stream")
+		}
+	}
+}
@@ -0,0 +1,25 @@
+module github.com/flywave/go-twins/client/grpc
+
+go 1.24.0
+
+require (
+	github.com/flywave/go-twins v0.0.0-00010101000000-000000000000
+	github.com/flywave/go-twins/protocol/pb v0.0.0-00010101000000-000000000000
+	google.golang.org/grpc v1.79.1
+)
+
+require (
+	github.com/fxamacker/cbor/v2 v2.9.0 // indirect
+	github.com/vmihailenco/msgpack/v5 v5.4.1 // indirect
+	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
+	github.com/x448/float16 v0.8.4 // indirect
+	golang.org/x/net v0.48.0 // indirect
+	golang.org/x/sys v0.40.0 // indirect
+	golang.org/x/text v0.33.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20251202230838-ff82c1b0f217 // indirect
+	google.golang.org/protobuf v1.36.10 // indirect
+)
+
+replace github.com/flywave/go-twins => ../..
+
+replace github.com/flywave/go-twins/protocol/pb => ../../protocol/pb
@@ -0,0 +1,58 @@
+package grpc
+
+import (
+	"time"
+
+	"github.com/flywave/go-twins/client"
+	gogrpc "google.golang.org/grpc"
+)
+
+const (
+	DefaultRequestTimeout = 10 * time.Second
+	DefaultSendBuffer     = 256
+)
+
+type Options struct {
+	Target         string
+	DialOptions    []gogrpc.DialOption
+	RequestTimeout time.Duration
+	SendBuffer     int
+	// ReplyTTL bounds how long the server keeps the stream a request
+	// without a timeout header came from for Reply.
+	ReplyTTL time.Duration
+}
+
+func NewOptions() *Options {
+	return &Options{
+		RequestTimeout: DefaultRequestTimeout,
+		SendBuffer:     DefaultSendBuffer,
+		ReplyTTL:       client.DefaultReplyTTL,
+	}
+}
+
+func (o *Options) WithTarget(target string) *Options {
+	o.Target = target
+	return o
+}
+
+// WithDialOption adds a dial option. The client dials with insecure
+// transport credentials unless an option supplies others.
+func (o *Options) WithDialOption(opt gogrpc.DialOption) *Options {
+	o.DialOptions = append(o.DialOptions, opt)
+	return o
+}
+
+func (o *Options) WithRequestTimeout(timeout time.Duration) *Options {
+	o.RequestTimeout = timeout
+	return o
+}
+
+func (o *Options) WithSendBuffer(size int) *Options {
+	o.SendBuffer = size
+	return o
+}
+
+func (o *Options) WithReplyTTL(ttl time.Duration) *Options {
+	o.ReplyTTL = ttl
+	return o
+}
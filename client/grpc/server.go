@@ -0,0 +1,126 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/flywave/go-twins/client"
+	"github.com/flywave/go-twins/protocol"
+	"github.com/flywave/go-twins/protocol/pb"
+	"github.com/flywave/go-twins/protocol/signals"
+	gogrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	ErrUnknownRequest = errors.New("grpc: unknown request id")
+	ErrServerClosed   = errors.New("grpc: server closed")
+)
+
+// Server implements the envelope service on top of a handler set: envelopes
+// arriving through Send, Request or Stream are dispatched to the subscribed
+// handlers, whose Reply calls answer unary requests and stream peers. Send
+// pushes to every open stream.
+type Server struct {
+	client.Dispatcher
+	opts    *Options
+	mu      sync.Mutex
+	streams map[*stream]struct{}
+	routes  *client.Routes
+	pending map[string]chan *protocol.Envelope
+}
+
+type stream struct {
+	out    chan *pb.Envelope
+	cancel context.CancelCauseFunc
+}
+
+var _ client.Client = (*Server)(nil)
+
+func NewServer(opts *Options) *Server {
+	if opts == nil {
+		opts = NewOptions()
+	}
+	return &Server{
+		opts:    opts,
+		streams: make(map[*stream]struct{}),
+		routes:  client.NewRoutes(opts.ReplyTTL),
+		pending: make(map[string]chan *protocol.Envelope),
+	}
+}
+
+func (s *Server) Register(registrar gogrpc.ServiceRegistrar) {
+	pb.RegisterEnvelopeServiceServer(registrar, s.Service())
+}
+
+// Service returns the generated service implementation; it is a separate
+// value because its Send method clashes with client.Client.
+func (s *Server) Service() pb.EnvelopeServiceServer {
+	return &service{server: s}
+}
+
+func (s *Server) Connect() error {
+	return nil
+}
+
+// Disconnect ends every open stream; the gRPC server itself is left to its
+// owner.
+func (s *Server) Disconnect() {
+	s.mu.Lock()
+	streams := s.streams
+	s.streams = make(map[*stream]struct{})
+	s.mu.Unlock()
+	s.routes.Reset()
+	for st := range streams {
+		st.cancel(ErrServerClosed)
+	}
+}
+
+func (s *Server) Send(message *protocol.Envelope) error {
+	if message.Topic == nil {
+		return errors.New("grpc: envelope without topic")
+	}
+	m, err := pb.FromEnvelope(message)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for st := range s.streams {
+		st.send(m)
+	}
+	return nil
+}
+
+func (s *Server) Reply(requestId string, message *protocol.Envelope) error {
+	message = client.WithHeaders(message, signals.WithCorrelationId(requestId))
+	s.mu.Lock()
+	pending, ok := s.pending[requestId]
+	delete(s.pending, requestId)
+	s.mu.Unlock()
+	if ok {
+		pending <- message
+		return nil
+	}
+	route, routed := s.routes.Take(requestId)
+	if !routed {
+		return ErrUnknownRequest
+	}
+	st := route.(*stream)
+	m, err := pb.FromEnvelope(message)
+	if err != nil {
+		return err
+	}
+	st.send(m)
+	return nil
+}
+
+func (st *stream) send(m *pb.Envelope) {
+	select {
+	case st.out <- m:
+	default:
+		st.cancel(status.Error(codes.ResourceExhausted, "grpc: stream send buffer full"))
+	}
+}
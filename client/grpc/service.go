@@ -0,0 +1,131 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/flywave/go-twins/client"
+	"github.com/flywave/go-twins/protocol"
+	"github.com/flywave/go-twins/protocol/pb"
+	"github.com/flywave/go-twins/protocol/signals"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type service struct {
+	pb.UnimplementedEnvelopeServiceServer
+	server *Server
+}
+
+func (svc *service) Send(ctx context.Context, request *pb.Envelope) (*pb.SendResponse, error) {
+	message, err := decode(request)
+	if err != nil {
+		return nil, err
+	}
+	svc.server.Dispatch(client.CorrelationId(message), message)
+	return &pb.SendResponse{}, nil
+}
+
+// Request dispatches the envelope and waits for the handler's Reply until
+// the call's deadline.
+func (svc *service) Request(ctx context.Context, request *pb.Envelope) (*pb.Envelope, error) {
+	message, err := decode(request)
+	if err != nil {
+		return nil, err
+	}
+	requestId := client.CorrelationId(message)
+	if requestId == "" {
+		requestId = client.NewRequestId()
+	}
+	message = client.WithHeaders(message, signals.WithCorrelationId(requestId), signals.WithResponseRequired(true))
+
+	s := svc.server
+	reply := make(chan *protocol.Envelope, 1)
+	s.mu.Lock()
+	s.pending[requestId] = reply
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.pending, requestId)
+		s.mu.Unlock()
+	}()
+
+	s.Dispatch(requestId, message)
+	select {
+	case response := <-reply:
+		m, err := pb.FromEnvelope(response)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		return m, nil
+	case <-ctx.Done():
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+}
+
+func (svc *service) Stream(ss pb.EnvelopeService_StreamServer) error {
+	s := svc.server
+	ctx, cancel := context.WithCancelCause(ss.Context())
+	defer cancel(nil)
+	st := &stream{out: make(chan *pb.Envelope, s.opts.SendBuffer), cancel: cancel}
+	s.mu.Lock()
+	s.streams[st] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.streams, st)
+		s.mu.Unlock()
+		s.routes.Drop(st)
+	}()
+
+	go func() {
+		for {
+			request, err := ss.Recv()
+			if err != nil {
+				cancel(err)
+				return
+			}
+			message, err := decode(request)
+			if err != nil {
+				continue
+			}
+			requestId := client.CorrelationId(message)
+			if client.IsResponseRequired(message) {
+				if requestId == "" {
+					requestId = client.NewRequestId()
+					message = client.WithHeaders(message, signals.WithCorrelationId(requestId))
+				}
+				s.routes.Put(requestId, message, st)
+			}
+			s.Dispatch(requestId, message)
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			err := context.Cause(ctx)
+			if err == context.Canceled || err == ErrServerClosed {
+				return nil
+			}
+			if _, ok := status.FromError(err); ok {
+				return err
+			}
+			return nil
+		case m := <-st.out:
+			if err := ss.Send(m); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func decode(m *pb.Envelope) (*protocol.Envelope, error) {
+	message, err := pb.ToEnvelope(m)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if message.Topic == nil {
+		return nil, status.Error(codes.InvalidArgument, "grpc: envelope without topic")
+	}
+	return message, nil
+}
@@ -0,0 +1,197 @@
+package client
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/flywave/go-twins/protocol"
+)
+
+const indexTopicLevels = 5
+
+// SubscriptionIndex is a trie over the five topic segments followed by the
+// path segments of subscription filters. Match walks the literal and the
+// wildcard branch of each level, so its cost grows with the depth of the
+// envelope's path rather than with the number of subscriptions. It only
+// narrows the candidates: the filters of the subscriptions it returns are
+// checked once more, which keeps glob segments such as "c*" correct.
+type SubscriptionIndex struct {
+	root indexNode
+	seq  uint64
+	keys map[*Subscription][]string
+}
+
+type indexNode struct {
+	children map[string]*indexNode
+	wildcard *indexNode
+	// rest holds subscriptions ending in "#" or without a path pattern;
+	// they match any number of remaining segments.
+	rest []*Subscription
+	subs []*Subscription
+}
+
+const (
+	indexWildcard = "\x00+"
+	indexRest     = "\x00#"
+)
+
+func NewSubscriptionIndex() *SubscriptionIndex {
+	return &SubscriptionIndex{keys: make(map[*Subscription][]string)}
+}
+
+func (x *SubscriptionIndex) Len() int {
+	return len(x.keys)
+}
+
+// Add indexes sub. It fails for path patterns the index cannot hold, which
+// are invalid patterns anyway.
+func (x *SubscriptionIndex) Add(sub *Subscription) error {
+	keys, err := indexKeys(sub.filter)
+	if err != nil {
+		return err
+	}
+	x.seq++
+	sub.seq = x.seq
+	x.keys[sub] = keys
+	n := &x.root
+	for _, key := range keys {
+		switch key {
+		case indexRest:
+			n.rest = append(n.rest, sub)
+			return nil
+		case indexWildcard:
+			if n.wildcard == nil {
+				n.wildcard = &indexNode{}
+			}
+			n = n.wildcard
+		default:
+			if n.children == nil {
+				n.children = make(map[string]*indexNode)
+			}
+			child := n.children[key]
+			if child == nil {
+				child = &indexNode{}
+				n.children[key] = child
+			}
+			n = child
+		}
+	}
+	n.subs = append(n.subs, sub)
+	return nil
+}
+
+func (x *SubscriptionIndex) Remove(sub *Subscription) {
+	keys, ok := x.keys[sub]
+	if !ok {
+		return
+	}
+	delete(x.keys, sub)
+	x.root.remove(sub, keys)
+}
+
+// remove reports whether n became empty so the parent can drop it.
+func (n *indexNode) remove(sub *Subscription, keys []string) bool {
+	if len(keys) == 0 {
+		n.subs = removeSubscription(n.subs, sub)
+	} else {
+		switch key := keys[0]; key {
+		case indexRest:
+			n.rest = removeSubscription(n.rest, sub)
+		case indexWildcard:
+			if n.wildcard != nil && n.wildcard.remove(sub, keys[1:]) {
+				n.wildcard = nil
+			}
+		default:
+			if child := n.children[key]; child != nil && child.remove(sub, keys[1:]) {
+				delete(n.children, key)
+			}
+		}
+	}
+	return len(n.children) == 0 && n.wildcard == nil && len(n.rest) == 0 && len(n.subs) == 0
+}
+
+func removeSubscription(subs []*Subscription, sub *Subscription) []*Subscription {
+	for i, s := range subs {
+		if s == sub {
+			return append(subs[:i:i], subs[i+1:]...)
+		}
+	}
+	return subs
+}
+
+// Match returns the subscriptions whose filter matches message, in the
+// order they were added.
+func (x *SubscriptionIndex) Match(message *protocol.Envelope) []*Subscription {
+	var candidates []*Subscription
+	x.root.collect(messageKeys(message), &candidates)
+	res := candidates[:0]
+	for _, sub := range candidates {
+		if sub.filter.Match(message) {
+			res = append(res, sub)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].seq < res[j].seq })
+	return res
+}
+
+func (n *indexNode) collect(keys []string, out *[]*Subscription) {
+	*out = append(*out, n.rest...)
+	if len(keys) == 0 {
+		*out = append(*out, n.subs...)
+		return
+	}
+	if child := n.children[keys[0]]; child != nil {
+		child.collect(keys[1:], out)
+	}
+	if n.wildcard != nil {
+		n.wildcard.collect(keys[1:], out)
+	}
+}
+
+func indexKeys(filter *Filter) ([]string, error) {
+	keys := make([]string, 0, indexTopicLevels+1)
+	if filter == nil || filter.Topic == nil {
+		for i := 0; i < indexTopicLevels; i++ {
+			keys = append(keys, indexWildcard)
+		}
+	} else {
+		t := filter.Topic
+		for _, segment := range []string{t.TenantName, t.ChannelName, string(t.Entity), string(t.Criterion), string(t.Action)} {
+			switch segment {
+			case "", protocol.TopicPlaceholder, "*":
+				keys = append(keys, indexWildcard)
+			default:
+				keys = append(keys, segment)
+			}
+		}
+	}
+	if filter == nil || filter.Path == "" {
+		return append(keys, indexRest), nil
+	}
+	segments := strings.Split(filter.Path, "/")
+	for i, segment := range segments {
+		switch {
+		case segment == protocol.WildcardMultiLevel:
+			if i != len(segments)-1 {
+				return nil, protocol.ErrInvalidPattern
+			}
+			keys = append(keys, indexRest)
+		case segment == protocol.WildcardSingleLevel, segment == protocol.TopicPlaceholder, strings.Contains(segment, "*"):
+			keys = append(keys, indexWildcard)
+		default:
+			keys = append(keys, segment)
+		}
+	}
+	return keys, nil
+}
+
+func messageKeys(message *protocol.Envelope) []string {
+	keys := make([]string, indexTopicLevels, indexTopicLevels+8)
+	if t := message.Topic; t != nil {
+		keys[0], keys[1], keys[2], keys[3], keys[4] = t.TenantName, t.ChannelName, string(t.Entity), string(t.Criterion), string(t.Action)
+	}
+	if message.Path != nil && !message.Path.Empty() {
+		keys = append(keys, strings.Split(message.Path.String(), "/")...)
+	}
+	return keys
+}
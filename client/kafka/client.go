@@ -0,0 +1,229 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/flywave/go-twins/client"
+	"github.com/flywave/go-twins/protocol"
+	"github.com/flywave/go-twins/protocol/signals"
+	"github.com/segmentio/kafka-go"
+)
+
+var (
+	ErrNotConnected   = errors.New("kafka: not connected")
+	ErrUnknownRequest = errors.New("kafka: unknown request id")
+)
+
+type Client struct {
+	client.Dispatcher
+	opts    *Options
+	mu      sync.Mutex
+	writer  *kafka.Writer
+	readers []*kafka.Reader
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	routes  *client.Routes
+}
+
+var _ client.Client = (*Client)(nil)
+
+func NewClient(opts *Options) *Client {
+	if opts == nil {
+		opts = NewOptions()
+	}
+	if opts.ClientId == "" {
+		opts.ClientId = "twins-" + client.NewRequestId()[:12]
+	}
+	if opts.ReplyTopic == "" {
+		opts.ReplyTopic = opts.Topic + DefaultReplyTopicSuffix
+	}
+	return &Client{opts: opts, routes: client.NewRoutes(opts.ReplyTTL)}
+}
+
+func (c *Client) Options() *Options {
+	return c.opts
+}
+
+func (c *Client) Connect() error {
+	if len(c.opts.Brokers) == 0 {
+		return errors.New("kafka: no broker configured")
+	}
+	dialer := &kafka.Dialer{
+		ClientID:  c.opts.ClientId,
+		Timeout:   c.opts.DialTimeout,
+		DualStack: true,
+		TLS:       c.opts.TLSConfig,
+	}
+	transport := &kafka.Transport{ClientID: c.opts.ClientId, DialTimeout: c.opts.DialTimeout, TLS: c.opts.TLSConfig}
+	writer := &kafka.Writer{
+		Addr:                   kafka.TCP(c.opts.Brokers...),
+		Balancer:               &balancer{},
+		BatchTimeout:           c.opts.BatchTimeout,
+		RequiredAcks:           kafka.RequireAll,
+		AllowAutoTopicCreation: true,
+		Transport:              transport,
+	}
+	events := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     c.opts.Brokers,
+		GroupID:     c.groupId(),
+		Topic:       c.opts.Topic,
+		Dialer:      dialer,
+		StartOffset: kafka.LastOffset,
+	})
+	replies := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:   c.opts.Brokers,
+		Topic:     c.opts.ReplyTopic,
+		Partition: replyPartition,
+		Dialer:    dialer,
+	})
+	if err := replies.SetOffset(kafka.LastOffset); err != nil {
+		writer.Close()
+		events.Close()
+		replies.Close()
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.mu.Lock()
+	c.writer = writer
+	c.readers = []*kafka.Reader{events, replies}
+	c.cancel = cancel
+	c.mu.Unlock()
+
+	c.wg.Add(2)
+	go c.consume(ctx, events, true)
+	go c.consume(ctx, replies, false)
+	return nil
+}
+
+// Without a group id every client reads the whole topic through a group of
+// its own, so offsets are still committed.
+func (c *Client) groupId() string {
+	if c.opts.GroupId != "" {
+		return c.opts.GroupId
+	}
+	return c.opts.ClientId
+}
+
+func (c *Client) Disconnect() {
+	c.mu.Lock()
+	writer, readers, cancel := c.writer, c.readers, c.cancel
+	c.writer = nil
+	c.readers = nil
+	c.cancel = nil
+	c.mu.Unlock()
+	c.routes.Reset()
+	if cancel == nil {
+		return
+	}
+	cancel()
+	c.wg.Wait()
+	for _, r := range readers {
+		r.Close()
+	}
+	writer.Close()
+}
+
+func (c *Client) Send(message *protocol.Envelope) error {
+	if message.Topic == nil {
+		return errors.New("kafka: envelope without topic")
+	}
+	if client.IsResponseRequired(message) && client.ReplyTo(message) == "" {
+		message = client.WithHeaders(message, signals.WithReplyTo(ReplyAddress(c.opts.ReplyTopic, c.opts.ClientId)))
+	}
+	return c.write(c.opts.Topic, "", message)
+}
+
+func (c *Client) Reply(requestId string, message *protocol.Envelope) error {
+	var replyTo string
+	if route, ok := c.routes.Take(requestId); ok {
+		replyTo = route.(string)
+	} else {
+		replyTo = client.ReplyTo(message)
+	}
+	if replyTo == "" {
+		return ErrUnknownRequest
+	}
+	message = client.WithHeaders(message, signals.WithCorrelationId(requestId))
+	topic, recipient := ParseReplyAddress(replyTo)
+	return c.write(topic, recipient, message)
+}
+
+// write sends message to topic; a reply to a Kafka client names its
+// recipient, see ReplyAddress.
+func (c *Client) write(topic, recipient string, message *protocol.Envelope) error {
+	c.mu.Lock()
+	writer := c.writer
+	c.mu.Unlock()
+	if writer == nil {
+		return ErrNotConnected
+	}
+	record, err := encodeRecord(c.opts.Codec, topic, message)
+	if err != nil {
+		return client.Rejected(err)
+	}
+	if recipient != "" {
+		record.Headers = append(record.Headers, kafka.Header{Key: recipientHeader, Value: []byte(recipient)})
+	}
+	err = writer.WriteMessages(context.Background(), record)
+	if errs, ok := err.(kafka.WriteErrors); ok && len(errs) == 1 {
+		err = errs[0]
+	}
+	if errors.Is(err, kafka.MessageSizeTooLarge) || errors.Is(err, kafka.InvalidMessageSize) {
+		return client.Rejected(err)
+	}
+	return err
+}
+
+// recordReader is the part of *kafka.Reader consume uses.
+type recordReader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	ReadMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+// consume hands records to the handlers one at a time and commits a record's
+// offset only after they returned, so a crash redelivers it. The reply
+// topic is shared and read without committing; replies to other clients
+// are skipped.
+func (c *Client) consume(ctx context.Context, reader recordReader, commit bool) {
+	defer c.wg.Done()
+	for {
+		var record kafka.Message
+		var err error
+		if commit {
+			record, err = reader.FetchMessage(ctx)
+		} else {
+			record, err = reader.ReadMessage(ctx)
+		}
+		if err != nil {
+			return
+		}
+		if !commit && recordRecipient(record) != c.opts.ClientId {
+			continue
+		}
+		if message, err := decodeRecord(record); err == nil {
+			c.receive(message, commit)
+		}
+		if commit {
+			if err := reader.CommitMessages(ctx, record); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (c *Client) receive(message *protocol.Envelope, routable bool) {
+	requestId := client.CorrelationId(message)
+	replyTo := client.ReplyTo(message)
+	if requestId == "" && replyTo != "" {
+		requestId = client.NewRequestId()
+		message = client.WithHeaders(message, signals.WithCorrelationId(requestId))
+	}
+	if routable && replyTo != "" {
+		c.routes.Put(requestId, message, replyTo)
+	}
+	c.Dispatch(requestId, message)
+}
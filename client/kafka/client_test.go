@@ -0,0 +1,130 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/flywave/go-twins/protocol"
+	"github.com/flywave/go-twins/protocol/signals"
+	"github.com/segmentio/kafka-go"
+)
+
+// fakeReader serves records and logs fetches and commits to a shared log.
+type fakeReader struct {
+	mu      sync.Mutex
+	records []kafka.Message
+	log     *[]string
+}
+
+func (r *fakeReader) next(ctx context.Context) (kafka.Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.records) == 0 {
+		return kafka.Message{}, context.Canceled
+	}
+	record := r.records[0]
+	r.records = r.records[1:]
+	return record, nil
+}
+
+func (r *fakeReader) FetchMessage(ctx context.Context) (kafka.Message, error) { return r.next(ctx) }
+func (r *fakeReader) ReadMessage(ctx context.Context) (kafka.Message, error)  { return r.next(ctx) }
+
+func (r *fakeReader) CommitMessages(ctx context.Context, msgs ...kafka.Message) error {
+	for _, m := range msgs {
+		*r.log = append(*r.log, fmt.Sprintf("commit %d", m.Offset))
+	}
+	return nil
+}
+
+func testRecord(t *testing.T, offset int64, headers ...signals.HeaderOpt) kafka.Message {
+	t.Helper()
+	record, err := encodeRecord(nil, DefaultTopic, &protocol.Envelope{
+		Topic:   &protocol.Topic{TenantName: "t", ChannelName: "c", Entity: protocol.EntityThings, Criterion: protocol.CriterionEvents, Action: protocol.ActionModified},
+		Headers: signals.NewHeaders(append(headers, signals.WithCorrelationId(fmt.Sprint(offset)))...),
+		Path:    (&protocol.Path{}).WithThing("thing1"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	record.Offset = offset
+	return record
+}
+
+func TestCommitAfterHandle(t *testing.T) {
+	var log []string
+	c := NewClient(NewOptions())
+	c.Subscribe(func(requestId string, message *protocol.Envelope) {
+		log = append(log, "handle "+requestId)
+	})
+	reader := &fakeReader{log: &log}
+	for i := int64(1); i <= 3; i++ {
+		reader.records = append(reader.records, testRecord(t, i))
+	}
+	undecodable := kafka.Message{Offset: 4, Value: []byte("not an envelope")}
+	reader.records = append(reader.records, undecodable)
+
+	c.wg.Add(1)
+	c.consume(context.Background(), reader, true)
+
+	want := []string{"handle 1", "commit 1", "handle 2", "commit 2", "handle 3", "commit 3", "commit 4"}
+	if fmt.Sprint(log) != fmt.Sprint(want) {
+		t.Fatalf("log %v, want %v", log, want)
+	}
+}
+
+func TestRepliesToOtherClientsAreSkipped(t *testing.T) {
+	var handled []string
+	c := NewClient(NewOptions().WithClientId("me"))
+	c.Subscribe(func(requestId string, message *protocol.Envelope) {
+		handled = append(handled, requestId)
+	})
+	mine, other, plain := testRecord(t, 1), testRecord(t, 2), testRecord(t, 3)
+	mine.Headers = append(mine.Headers, kafka.Header{Key: recipientHeader, Value: []byte("me")})
+	other.Headers = append(other.Headers, kafka.Header{Key: recipientHeader, Value: []byte("you")})
+	var log []string
+	c.wg.Add(1)
+	c.consume(context.Background(), &fakeReader{records: []kafka.Message{mine, other, plain}, log: &log}, false)
+
+	if fmt.Sprint(handled) != "[1]" || len(log) != 0 {
+		t.Fatalf("handled %v, committed %v", handled, log)
+	}
+}
+
+func TestReplyAddress(t *testing.T) {
+	c := NewClient(NewOptions().WithClientId("me"))
+	if c.opts.ReplyTopic != "twins.replies" {
+		t.Fatalf("reply topic %q", c.opts.ReplyTopic)
+	}
+	address := ReplyAddress(c.opts.ReplyTopic, c.opts.ClientId)
+	if topic, id := ParseReplyAddress(address); topic != "twins.replies" || id != "me" {
+		t.Fatalf("parsed %q, %q", topic, id)
+	}
+	if topic, id := ParseReplyAddress("plain.topic"); topic != "plain.topic" || id != "" {
+		t.Fatalf("parsed %q, %q", topic, id)
+	}
+
+	b := &balancer{}
+	reply := kafka.Message{Key: []byte("thing1"), Headers: []kafka.Header{{Key: recipientHeader, Value: []byte("me")}}}
+	for i := 0; i < 10; i++ {
+		if p := b.Balance(reply, 0, 1, 2, 3); p != replyPartition {
+			t.Fatalf("reply written to partition %d", p)
+		}
+	}
+	event := kafka.Message{Key: []byte("thing1")}
+	if b.Balance(event, 0, 1, 2, 3) != b.Balance(event, 0, 1, 2, 3) {
+		t.Fatal("events of one twin spread over partitions")
+	}
+
+	record := testRecord(t, 1)
+	record.Headers = append(record.Headers, kafka.Header{Key: recipientHeader, Value: []byte("me")})
+	decoded, err := decodeRecord(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := decoded.Headers.Get(recipientHeader); ok {
+		t.Fatal("recipient header leaked into the envelope")
+	}
+}
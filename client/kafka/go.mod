@@ -0,0 +1,19 @@
+module github.com/flywave/go-twins/client/kafka
+
+go 1.24.0
+
+require (
+	github.com/flywave/go-twins v0.0.0-00010101000000-000000000000
+	github.com/segmentio/kafka-go v0.4.50
+)
+
+require (
+	github.com/fxamacker/cbor/v2 v2.9.0 // indirect
+	github.com/klauspost/compress v1.18.3 // indirect
+	github.com/pierrec/lz4/v4 v4.1.15 // indirect
+	github.com/vmihailenco/msgpack/v5 v5.4.1 // indirect
+	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
+	github.com/x448/float16 v0.8.4 // indirect
+)
+
+replace github.com/flywave/go-twins => ../..
@@ -0,0 +1,99 @@
+package kafka
+
+import (
+	"crypto/tls"
+	"time"
+
+	"github.com/flywave/go-twins/client"
+	"github.com/flywave/go-twins/protocol"
+)
+
+const (
+	DefaultTopic        = "twins"
+	DefaultBatchTimeout = 10 * time.Millisecond
+	DefaultDialTimeout  = 10 * time.Second
+	// DefaultReplyTopicSuffix names the reply topic after the topic when
+	// none is configured, e.g. "twins.replies".
+	DefaultReplyTopicSuffix = ".replies"
+)
+
+type Options struct {
+	Brokers  []string
+	ClientId string
+	Topic    string
+	GroupId  string
+	// ReplyTopic receives the replies to this client's requests. It is
+	// shared by every client with the same setting, so it is created once
+	// rather than per client; each client skips the replies to others.
+	ReplyTopic   string
+	BatchTimeout time.Duration
+	DialTimeout  time.Duration
+	// Codec encodes outgoing envelopes; nil means protocol.DefaultCodec().
+	// Incoming envelopes are decoded by the content type they arrive with.
+	Codec protocol.Codec
+	// ReplyTTL bounds how long the reply topic of a received request
+	// without a timeout header is kept for Reply.
+	ReplyTTL  time.Duration
+	TLSConfig *tls.Config
+}
+
+func NewOptions() *Options {
+	return &Options{
+		Topic:        DefaultTopic,
+		BatchTimeout: DefaultBatchTimeout,
+		DialTimeout:  DefaultDialTimeout,
+		ReplyTTL:     client.DefaultReplyTTL,
+	}
+}
+
+func (o *Options) WithBroker(address string) *Options {
+	o.Brokers = append(o.Brokers, address)
+	return o
+}
+
+func (o *Options) WithClientId(id string) *Options {
+	o.ClientId = id
+	return o
+}
+
+func (o *Options) WithTopic(topic string) *Options {
+	o.Topic = topic
+	return o
+}
+
+// WithGroupId makes clients with the same id share the partitions of the
+// topic; offsets are committed per group.
+func (o *Options) WithGroupId(id string) *Options {
+	o.GroupId = id
+	return o
+}
+
+func (o *Options) WithReplyTopic(topic string) *Options {
+	o.ReplyTopic = topic
+	return o
+}
+
+func (o *Options) WithBatchTimeout(timeout time.Duration) *Options {
+	o.BatchTimeout = timeout
+	return o
+}
+
+func (o *Options) WithDialTimeout(timeout time.Duration) *Options {
+	o.DialTimeout = timeout
+	return o
+}
+
+func (o *Options) WithCodec(codec protocol.Codec) *Options {
+	o.Codec = codec
+	return o
+}
+
+func (o *Options) WithReplyTTL(ttl time.Duration) *Options {
+	o.ReplyTTL = ttl
+	return o
+}
+
+func (o *Options) WithTLSConfig(config *tls.Config) *Options {
+	o.TLSConfig = config
+	return o
+}
@@ -0,0 +1,154 @@
+package kafka
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/flywave/go-twins/protocol"
+	"github.com/segmentio/kafka-go"
+)
+
+// codecHeader carries the content type of the record value, which is the
+// envelope without its headers; the envelope headers travel as the other
+// record headers.
+const codecHeader = "twins-codec"
+
+// recipientHeader carries the client id of the requester on a reply, so
+// that the clients sharing a reply topic each pick out their own replies.
+const recipientHeader = "twins-recipient"
+
+// replyPartition is the partition of the reply topic replies are written to
+// and read from, so that a reply topic needs no consumer group.
+const replyPartition = 0
+
+// ReplyAddress returns the reply-to header value of a request: the reply
+// topic and the client id of the requester, separated by ":", which topic
+// names cannot contain.
+func ReplyAddress(topic, clientId string) string {
+	return topic + ":" + clientId
+}
+
+// ParseReplyAddress splits a reply-to header value into the reply topic and
+// the requester's client id, which is empty for a plain topic.
+func ParseReplyAddress(address string) (topic, clientId string) {
+	topic, clientId, _ = strings.Cut(address, ":")
+	return topic, clientId
+}
+
+func recordRecipient(record kafka.Message) string {
+	for _, h := range record.Headers {
+		if h.Key == recipientHeader {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+// balancer keys records by twin name, see RecordKey, and writes replies to
+// the partition the reply readers consume.
+type balancer struct {
+	hash kafka.Hash
+}
+
+func (b *balancer) Balance(record kafka.Message, partitions ...int) int {
+	if recordRecipient(record) != "" {
+		for _, p := range partitions {
+			if p == replyPartition {
+				return p
+			}
+		}
+	}
+	return b.hash.Balance(record, partitions...)
+}
+
+// RecordKey returns the thing or device name addressed by the envelope path,
+// so every record of one twin lands in the same partition.
+func RecordKey(message *protocol.Envelope) []byte {
+	if message == nil || message.Path == nil || message.Path.Empty() {
+		return nil
+	}
+	if message.Path.EntityType() == protocol.EntityUnknown {
+		return nil
+	}
+	segments := strings.SplitN(strings.TrimPrefix(message.Path.String(), "@"), "/", 3)
+	if len(segments) < 2 || segments[1] == "" {
+		return nil
+	}
+	return []byte(segments[1])
+}
+
+// Strings travel raw unless they would read back as another JSON value;
+// everything else is JSON encoded, which keeps the mapping lossless.
+func recordHeaders(headers *protocol.Headers) []kafka.Header {
+	if headers == nil {
+		return nil
+	}
+	res := make([]kafka.Header, 0, len(headers.Values))
+	for k, v := range headers.Values {
+		var value []byte
+		if s, ok := v.(string); ok && !json.Valid([]byte(s)) {
+			value = []byte(s)
+		} else {
+			buf, err := json.Marshal(v)
+			if err != nil {
+				continue
+			}
+			value = buf
+		}
+		res = append(res, kafka.Header{Key: k, Value: value})
+	}
+	return res
+}
+
+func envelopeHeaders(headers []kafka.Header) *protocol.Headers {
+	if len(headers) == 0 {
+		return nil
+	}
+	values := make(map[string]interface{}, len(headers))
+	for _, h := range headers {
+		if h.Key == codecHeader || h.Key == recipientHeader {
+			continue
+		}
+		var v interface{}
+		if err := json.Unmarshal(h.Value, &v); err != nil {
+			v = string(h.Value)
+		}
+		values[h.Key] = v
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	return protocol.NewHeadersWithValues(values)
+}
+
+func encodeRecord(codec protocol.Codec, topic string, message *protocol.Envelope) (kafka.Message, error) {
+	body := *message
+	body.Headers = nil
+	contentType, payload, err := protocol.EncodeEnvelope(codec, &body)
+	if err != nil {
+		return kafka.Message{}, err
+	}
+	headers := append(recordHeaders(message.Headers), kafka.Header{Key: codecHeader, Value: []byte(contentType)})
+	return kafka.Message{
+		Topic:   topic,
+		Key:     RecordKey(message),
+		Value:   payload,
+		Headers: headers,
+	}, nil
+}
+
+func decodeRecord(record kafka.Message) (*protocol.Envelope, error) {
+	var contentType string
+	for _, h := range record.Headers {
+		if h.Key == codecHeader {
+			contentType = string(h.Value)
+		}
+	}
+	headers := envelopeHeaders(record.Headers)
+	message, err := protocol.DecodeEnvelope(contentType, record.Value)
+	if err != nil {
+		return nil, err
+	}
+	message.Headers = headers
+	return message, nil
+}
@@ -0,0 +1,48 @@
+package kafka
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/flywave/go-twins/protocol"
+	"github.com/flywave/go-twins/protocol/signals"
+)
+
+func TestRecordRoundTrip(t *testing.T) {
+	message := &protocol.Envelope{
+		Topic: &protocol.Topic{TenantName: "t", ChannelName: "c", Entity: protocol.EntityThings, Criterion: protocol.CriterionCommands, Action: protocol.ActionCreateOrModify},
+		Headers: signals.NewHeaders(
+			signals.WithCorrelationId("r1"),
+			signals.WithReplyTo("replies"),
+			signals.WithResponseRequired(true),
+		),
+		Path:  (&protocol.Path{}).WithThingAttribute("thing1", "location"),
+		Value: map[string]interface{}{"lat": 1.5},
+	}
+	message.Headers.Set("x-number-string", "42")
+
+	record, err := encodeRecord(nil, "twins", message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(record.Key) != "thing1" {
+		t.Fatalf("key %q, want thing1", record.Key)
+	}
+	decoded, err := decodeRecord(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Topic.String() != message.Topic.String() || decoded.Path.String() != message.Path.String() {
+		t.Fatalf("decoded %s %s", decoded.Topic, decoded.Path)
+	}
+	if !reflect.DeepEqual(decoded.Value, message.Value) {
+		t.Fatalf("value %v, want %v", decoded.Value, message.Value)
+	}
+	for _, name := range message.Headers.Names() {
+		want, _ := message.Headers.Get(name)
+		got, _ := decoded.Headers.Get(name)
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("header %s = %#v, want %#v", name, got, want)
+		}
+	}
+}
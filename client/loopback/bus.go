@@ -0,0 +1,138 @@
+package loopback
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/flywave/go-twins/client"
+	"github.com/flywave/go-twins/protocol"
+	"github.com/flywave/go-twins/protocol/signals"
+)
+
+var (
+	ErrNotConnected   = errors.New("loopback: client not connected")
+	ErrUnknownRequest = errors.New("loopback: unknown request id")
+)
+
+type Bus struct {
+	mu      sync.RWMutex
+	clients map[*Client]struct{}
+	routes  *client.Routes
+	async   bool
+	pending sync.WaitGroup
+}
+
+func NewBus() *Bus {
+	return &Bus{
+		clients: make(map[*Client]struct{}),
+		routes:  client.NewRoutes(client.DefaultReplyTTL),
+	}
+}
+
+func (b *Bus) WithAsync(async bool) *Bus {
+	b.async = async
+	return b
+}
+
+// WithReplyTTL bounds how long the sender of an envelope with a correlation
+// id can be replied to when the envelope has no timeout header.
+func (b *Bus) WithReplyTTL(ttl time.Duration) *Bus {
+	b.routes = client.NewRoutes(ttl)
+	return b
+}
+
+func (b *Bus) NewClient() *Client {
+	return &Client{bus: b}
+}
+
+// Wait blocks until every envelope queued on an asynchronous bus has been
+// handled.
+func (b *Bus) Wait() {
+	b.pending.Wait()
+}
+
+func (b *Bus) attach(c *Client) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.clients[c]; ok {
+		return
+	}
+	if b.async {
+		c.mailbox = newMailbox(&b.pending)
+		go c.mailbox.run(c.Dispatch)
+	}
+	b.clients[c] = struct{}{}
+}
+
+func (b *Bus) detach(c *Client) {
+	b.mu.Lock()
+	if _, ok := b.clients[c]; !ok {
+		b.mu.Unlock()
+		return
+	}
+	delete(b.clients, c)
+	b.routes.Drop(c)
+	mb := c.mailbox
+	c.mailbox = nil
+	b.mu.Unlock()
+	if mb != nil {
+		mb.close()
+	}
+}
+
+func (b *Bus) publish(sender *Client, message *protocol.Envelope) error {
+	requestId := client.CorrelationId(message)
+	if requestId == "" && client.IsResponseRequired(message) {
+		requestId = client.NewRequestId()
+		message = client.WithHeaders(message, signals.WithCorrelationId(requestId))
+	}
+
+	b.mu.Lock()
+	if _, ok := b.clients[sender]; !ok {
+		b.mu.Unlock()
+		return ErrNotConnected
+	}
+	if requestId != "" {
+		b.routes.Put(requestId, message, sender)
+	}
+	targets := make([]*Client, 0, len(b.clients))
+	for c := range b.clients {
+		if c != sender {
+			targets = append(targets, c)
+		}
+	}
+	b.mu.Unlock()
+
+	for _, c := range targets {
+		b.deliver(c, requestId, message)
+	}
+	return nil
+}
+
+func (b *Bus) reply(sender *Client, requestId string, message *protocol.Envelope) error {
+	b.mu.Lock()
+	if _, ok := b.clients[sender]; !ok {
+		b.mu.Unlock()
+		return ErrNotConnected
+	}
+	target, ok := b.routes.Take(requestId)
+	b.mu.Unlock()
+	if !ok {
+		return ErrUnknownRequest
+	}
+	b.deliver(target.(*Client), requestId, client.WithHeaders(message, signals.WithCorrelationId(requestId)))
+	return nil
+}
+
+func (b *Bus) deliver(c *Client, requestId string, message *protocol.Envelope) {
+	d := delivery{requestId: requestId, message: client.WithHeaders(message)}
+	b.mu.RLock()
+	mb := c.mailbox
+	b.mu.RUnlock()
+	if mb == nil {
+		c.Dispatch(d.requestId, d.message)
+		return
+	}
+	mb.push(d)
+}
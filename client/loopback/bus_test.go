@@ -0,0 +1,92 @@
+package loopback
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/flywave/go-twins/client"
+	"github.com/flywave/go-twins/protocol"
+	"github.com/flywave/go-twins/protocol/signals"
+)
+
+func TestRequestReplyRoundTrip(t *testing.T) {
+	for _, async := range []bool{false, true} {
+		bus := NewBus().WithAsync(async)
+		responder, requester := bus.NewClient(), bus.NewClient()
+		for _, c := range []*Client{responder, requester} {
+			if err := c.Connect(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		responder.Subscribe(func(requestId string, message *protocol.Envelope) {
+			if !client.IsResponseRequired(message) {
+				return
+			}
+			if err := responder.Reply(requestId, &protocol.Envelope{Topic: message.Topic, Status: http.StatusNoContent}); err != nil {
+				t.Error(err)
+			}
+		})
+
+		r := client.NewRequester(requester)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		res, err := r.Request(ctx, &protocol.Envelope{
+			Topic: &protocol.Topic{TenantName: "t", ChannelName: "c", Entity: protocol.EntityThings, Criterion: protocol.CriterionCommands, Action: protocol.ActionCreateOrModify},
+			Path:  (&protocol.Path{}).WithThing("thing1"),
+		})
+		cancel()
+		r.Close()
+		if err != nil {
+			t.Fatalf("async %v: %v", async, err)
+		}
+		if res.Status != http.StatusNoContent {
+			t.Fatalf("async %v: status %d, want %d", async, res.Status, http.StatusNoContent)
+		}
+		responder.Disconnect()
+		requester.Disconnect()
+	}
+}
+
+func TestFireAndForgetRoutesExpire(t *testing.T) {
+	bus := NewBus().WithReplyTTL(10 * time.Millisecond)
+	sender, receiver := bus.NewClient(), bus.NewClient()
+	sender.Connect()
+	receiver.Connect()
+	defer sender.Disconnect()
+	defer receiver.Disconnect()
+
+	topic := &protocol.Topic{TenantName: "t", ChannelName: "c", Entity: protocol.EntityThings, Criterion: protocol.CriterionEvents, Action: protocol.ActionModified}
+	for i := 0; i < 100; i++ {
+		sender.Send(client.WithHeaders(&protocol.Envelope{Topic: topic}, signals.WithCorrelationId(client.NewRequestId())))
+	}
+	time.Sleep(20 * time.Millisecond)
+	sender.Send(client.WithHeaders(&protocol.Envelope{Topic: topic}, signals.WithCorrelationId("last")))
+	if n := bus.routes.Len(); n != 1 {
+		t.Fatalf("%d routes kept, want 1", n)
+	}
+}
+
+func TestDisconnectFromAsyncHandler(t *testing.T) {
+	bus := NewBus().WithAsync(true)
+	sender, receiver := bus.NewClient(), bus.NewClient()
+	sender.Connect()
+	receiver.Connect()
+	defer sender.Disconnect()
+
+	done := make(chan struct{})
+	receiver.Subscribe(func(string, *protocol.Envelope) {
+		receiver.Disconnect()
+		close(done)
+	})
+	sender.Send(&protocol.Envelope{Topic: &protocol.Topic{TenantName: "t", ChannelName: "c", Entity: protocol.EntityThings, Criterion: protocol.CriterionEvents, Action: protocol.ActionModified}})
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Disconnect from a handler deadlocked")
+	}
+	bus.Wait()
+	if err := receiver.Send(&protocol.Envelope{Topic: &protocol.Topic{}}); err != ErrNotConnected {
+		t.Fatalf("send after disconnect: %v", err)
+	}
+}
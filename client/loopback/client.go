@@ -0,0 +1,37 @@
+package loopback
+
+import (
+	"github.com/flywave/go-twins/client"
+	"github.com/flywave/go-twins/protocol"
+)
+
+// Client delivers every envelope it sends to all other clients connected to
+// the same Bus; a client never receives its own envelopes.
+type Client struct {
+	client.Dispatcher
+	bus     *Bus
+	mailbox *mailbox
+}
+
+var _ client.Client = (*Client)(nil)
+
+func (c *Client) Bus() *Bus {
+	return c.bus
+}
+
+func (c *Client) Connect() error {
+	c.bus.attach(c)
+	return nil
+}
+
+func (c *Client) Disconnect() {
+	c.bus.detach(c)
+}
+
+func (c *Client) Send(message *protocol.Envelope) error {
+	return c.bus.publish(c, message)
+}
+
+func (c *Client) Reply(requestId string, message *protocol.Envelope) error {
+	return c.bus.reply(c, requestId, message)
+}
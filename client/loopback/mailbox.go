@@ -0,0 +1,83 @@
+package loopback
+
+import (
+	"sync"
+
+	"github.com/flywave/go-twins/protocol"
+)
+
+type delivery struct {
+	requestId string
+	message   *protocol.Envelope
+}
+
+type mailbox struct {
+	mu          sync.Mutex
+	cond        *sync.Cond
+	queue       []delivery
+	closed      bool
+	dispatching bool
+	pending     *sync.WaitGroup
+	done        chan struct{}
+}
+
+func newMailbox(pending *sync.WaitGroup) *mailbox {
+	mb := &mailbox{pending: pending, done: make(chan struct{})}
+	mb.cond = sync.NewCond(&mb.mu)
+	return mb
+}
+
+func (mb *mailbox) push(d delivery) {
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+	if mb.closed {
+		return
+	}
+	mb.pending.Add(1)
+	mb.queue = append(mb.queue, d)
+	mb.cond.Signal()
+}
+
+func (mb *mailbox) run(dispatch func(requestId string, message *protocol.Envelope)) {
+	defer close(mb.done)
+	for {
+		mb.mu.Lock()
+		for len(mb.queue) == 0 && !mb.closed {
+			mb.cond.Wait()
+		}
+		if mb.closed {
+			for range mb.queue {
+				mb.pending.Done()
+			}
+			mb.queue = nil
+			mb.mu.Unlock()
+			return
+		}
+		d := mb.queue[0]
+		mb.queue[0] = delivery{}
+		mb.queue = mb.queue[1:]
+		mb.dispatching = true
+		mb.mu.Unlock()
+
+		dispatch(d.requestId, d.message)
+		mb.mu.Lock()
+		mb.dispatching = false
+		mb.mu.Unlock()
+		mb.pending.Done()
+	}
+}
+
+// close stops delivery: no handler starts after it returns. It waits for the
+// mailbox goroutine to exit unless a handler is running, since that handler
+// may be the caller, e.g. one that disconnects its client; the goroutine
+// then exits once the handler returns.
+func (mb *mailbox) close() {
+	mb.mu.Lock()
+	mb.closed = true
+	dispatching := mb.dispatching
+	mb.cond.Signal()
+	mb.mu.Unlock()
+	if !dispatching {
+		<-mb.done
+	}
+}
@@ -0,0 +1,45 @@
+package mqtt
+
+import (
+	"io"
+	"log/slog"
+
+	mochi "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/hooks/auth"
+	"github.com/mochi-mqtt/server/v2/listeners"
+)
+
+type Broker struct {
+	server   *mochi.Server
+	listener *listeners.TCP
+}
+
+func NewBroker(address string) (*Broker, error) {
+	server := mochi.New(&mochi.Options{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err := server.AddHook(new(auth.AllowHook), nil); err != nil {
+		return nil, err
+	}
+	tcp := listeners.NewTCP(listeners.Config{ID: "twins", Address: address})
+	if err := server.AddListener(tcp); err != nil {
+		return nil, err
+	}
+	return &Broker{server: server, listener: tcp}, nil
+}
+
+func (b *Broker) Start() error {
+	return b.server.Serve()
+}
+
+func (b *Broker) Addr() string {
+	return b.listener.Address()
+}
+
+func (b *Broker) URL() string {
+	return "tcp://" + b.Addr()
+}
+
+func (b *Broker) Close() error {
+	return b.server.Close()
+}
@@ -0,0 +1,175 @@
+package mqtt
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/flywave/go-twins/client"
+	"github.com/flywave/go-twins/protocol"
+	"github.com/flywave/go-twins/protocol/signals"
+)
+
+var ErrUnknownRequest = errors.New("mqtt: unknown request id")
+
+type Client struct {
+	client.Dispatcher
+	opts    *Options
+	session session
+	mu      sync.Mutex
+	routes  *client.Routes
+	lost    client.ConnectionLostHandler
+}
+
+var (
+	_ client.Client             = (*Client)(nil)
+	_ client.ConnectionNotifier = (*Client)(nil)
+)
+
+func NewClient(opts *Options) *Client {
+	if opts == nil {
+		opts = NewOptions()
+	}
+	if opts.ClientId == "" {
+		opts.ClientId = "twins-" + client.NewRequestId()[:12]
+	}
+	if opts.ReplyTopic == "" {
+		opts.ReplyTopic = joinTopic(opts.TopicPrefix, []string{"replies", opts.ClientId})
+	}
+	return &Client{opts: opts, routes: client.NewRoutes(opts.ReplyTTL)}
+}
+
+func (c *Client) Options() *Options {
+	return c.opts
+}
+
+func (c *Client) SetConnectionLostHandler(handler client.ConnectionLostHandler) {
+	c.mu.Lock()
+	c.lost = handler
+	c.mu.Unlock()
+}
+
+func (c *Client) Connect() error {
+	s, err := newSession(c.opts, c.receive)
+	if err != nil {
+		return err
+	}
+	s.setConnectionLostHandler(c.connectionLost)
+	ctx, cancel := context.WithTimeout(context.Background(), c.opts.ConnectTimeout)
+	defer cancel()
+	if err := s.connect(ctx); err != nil {
+		return err
+	}
+	filters := c.opts.Filters
+	if len(filters) == 0 {
+		filters = []string{TopicFilter(c.opts.TopicPrefix, &protocol.Topic{})}
+	}
+	filters = append(filters, c.opts.ReplyTopic)
+	if err := s.subscribe(ctx, filters); err != nil {
+		s.disconnect()
+		return err
+	}
+	c.mu.Lock()
+	c.session = s
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *Client) Disconnect() {
+	c.mu.Lock()
+	s := c.session
+	c.session = nil
+	c.mu.Unlock()
+	c.routes.Reset()
+	if s != nil {
+		s.disconnect()
+	}
+}
+
+func (c *Client) connectionLost(err error) {
+	c.mu.Lock()
+	lost := c.lost
+	c.mu.Unlock()
+	if lost != nil {
+		lost(err)
+	}
+}
+
+func (c *Client) Send(message *protocol.Envelope) error {
+	if message.Topic == nil {
+		return errors.New("mqtt: envelope without topic")
+	}
+	if client.IsResponseRequired(message) && client.ReplyTo(message) == "" {
+		message = client.WithHeaders(message, signals.WithReplyTo(c.opts.ReplyTopic))
+	}
+	return c.publish(TopicName(c.opts.TopicPrefix, message.Topic), message)
+}
+
+func (c *Client) Reply(requestId string, message *protocol.Envelope) error {
+	var replyTo string
+	if route, ok := c.routes.Take(requestId); ok {
+		replyTo = route.(string)
+	} else {
+		replyTo = client.ReplyTo(message)
+	}
+	if replyTo == "" {
+		return ErrUnknownRequest
+	}
+	message = client.WithHeaders(message, signals.WithCorrelationId(requestId))
+	return c.publish(replyTo, message)
+}
+
+func (c *Client) publish(topic string, message *protocol.Envelope) error {
+	c.mu.Lock()
+	s := c.session
+	c.mu.Unlock()
+	if s == nil {
+		return errors.New("mqtt: not connected")
+	}
+	contentType, payload, err := protocol.EncodeEnvelope(c.opts.Codec, message)
+	if err != nil {
+		return client.Rejected(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.opts.PublishTimeout)
+	defer cancel()
+	return s.publish(ctx, &publication{
+		Topic:         topic,
+		QoS:           c.opts.QoS,
+		Retained:      c.opts.Retained,
+		Payload:       payload,
+		ContentType:   contentType,
+		ReplyTo:       client.ReplyTo(message),
+		CorrelationId: client.CorrelationId(message),
+	})
+}
+
+func (c *Client) receive(pub *publication) {
+	message, err := protocol.DecodeEnvelope(pub.ContentType, pub.Payload)
+	if err != nil {
+		c.opts.logger().Warn("mqtt: dropping undecodable message", "topic", pub.Topic, "error", err)
+		return
+	}
+	var opts []signals.HeaderOpt
+	requestId := client.CorrelationId(message)
+	if requestId == "" {
+		requestId = pub.CorrelationId
+		if requestId == "" && (pub.ReplyTo != "" || client.ReplyTo(message) != "") {
+			requestId = client.NewRequestId()
+		}
+		if requestId != "" {
+			opts = append(opts, signals.WithCorrelationId(requestId))
+		}
+	}
+	replyTo := client.ReplyTo(message)
+	if replyTo == "" && pub.ReplyTo != "" {
+		replyTo = pub.ReplyTo
+		opts = append(opts, signals.WithReplyTo(replyTo))
+	}
+	if len(opts) > 0 {
+		message = client.WithHeaders(message, opts...)
+	}
+	if replyTo != "" && pub.Topic != c.opts.ReplyTopic {
+		c.routes.Put(requestId, message, replyTo)
+	}
+	c.Dispatch(requestId, message)
+}
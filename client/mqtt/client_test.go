@@ -0,0 +1,77 @@
+package mqtt
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/flywave/go-twins/client"
+	"github.com/flywave/go-twins/protocol"
+)
+
+func startBroker(t *testing.T) *Broker {
+	t.Helper()
+	broker, err := NewBroker("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := broker.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { broker.Close() })
+	return broker
+}
+
+func commandTopic() *protocol.Topic {
+	return &protocol.Topic{TenantName: "t", ChannelName: "c", Entity: protocol.EntityThings, Criterion: protocol.CriterionCommands, Action: protocol.ActionCreateOrModify}
+}
+
+func TestRequestReplyRoundTrip(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		version ProtocolVersion
+	}{{"3.1.1", ProtocolVersion311}, {"5", ProtocolVersion5}} {
+		t.Run(tc.name, func(t *testing.T) {
+			broker := startBroker(t)
+			connect := func() *Client {
+				c := NewClient(NewOptions().WithBroker(broker.URL()).WithProtocolVersion(tc.version))
+				if err := c.Connect(); err != nil {
+					t.Fatal(err)
+				}
+				t.Cleanup(c.Disconnect)
+				return c
+			}
+			responder, requester := connect(), connect()
+
+			responder.Subscribe(func(requestId string, message *protocol.Envelope) {
+				if !client.IsResponseRequired(message) {
+					return
+				}
+				res := &protocol.Envelope{Topic: commandTopic(), Path: message.Path, Status: http.StatusNoContent}
+				if err := responder.Reply(requestId, res); err != nil {
+					t.Error(err)
+				}
+			})
+
+			r := client.NewRequester(requester)
+			defer r.Close()
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			res, err := r.Request(ctx, &protocol.Envelope{
+				Topic: commandTopic(),
+				Path:  (&protocol.Path{}).WithThingAttribute("thing1", "location"),
+				Value: "here",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Status != http.StatusNoContent {
+				t.Fatalf("status %d, want %d", res.Status, http.StatusNoContent)
+			}
+			if n := responder.routes.Len(); n != 0 {
+				t.Fatalf("%d reply routes left after replying", n)
+			}
+		})
+	}
+}
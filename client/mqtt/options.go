@@ -0,0 +1,158 @@
+package mqtt
+
+import (
+	"crypto/tls"
+	"log/slog"
+	"time"
+
+	"github.com/flywave/go-twins/client"
+	"github.com/flywave/go-twins/protocol"
+)
+
+type ProtocolVersion uint
+
+const (
+	ProtocolVersion311 ProtocolVersion = 4
+	ProtocolVersion5   ProtocolVersion = 5
+)
+
+const (
+	DefaultTopicPrefix    = "twins"
+	DefaultKeepAlive      = 30 * time.Second
+	DefaultConnectTimeout = 10 * time.Second
+	DefaultPublishTimeout = 10 * time.Second
+	ContentTypeJSON       = protocol.ContentTypeJSON
+)
+
+type Options struct {
+	Brokers         []string
+	ClientId        string
+	Username        string
+	Password        string
+	ProtocolVersion ProtocolVersion
+	QoS             byte
+	Retained        bool
+	CleanSession    bool
+	TopicPrefix     string
+	Filters         []string
+	ReplyTopic      string
+	KeepAlive       time.Duration
+	ConnectTimeout  time.Duration
+	PublishTimeout  time.Duration
+	// Codec encodes outgoing envelopes; nil means protocol.DefaultCodec().
+	// Incoming envelopes are decoded by the content type they arrive with.
+	Codec protocol.Codec
+	// ReplyTTL bounds how long the reply route of a received request
+	// without a timeout header is kept for Reply.
+	ReplyTTL  time.Duration
+	TLSConfig *tls.Config
+	// Logger receives the messages the client has to drop, such as
+	// payloads it cannot decode; nil means slog.Default().
+	Logger *slog.Logger
+}
+
+func NewOptions() *Options {
+	return &Options{
+		ProtocolVersion: ProtocolVersion311,
+		QoS:             1,
+		CleanSession:    true,
+		TopicPrefix:     DefaultTopicPrefix,
+		KeepAlive:       DefaultKeepAlive,
+		ConnectTimeout:  DefaultConnectTimeout,
+		PublishTimeout:  DefaultPublishTimeout,
+		ReplyTTL:        client.DefaultReplyTTL,
+	}
+}
+
+func (o *Options) WithBroker(url string) *Options {
+	o.Brokers = append(o.Brokers, url)
+	return o
+}
+
+func (o *Options) WithClientId(id string) *Options {
+	o.ClientId = id
+	return o
+}
+
+func (o *Options) WithCredentials(username, password string) *Options {
+	o.Username = username
+	o.Password = password
+	return o
+}
+
+func (o *Options) WithProtocolVersion(version ProtocolVersion) *Options {
+	o.ProtocolVersion = version
+	return o
+}
+
+func (o *Options) WithQoS(qos byte) *Options {
+	o.QoS = qos
+	return o
+}
+
+func (o *Options) WithRetained(retained bool) *Options {
+	o.Retained = retained
+	return o
+}
+
+func (o *Options) WithCleanSession(clean bool) *Options {
+	o.CleanSession = clean
+	return o
+}
+
+func (o *Options) WithTopicPrefix(prefix string) *Options {
+	o.TopicPrefix = prefix
+	return o
+}
+
+func (o *Options) WithFilter(filter string) *Options {
+	o.Filters = append(o.Filters, filter)
+	return o
+}
+
+func (o *Options) WithReplyTopic(topic string) *Options {
+	o.ReplyTopic = topic
+	return o
+}
+
+func (o *Options) WithKeepAlive(keepAlive time.Duration) *Options {
+	o.KeepAlive = keepAlive
+	return o
+}
+
+func (o *Options) WithConnectTimeout(timeout time.Duration) *Options {
+	o.ConnectTimeout = timeout
+	return o
+}
+
+func (o *Options) WithTLSConfig(config *tls.Config) *Options {
+	o.TLSConfig = config
+	return o
+}
+
+func (o *Options) WithPublishTimeout(timeout time.Duration) *Options {
+	o.PublishTimeout = timeout
+	return o
+}
+
+func (o *Options) WithCodec(codec protocol.Codec) *Options {
+	o.Codec = codec
+	return o
+}
+
+func (o *Options) WithReplyTTL(ttl time.Duration) *Options {
+	o.ReplyTTL = ttl
+	return o
+}
+
+func (o *Options) WithLogger(logger *slog.Logger) *Options {
+	o.Logger = logger
+	return o
+}
+
+func (o *Options) logger() *slog.Logger {
+	if o.Logger != nil {
+		return o.Logger
+	}
+	return slog.Default()
+}
@@ -0,0 +1,329 @@
+package mqtt
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sync"
+
+	paho5 "github.com/eclipse/paho.golang/paho"
+	paho "github.com/eclipse/paho.mqtt.golang"
+)
+
+type publication struct {
+	Topic         string
+	QoS           byte
+	Retained      bool
+	Payload       []byte
+	ContentType   string
+	ReplyTo       string
+	CorrelationId string
+}
+
+type receiver func(pub *publication)
+
+type session interface {
+	setConnectionLostHandler(handler func(err error))
+	connect(ctx context.Context) error
+	disconnect()
+	publish(ctx context.Context, pub *publication) error
+	subscribe(ctx context.Context, filters []string) error
+}
+
+func newSession(opts *Options, recv receiver) (session, error) {
+	if len(opts.Brokers) == 0 {
+		return nil, errors.New("mqtt: no broker configured")
+	}
+	switch opts.ProtocolVersion {
+	case ProtocolVersion311, 0:
+		return newSession311(opts, recv), nil
+	case ProtocolVersion5:
+		return newSession5(opts, recv), nil
+	}
+	return nil, fmt.Errorf("mqtt: unsupported protocol version %d", opts.ProtocolVersion)
+}
+
+type session311 struct {
+	opts    *Options
+	recv    receiver
+	client  paho.Client
+	mu      sync.Mutex
+	filters []string
+	lost    func(err error)
+}
+
+func newSession311(opts *Options, recv receiver) *session311 {
+	s := &session311{opts: opts, recv: recv}
+	co := paho.NewClientOptions()
+	for _, b := range opts.Brokers {
+		co.AddBroker(b)
+	}
+	co.SetClientID(opts.ClientId)
+	co.SetUsername(opts.Username)
+	co.SetPassword(opts.Password)
+	co.SetProtocolVersion(uint(ProtocolVersion311))
+	co.SetCleanSession(opts.CleanSession)
+	co.SetKeepAlive(opts.KeepAlive)
+	co.SetConnectTimeout(opts.ConnectTimeout)
+	// reconnecting is left to the caller, e.g. the reconnect decorator, so
+	// that two loops do not race to restore the session
+	co.SetAutoReconnect(false)
+	co.SetOrderMatters(false)
+	if opts.TLSConfig != nil {
+		co.SetTLSConfig(opts.TLSConfig)
+	}
+	co.SetOnConnectHandler(func(c paho.Client) {
+		s.mu.Lock()
+		filters := s.filters
+		s.mu.Unlock()
+		if len(filters) > 0 {
+			c.SubscribeMultiple(s.filterMap(filters), s.onMessage)
+		}
+	})
+	co.SetConnectionLostHandler(func(c paho.Client, err error) {
+		s.mu.Lock()
+		lost := s.lost
+		s.mu.Unlock()
+		if lost != nil {
+			lost(err)
+		}
+	})
+	s.client = paho.NewClient(co)
+	return s
+}
+
+func (s *session311) setConnectionLostHandler(handler func(err error)) {
+	s.mu.Lock()
+	s.lost = handler
+	s.mu.Unlock()
+}
+
+func (s *session311) connect(ctx context.Context) error {
+	return wait(ctx, s.client.Connect())
+}
+
+func (s *session311) disconnect() {
+	s.client.Disconnect(250)
+}
+
+func (s *session311) publish(ctx context.Context, pub *publication) error {
+	return wait(ctx, s.client.Publish(pub.Topic, pub.QoS, pub.Retained, pub.Payload))
+}
+
+func (s *session311) subscribe(ctx context.Context, filters []string) error {
+	s.mu.Lock()
+	s.filters = append(s.filters, filters...)
+	s.mu.Unlock()
+	return wait(ctx, s.client.SubscribeMultiple(s.filterMap(filters), s.onMessage))
+}
+
+func (s *session311) filterMap(filters []string) map[string]byte {
+	res := make(map[string]byte, len(filters))
+	for _, f := range filters {
+		res[f] = s.opts.QoS
+	}
+	return res
+}
+
+func (s *session311) onMessage(c paho.Client, msg paho.Message) {
+	s.recv(&publication{
+		Topic:    msg.Topic(),
+		QoS:      msg.Qos(),
+		Retained: msg.Retained(),
+		Payload:  msg.Payload(),
+	})
+}
+
+func wait(ctx context.Context, token paho.Token) error {
+	select {
+	case <-token.Done():
+		return token.Error()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+type session5 struct {
+	opts   *Options
+	recv   receiver
+	mu     sync.Mutex
+	client *paho5.Client
+	lost   func(err error)
+}
+
+func newSession5(opts *Options, recv receiver) *session5 {
+	return &session5{opts: opts, recv: recv}
+}
+
+func (s *session5) setConnectionLostHandler(handler func(err error)) {
+	s.mu.Lock()
+	s.lost = handler
+	s.mu.Unlock()
+}
+
+func (s *session5) connectionLost(client *paho5.Client, err error) {
+	s.mu.Lock()
+	if s.client != client {
+		s.mu.Unlock()
+		return
+	}
+	s.client = nil
+	lost := s.lost
+	s.mu.Unlock()
+	if lost != nil {
+		lost(err)
+	}
+}
+
+func (s *session5) connect(ctx context.Context) error {
+	var lastErr error
+	for _, broker := range s.opts.Brokers {
+		conn, err := dial(ctx, broker, s.opts.TLSConfig)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		var client *paho5.Client
+		client = paho5.NewClient(paho5.ClientConfig{
+			ClientID: s.opts.ClientId,
+			Conn:     conn,
+			OnPublishReceived: []func(paho5.PublishReceived) (bool, error){
+				func(pr paho5.PublishReceived) (bool, error) {
+					s.onMessage(pr.Packet)
+					return true, nil
+				},
+			},
+			OnClientError: func(err error) {
+				s.connectionLost(client, err)
+			},
+			OnServerDisconnect: func(d *paho5.Disconnect) {
+				s.connectionLost(client, fmt.Errorf("mqtt: server disconnect: %d", d.ReasonCode))
+			},
+		})
+		cp := &paho5.Connect{
+			ClientID:   s.opts.ClientId,
+			KeepAlive:  uint16(s.opts.KeepAlive.Seconds()),
+			CleanStart: s.opts.CleanSession,
+		}
+		if s.opts.Username != "" {
+			cp.Username = s.opts.Username
+			cp.UsernameFlag = true
+		}
+		if s.opts.Password != "" {
+			cp.Password = []byte(s.opts.Password)
+			cp.PasswordFlag = true
+		}
+		ack, err := client.Connect(ctx, cp)
+		if err != nil {
+			conn.Close()
+			lastErr = err
+			continue
+		}
+		if ack.ReasonCode >= 0x80 {
+			conn.Close()
+			lastErr = fmt.Errorf("mqtt: connect refused: %d %s", ack.ReasonCode, ack.Properties.ReasonString)
+			continue
+		}
+		s.mu.Lock()
+		s.client = client
+		s.mu.Unlock()
+		return nil
+	}
+	return lastErr
+}
+
+func (s *session5) current() (*paho5.Client, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.client == nil {
+		return nil, errors.New("mqtt: not connected")
+	}
+	return s.client, nil
+}
+
+func (s *session5) disconnect() {
+	s.mu.Lock()
+	client := s.client
+	s.client = nil
+	s.mu.Unlock()
+	if client != nil {
+		client.Disconnect(&paho5.Disconnect{ReasonCode: 0})
+	}
+}
+
+func (s *session5) publish(ctx context.Context, pub *publication) error {
+	client, err := s.current()
+	if err != nil {
+		return err
+	}
+	props := &paho5.PublishProperties{
+		ContentType:   pub.ContentType,
+		ResponseTopic: pub.ReplyTo,
+	}
+	if pub.CorrelationId != "" {
+		props.CorrelationData = []byte(pub.CorrelationId)
+	}
+	_, err = client.Publish(ctx, &paho5.Publish{
+		Topic:      pub.Topic,
+		QoS:        pub.QoS,
+		Retain:     pub.Retained,
+		Payload:    pub.Payload,
+		Properties: props,
+	})
+	return err
+}
+
+func (s *session5) subscribe(ctx context.Context, filters []string) error {
+	client, err := s.current()
+	if err != nil {
+		return err
+	}
+	sub := &paho5.Subscribe{}
+	for _, f := range filters {
+		sub.Subscriptions = append(sub.Subscriptions, paho5.SubscribeOptions{Topic: f, QoS: s.opts.QoS})
+	}
+	ack, err := client.Subscribe(ctx, sub)
+	if err != nil {
+		return err
+	}
+	for i, code := range ack.Reasons {
+		if code >= 0x80 {
+			return fmt.Errorf("mqtt: subscribe to %s refused: %d", filters[i], code)
+		}
+	}
+	return nil
+}
+
+func (s *session5) onMessage(p *paho5.Publish) {
+	pub := &publication{
+		Topic:    p.Topic,
+		QoS:      p.QoS,
+		Retained: p.Retain,
+		Payload:  p.Payload,
+	}
+	if p.Properties != nil {
+		pub.ContentType = p.Properties.ContentType
+		pub.ReplyTo = p.Properties.ResponseTopic
+		pub.CorrelationId = string(p.Properties.CorrelationData)
+	}
+	s.recv(pub)
+}
+
+func dial(ctx context.Context, broker string, config *tls.Config) (net.Conn, error) {
+	u, err := url.Parse(broker)
+	if err != nil {
+		return nil, err
+	}
+	var d net.Dialer
+	switch u.Scheme {
+	case "tcp", "mqtt", "":
+		return d.DialContext(ctx, "tcp", u.Host)
+	case "ssl", "tls", "mqtts", "tcps":
+		td := &tls.Dialer{NetDialer: &d, Config: config}
+		return td.DialContext(ctx, "tcp", u.Host)
+	}
+	return nil, fmt.Errorf("mqtt: unsupported broker scheme %q", u.Scheme)
+}
@@ -0,0 +1,27 @@
+package mqtt
+
+import (
+	"strings"
+
+	"github.com/flywave/go-twins/protocol"
+)
+
+func TopicName(prefix string, topic *protocol.Topic) string {
+	segments := []string{topic.TenantName, topic.ChannelName, string(topic.Entity), string(topic.Criterion)}
+	if topic.Action != "" {
+		segments = append(segments, string(topic.Action))
+	}
+	return joinTopic(prefix, segments)
+}
+
+func TopicFilter(prefix string, topic *protocol.Topic) string {
+	return protocol.TopicToMQTTFilter(prefix, topic)
+}
+
+func joinTopic(prefix string, segments []string) string {
+	name := strings.Join(segments, "/")
+	if prefix != "" {
+		return prefix + "/" + name
+	}
+	return name
+}
@@ -0,0 +1,247 @@
+package nats
+
+import (
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/flywave/go-twins/client"
+	"github.com/flywave/go-twins/protocol"
+	"github.com/flywave/go-twins/protocol/signals"
+	"github.com/nats-io/nats.go"
+)
+
+var (
+	ErrNotConnected   = errors.New("nats: not connected")
+	ErrUnknownRequest = errors.New("nats: unknown request id")
+)
+
+type Client struct {
+	client.Dispatcher
+	opts    *Options
+	mu      sync.Mutex
+	conn    *nats.Conn
+	inbox   string
+	subs    []*nats.Subscription
+	routes  *client.Routes
+	lost    client.ConnectionLostHandler
+	closing bool
+}
+
+var (
+	_ client.Client             = (*Client)(nil)
+	_ client.ConnectionNotifier = (*Client)(nil)
+)
+
+func NewClient(opts *Options) *Client {
+	if opts == nil {
+		opts = NewOptions()
+	}
+	return &Client{opts: opts, routes: client.NewRoutes(opts.ReplyTTL)}
+}
+
+func (c *Client) Options() *Options {
+	return c.opts
+}
+
+func (c *Client) SetConnectionLostHandler(handler client.ConnectionLostHandler) {
+	c.mu.Lock()
+	c.lost = handler
+	c.mu.Unlock()
+}
+
+func (c *Client) Connect() error {
+	servers := c.opts.Servers
+	if len(servers) == 0 {
+		servers = []string{nats.DefaultURL}
+	}
+	opts := []nats.Option{
+		nats.Timeout(c.opts.ConnectTimeout),
+		nats.ReconnectWait(c.opts.ReconnectWait),
+		nats.MaxReconnects(c.opts.MaxReconnects),
+		nats.ClosedHandler(c.closed),
+	}
+	if c.opts.Name != "" {
+		opts = append(opts, nats.Name(c.opts.Name))
+	}
+	if c.opts.Username != "" {
+		opts = append(opts, nats.UserInfo(c.opts.Username, c.opts.Password))
+	}
+	if c.opts.Token != "" {
+		opts = append(opts, nats.Token(c.opts.Token))
+	}
+	if c.opts.NoEcho {
+		opts = append(opts, nats.NoEcho())
+	}
+	if c.opts.TLSConfig != nil {
+		opts = append(opts, nats.Secure(c.opts.TLSConfig))
+	}
+	conn, err := nats.Connect(strings.Join(servers, ","), opts...)
+	if err != nil {
+		return err
+	}
+
+	filters := c.opts.Filters
+	if len(filters) == 0 {
+		filters = SubjectFilters(c.opts.SubjectPrefix, &protocol.Topic{})
+	}
+	var subs []*nats.Subscription
+	for _, f := range filters {
+		var sub *nats.Subscription
+		if c.opts.Queue != "" {
+			sub, err = conn.QueueSubscribe(f, c.opts.Queue, c.receive)
+		} else {
+			sub, err = conn.Subscribe(f, c.receive)
+		}
+		if err != nil {
+			conn.Close()
+			return err
+		}
+		subs = append(subs, sub)
+	}
+	inbox := conn.NewInbox()
+	sub, err := conn.Subscribe(inbox, c.receive)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	subs = append(subs, sub)
+	if err := conn.Flush(); err != nil {
+		conn.Close()
+		return err
+	}
+
+	c.mu.Lock()
+	c.conn = conn
+	c.inbox = inbox
+	c.subs = subs
+	c.closing = false
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *Client) Disconnect() {
+	c.mu.Lock()
+	conn := c.conn
+	c.conn = nil
+	c.subs = nil
+	c.closing = true
+	c.mu.Unlock()
+	c.routes.Reset()
+	if conn != nil {
+		conn.Drain()
+	}
+}
+
+// closed fires once the NATS connection gave up reconnecting or was shut
+// down; only the former is reported as a lost connection.
+func (c *Client) closed(conn *nats.Conn) {
+	c.mu.Lock()
+	current := c.conn == conn && !c.closing
+	if current {
+		c.conn = nil
+	}
+	lost := c.lost
+	c.mu.Unlock()
+	if current && lost != nil {
+		err := conn.LastError()
+		if err == nil {
+			err = nats.ErrConnectionClosed
+		}
+		lost(err)
+	}
+}
+
+func (c *Client) current() (*nats.Conn, string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		return nil, "", ErrNotConnected
+	}
+	return c.conn, c.inbox, nil
+}
+
+func (c *Client) Send(message *protocol.Envelope) error {
+	if message.Topic == nil {
+		return errors.New("nats: envelope without topic")
+	}
+	conn, inbox, err := c.current()
+	if err != nil {
+		return err
+	}
+	reply := ""
+	if client.IsResponseRequired(message) {
+		reply = client.ReplyTo(message)
+		if reply == "" {
+			reply = inbox
+		}
+	}
+	return c.publish(conn, Subject(c.opts.SubjectPrefix, message.Topic), reply, message)
+}
+
+func (c *Client) Reply(requestId string, message *protocol.Envelope) error {
+	conn, _, err := c.current()
+	if err != nil {
+		return err
+	}
+	var subject string
+	if route, ok := c.routes.Take(requestId); ok {
+		subject = route.(string)
+	} else {
+		subject = client.ReplyTo(message)
+	}
+	if subject == "" {
+		return ErrUnknownRequest
+	}
+	message = client.WithHeaders(message, signals.WithCorrelationId(requestId))
+	return c.publish(conn, subject, "", message)
+}
+
+func (c *Client) publish(conn *nats.Conn, subject, reply string, message *protocol.Envelope) error {
+	contentType, payload, err := protocol.EncodeEnvelope(c.opts.Codec, message)
+	if err != nil {
+		return client.Rejected(err)
+	}
+	msg := nats.NewMsg(subject)
+	msg.Reply = reply
+	msg.Data = payload
+	msg.Header.Set("Content-Type", contentType)
+	if id := client.CorrelationId(message); id != "" {
+		msg.Header.Set(protocol.HeaderCorrelationId, id)
+	}
+	if err := conn.PublishMsg(msg); err != nil {
+		if errors.Is(err, nats.ErrMaxPayload) {
+			return client.Rejected(err)
+		}
+		return err
+	}
+	return nil
+}
+
+func (c *Client) receive(msg *nats.Msg) {
+	message, err := protocol.DecodeEnvelope(msg.Header.Get("Content-Type"), msg.Data)
+	if err != nil {
+		c.opts.logger().Warn("nats: dropping undecodable message", "subject", msg.Subject, "error", err)
+		return
+	}
+	requestId := client.CorrelationId(message)
+	if requestId == "" {
+		requestId = msg.Header.Get(protocol.HeaderCorrelationId)
+		if requestId == "" && msg.Reply != "" {
+			requestId = client.NewRequestId()
+		}
+		if requestId != "" {
+			message = client.WithHeaders(message, signals.WithCorrelationId(requestId))
+		}
+	}
+	if msg.Reply != "" && msg.Sub != nil && msg.Sub.Subject != c.inboxSubject() {
+		c.routes.Put(requestId, message, msg.Reply)
+	}
+	c.Dispatch(requestId, message)
+}
+
+func (c *Client) inboxSubject() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.inbox
+}
@@ -0,0 +1,200 @@
+package nats
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/flywave/go-twins/client"
+	"github.com/flywave/go-twins/protocol"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+)
+
+func startServer(t *testing.T) *server.Server {
+	t.Helper()
+	s, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: server.RANDOM_PORT, NoLog: true, NoSigs: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Start()
+	if !s.ReadyForConnections(5 * time.Second) {
+		t.Fatal("nats server not ready")
+	}
+	t.Cleanup(s.Shutdown)
+	return s
+}
+
+func newTestClient(t *testing.T, s *server.Server, opts *Options) *Client {
+	t.Helper()
+	c := NewClient(opts.WithServer(s.ClientURL()))
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(c.Disconnect)
+	return c
+}
+
+func commandTopic() *protocol.Topic {
+	return &protocol.Topic{TenantName: "t", ChannelName: "c", Entity: protocol.EntityThings, Criterion: protocol.CriterionCommands, Action: protocol.ActionCreateOrModify}
+}
+
+func TestRequestReplyRoundTrip(t *testing.T) {
+	s := startServer(t)
+	responder := newTestClient(t, s, NewOptions())
+	requester := newTestClient(t, s, NewOptions().WithNoEcho(true))
+
+	responder.Subscribe(func(requestId string, message *protocol.Envelope) {
+		if !client.IsResponseRequired(message) {
+			return
+		}
+		res := &protocol.Envelope{Topic: commandTopic(), Path: message.Path, Status: http.StatusNoContent}
+		if err := responder.Reply(requestId, res); err != nil {
+			t.Error(err)
+		}
+	})
+
+	r := client.NewRequester(requester)
+	defer r.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	res, err := r.Request(ctx, &protocol.Envelope{
+		Topic: commandTopic(),
+		Path:  (&protocol.Path{}).WithThingAttribute("thing1", "location"),
+		Value: "here",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != http.StatusNoContent {
+		t.Fatalf("status %d, want %d", res.Status, http.StatusNoContent)
+	}
+}
+
+func TestQueueGroupSharesEnvelopes(t *testing.T) {
+	s := startServer(t)
+	var received atomic.Int32
+	for i := 0; i < 2; i++ {
+		worker := newTestClient(t, s, NewOptions().WithQueue("workers"))
+		worker.Subscribe(func(string, *protocol.Envelope) { received.Add(1) })
+	}
+	sender := newTestClient(t, s, NewOptions().WithNoEcho(true))
+	const n = 10
+	for i := 0; i < n; i++ {
+		if err := sender.Send(&protocol.Envelope{Topic: commandTopic(), Path: (&protocol.Path{}).WithThing("thing1")}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for received.Load() < n && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if got := received.Load(); got != n {
+		t.Fatalf("group received %d envelopes, want %d", got, n)
+	}
+}
+
+func TestConfiguredCodecIgnoresContentTypeHeader(t *testing.T) {
+	s := startServer(t)
+	receiver := newTestClient(t, s, NewOptions())
+	received := make(chan *protocol.Envelope, 1)
+	receiver.Subscribe(func(_ string, message *protocol.Envelope) { received <- message })
+	sender := newTestClient(t, s, NewOptions().WithNoEcho(true).WithCodec(protocol.CBORCodec{}))
+
+	message := &protocol.Envelope{
+		Topic:   commandTopic(),
+		Headers: protocol.NewHeadersWithValues(map[string]interface{}{protocol.HeaderContentType: "text/plain"}),
+		Path:    (&protocol.Path{}).WithThingAttribute("thing1", "note"),
+		Value:   "hello",
+	}
+	if err := sender.Send(message); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case got := <-received:
+		if got.Value != "hello" || got.Headers.ContentType() != "text/plain" {
+			t.Fatalf("received %v with content-type %q", got.Value, got.Headers.ContentType())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("nothing received")
+	}
+}
+
+func TestSubjectEscapesTokens(t *testing.T) {
+	topic := &protocol.Topic{TenantName: "a.b", ChannelName: "c*", Entity: protocol.EntityThings, Criterion: protocol.CriterionMessages, Action: "x>y 100%"}
+	if got := Subject(DefaultSubjectPrefix, topic); got != "twins.a%2Eb.c%2A.things.messages.x%3Ey%20100%25" {
+		t.Fatalf("subject %s", got)
+	}
+	filters := SubjectFilters(DefaultSubjectPrefix, &protocol.Topic{TenantName: "a.b", Criterion: protocol.CriterionMessages})
+	if len(filters) != 2 || filters[0] != "twins.a%2Eb.*.*.messages" {
+		t.Fatalf("filters %v", filters)
+	}
+}
+
+func TestDefaultFiltersIgnoreOtherTraffic(t *testing.T) {
+	s := startServer(t)
+	var logged bytes.Buffer
+	var mu sync.Mutex
+	logger := slog.New(slog.NewTextHandler(writerFunc(func(p []byte) (int, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return logged.Write(p)
+	}), nil))
+	receiver := newTestClient(t, s, NewOptions().WithLogger(logger))
+	received := make(chan *protocol.Envelope, 4)
+	receiver.Subscribe(func(_ string, message *protocol.Envelope) { received <- message })
+
+	raw, err := nats.Connect(s.ClientURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer raw.Close()
+	payload, _ := json.Marshal(&protocol.Envelope{Topic: commandTopic()})
+	for _, subject := range []string{"orders.eu.new.today", "orders.eu.new.today.late"} {
+		if err := raw.Publish(subject, payload); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := raw.Publish("twins.t.c.things.commands", []byte("not an envelope")); err != nil {
+		t.Fatal(err)
+	}
+	if err := raw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	sender := newTestClient(t, s, NewOptions().WithNoEcho(true))
+	topic := &protocol.Topic{TenantName: "a.b", ChannelName: "c", Entity: protocol.EntityThings, Criterion: protocol.CriterionEvents, Action: protocol.ActionModified}
+	if err := sender.Send(&protocol.Envelope{Topic: topic, Path: (&protocol.Path{}).WithThing("thing1")}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case got := <-received:
+		if got.Topic.TenantName != "a.b" {
+			t.Fatalf("received topic %s", got.Topic)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("nothing received")
+	}
+	select {
+	case got := <-received:
+		t.Fatalf("received unrelated traffic %v", got.Topic)
+	case <-time.After(50 * time.Millisecond):
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !strings.Contains(logged.String(), "nats: dropping undecodable message") {
+		t.Fatalf("logged %q", logged.String())
+	}
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) { return f(p) }
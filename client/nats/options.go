@@ -0,0 +1,136 @@
+package nats
+
+import (
+	"crypto/tls"
+	"log/slog"
+	"time"
+
+	"github.com/flywave/go-twins/client"
+	"github.com/flywave/go-twins/protocol"
+)
+
+const (
+	DefaultConnectTimeout = 10 * time.Second
+	DefaultReconnectWait  = 2 * time.Second
+	DefaultMaxReconnects  = 60
+	DefaultSubjectPrefix  = "twins"
+	ContentTypeJSON       = protocol.ContentTypeJSON
+)
+
+type Options struct {
+	Servers  []string
+	Name     string
+	Username string
+	Password string
+	Token    string
+	// SubjectPrefix is the first token of every subject, so that the
+	// default filters do not catch unrelated traffic on the server.
+	SubjectPrefix  string
+	Filters        []string
+	Queue          string
+	NoEcho         bool
+	ConnectTimeout time.Duration
+	ReconnectWait  time.Duration
+	MaxReconnects  int
+	// Codec encodes outgoing envelopes; nil means protocol.DefaultCodec().
+	// Incoming envelopes are decoded by the content type they arrive with.
+	Codec protocol.Codec
+	// ReplyTTL bounds how long the reply subject of a received request
+	// without a timeout header is kept for Reply.
+	ReplyTTL  time.Duration
+	TLSConfig *tls.Config
+	// Logger receives the messages the client has to drop, such as
+	// payloads it cannot decode; nil means slog.Default().
+	Logger *slog.Logger
+}
+
+func NewOptions() *Options {
+	return &Options{
+		ConnectTimeout: DefaultConnectTimeout,
+		ReconnectWait:  DefaultReconnectWait,
+		MaxReconnects:  DefaultMaxReconnects,
+		SubjectPrefix:  DefaultSubjectPrefix,
+		ReplyTTL:       client.DefaultReplyTTL,
+	}
+}
+
+func (o *Options) WithServer(url string) *Options {
+	o.Servers = append(o.Servers, url)
+	return o
+}
+
+func (o *Options) WithName(name string) *Options {
+	o.Name = name
+	return o
+}
+
+func (o *Options) WithCredentials(username, password string) *Options {
+	o.Username = username
+	o.Password = password
+	return o
+}
+
+func (o *Options) WithToken(token string) *Options {
+	o.Token = token
+	return o
+}
+
+func (o *Options) WithSubjectPrefix(prefix string) *Options {
+	o.SubjectPrefix = prefix
+	return o
+}
+
+func (o *Options) WithFilter(subject string) *Options {
+	o.Filters = append(o.Filters, subject)
+	return o
+}
+
+// WithQueue makes every filter subscription join the queue group, so each
+// envelope is delivered to only one client of the group.
+func (o *Options) WithQueue(queue string) *Options {
+	o.Queue = queue
+	return o
+}
+
+func (o *Options) WithNoEcho(noEcho bool) *Options {
+	o.NoEcho = noEcho
+	return o
+}
+
+func (o *Options) WithConnectTimeout(timeout time.Duration) *Options {
+	o.ConnectTimeout = timeout
+	return o
+}
+
+func (o *Options) WithReconnect(wait time.Duration, max int) *Options {
+	o.ReconnectWait = wait
+	o.MaxReconnects = max
+	return o
+}
+
+func (o *Options) WithCodec(codec protocol.Codec) *Options {
+	o.Codec = codec
+	return o
+}
+
+func (o *Options) WithReplyTTL(ttl time.Duration) *Options {
+	o.ReplyTTL = ttl
+	return o
+}
+
+func (o *Options) WithTLSConfig(config *tls.Config) *Options {
+	o.TLSConfig = config
+	return o
+}
+
+func (o *Options) WithLogger(logger *slog.Logger) *Options {
+	o.Logger = logger
+	return o
+}
+
+func (o *Options) logger() *slog.Logger {
+	if o.Logger != nil {
+		return o.Logger
+	}
+	return slog.Default()
+}
@@ -0,0 +1,81 @@
+package nats
+
+import (
+	"strings"
+
+	"github.com/flywave/go-twins/protocol"
+)
+
+const (
+	singleTokenWildcard = "*"
+	fullWildcard        = ">"
+
+	// tokenReserved are the characters a subject token cannot hold as they
+	// are: the separator, the wildcards and whitespace, plus the escape
+	// character itself.
+	tokenReserved = ".*>% \t\r\n"
+)
+
+// Subject returns the subject topic is published on. Each topic segment is
+// one token; separators and wildcards in tenant, channel or action names are
+// percent-escaped, so that e.g. a tenant "a.b" does not become two tokens.
+func Subject(prefix string, topic *protocol.Topic) string {
+	tokens := []string{escapeToken(topic.TenantName), escapeToken(topic.ChannelName), string(topic.Entity), string(topic.Criterion)}
+	if topic.Action != "" {
+		tokens = append(tokens, escapeToken(string(topic.Action)))
+	}
+	return joinSubject(prefix, tokens)
+}
+
+// SubjectFilters returns the subjects to subscribe for topic. A topic without
+// action matches subjects with and without one, which takes two NATS
+// subscriptions because wildcards always match at least one token.
+func SubjectFilters(prefix string, topic *protocol.Topic) []string {
+	tokens := []string{
+		filterToken(topic.TenantName),
+		filterToken(topic.ChannelName),
+		filterToken(string(topic.Entity)),
+		filterToken(string(topic.Criterion)),
+	}
+	if topic.Action != "" {
+		return []string{joinSubject(prefix, append(tokens, filterToken(string(topic.Action))))}
+	}
+	return []string{
+		joinSubject(prefix, tokens),
+		joinSubject(prefix, append(tokens, singleTokenWildcard)),
+	}
+}
+
+func filterToken(token string) string {
+	if token == "" || token == protocol.TopicPlaceholder || token == singleTokenWildcard {
+		return singleTokenWildcard
+	}
+	return escapeToken(token)
+}
+
+func escapeToken(token string) string {
+	if !strings.ContainsAny(token, tokenReserved) {
+		return token
+	}
+	const hex = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(token); i++ {
+		c := token[i]
+		if strings.IndexByte(tokenReserved, c) < 0 {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(hex[c>>4])
+		b.WriteByte(hex[c&0xf])
+	}
+	return b.String()
+}
+
+func joinSubject(prefix string, tokens []string) string {
+	name := strings.Join(tokens, ".")
+	if prefix != "" {
+		return prefix + "." + name
+	}
+	return name
+}
@@ -0,0 +1,252 @@
+package reconnect
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/flywave/go-twins/client"
+	"github.com/flywave/go-twins/model"
+	"github.com/flywave/go-twins/protocol"
+)
+
+var ErrNotConnected = errors.New("reconnect: not connected")
+
+// Client decorates another client.Client. Lost connections are re-established
+// in the background with exponential backoff, and envelopes sent while the
+// link is down are queued in the outbox and flushed in order on reconnect.
+// Only link failures are retried: an envelope the inner client rejects, see
+// client.ErrRejected, is returned by Send, or dropped and reported to
+// Options.OnRejected when it was queued.
+type Client struct {
+	client.Dispatcher
+	inner        client.Client
+	opts         *Options
+	outbox       Outbox
+	mu           sync.Mutex
+	status       model.ConnectivityStatus
+	stop         chan struct{}
+	closed       bool
+	reconnecting bool
+	sendMu       sync.Mutex
+}
+
+var _ client.Client = (*Client)(nil)
+
+func NewClient(inner client.Client, opts *Options) *Client {
+	if opts == nil {
+		opts = NewOptions()
+	}
+	c := &Client{
+		inner:  inner,
+		opts:   opts,
+		outbox: opts.Outbox,
+		status: model.ConnectivityStatusUnknown,
+		closed: true,
+	}
+	if c.outbox == nil {
+		c.outbox = NewMemoryOutbox()
+	}
+	inner.Subscribe(c.Dispatch)
+	if n, ok := inner.(client.ConnectionNotifier); ok {
+		n.SetConnectionLostHandler(c.connectionLost)
+	}
+	return c
+}
+
+func (c *Client) Inner() client.Client {
+	return c.inner
+}
+
+func (c *Client) Outbox() Outbox {
+	return c.outbox
+}
+
+func (c *Client) Status() model.ConnectivityStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.status
+}
+
+// Connect makes one connection attempt. A failed attempt is reported through
+// the status handler and retried in the background rather than returned, so
+// Send can be used right away.
+func (c *Client) Connect() error {
+	c.mu.Lock()
+	if !c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = false
+	c.stop = make(chan struct{})
+	c.mu.Unlock()
+
+	if err := c.inner.Connect(); err != nil {
+		c.reconnect(err)
+		return nil
+	}
+	c.setStatus(model.ConnectivityStatusOpen, nil)
+	c.flush()
+	return nil
+}
+
+func (c *Client) Disconnect() {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return
+	}
+	c.closed = true
+	close(c.stop)
+	c.mu.Unlock()
+	c.inner.Disconnect()
+	c.setStatus(model.ConnectivityStatusClosed, nil)
+}
+
+func (c *Client) Send(message *protocol.Envelope) error {
+	if message == nil || message.Topic == nil {
+		return errors.New("reconnect: envelope without topic")
+	}
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	if c.Status() == model.ConnectivityStatusOpen && c.outbox.Len() == 0 {
+		err := c.inner.Send(message)
+		if err == nil || errors.Is(err, client.ErrRejected) {
+			return err
+		}
+		c.reconnect(err)
+	}
+	if err := c.outbox.Push(message); err != nil {
+		return err
+	}
+	if c.Status() == model.ConnectivityStatusOpen {
+		// a previous flush stopped on an outbox error; retry it
+		c.outboxError(c.flushLocked())
+	}
+	return nil
+}
+
+func (c *Client) Reply(requestId string, message *protocol.Envelope) error {
+	if c.Status() != model.ConnectivityStatusOpen {
+		return ErrNotConnected
+	}
+	return c.inner.Reply(requestId, message)
+}
+
+func (c *Client) connectionLost(err error) {
+	c.reconnect(err)
+}
+
+func (c *Client) setStatus(status model.ConnectivityStatus, err error) {
+	c.mu.Lock()
+	if c.status == status {
+		c.mu.Unlock()
+		return
+	}
+	c.status = status
+	handler := c.opts.OnStatusChange
+	c.mu.Unlock()
+	if handler != nil {
+		handler(status, err)
+	}
+}
+
+func (c *Client) reconnect(cause error) {
+	c.mu.Lock()
+	if c.closed || c.reconnecting {
+		c.mu.Unlock()
+		return
+	}
+	c.reconnecting = true
+	stop := c.stop
+	c.mu.Unlock()
+	c.setStatus(model.ConnectivityStatusFailed, cause)
+	go c.loop(stop)
+}
+
+func (c *Client) loop(stop chan struct{}) {
+	backoff := c.opts.InitialBackoff
+	for {
+		timer := time.NewTimer(c.opts.jittered(backoff))
+		select {
+		case <-stop:
+			timer.Stop()
+			c.mu.Lock()
+			c.reconnecting = false
+			c.mu.Unlock()
+			return
+		case <-timer.C:
+		}
+		c.inner.Disconnect()
+		if err := c.inner.Connect(); err != nil {
+			backoff = c.opts.next(backoff)
+			continue
+		}
+		c.mu.Lock()
+		c.reconnecting = false
+		select {
+		case <-stop:
+			c.mu.Unlock()
+			c.inner.Disconnect()
+			return
+		default:
+		}
+		c.mu.Unlock()
+		c.setStatus(model.ConnectivityStatusOpen, nil)
+		c.flush()
+		return
+	}
+}
+
+func (c *Client) flush() {
+	c.sendMu.Lock()
+	err := c.flushLocked()
+	c.sendMu.Unlock()
+	c.outboxError(err)
+}
+
+// flushLocked sends the queued envelopes in order until the outbox is empty
+// or the link fails. It stops at the first outbox error, leaving the entry in
+// place, except for corrupt entries and rejected envelopes, which can never
+// be sent.
+func (c *Client) flushLocked() error {
+	for c.Status() == model.ConnectivityStatusOpen {
+		message, err := c.outbox.Peek()
+		if errors.Is(err, ErrCorruptEntry) {
+			if err := c.outbox.Pop(); err != nil {
+				return err
+			}
+			c.outboxError(err)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if message == nil {
+			return nil
+		}
+		if err := c.inner.Send(message); err != nil {
+			if !errors.Is(err, client.ErrRejected) {
+				c.reconnect(err)
+				return nil
+			}
+			c.rejected(message, err)
+		}
+		if err := c.outbox.Pop(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Client) rejected(message *protocol.Envelope, err error) {
+	if c.opts.OnRejected != nil {
+		c.opts.OnRejected(message, err)
+	}
+}
+
+func (c *Client) outboxError(err error) {
+	if err != nil && c.opts.OnOutboxError != nil {
+		c.opts.OnOutboxError(err)
+	}
+}
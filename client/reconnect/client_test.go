@@ -0,0 +1,322 @@
+package reconnect
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/flywave/go-twins/client"
+	"github.com/flywave/go-twins/model"
+	"github.com/flywave/go-twins/protocol"
+)
+
+type recordingClient struct {
+	client.Dispatcher
+	mu   sync.Mutex
+	sent []*protocol.Envelope
+}
+
+func (c *recordingClient) Connect() error { return nil }
+func (c *recordingClient) Disconnect()    {}
+
+func (c *recordingClient) Reply(requestId string, message *protocol.Envelope) error {
+	return c.Send(message)
+}
+
+func (c *recordingClient) Send(message *protocol.Envelope) error {
+	c.mu.Lock()
+	c.sent = append(c.sent, message)
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *recordingClient) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.sent)
+}
+
+// flakyOutbox fails the next peek or pop once when told to.
+type flakyOutbox struct {
+	*MemoryOutbox
+	failPeek, failPop bool
+}
+
+var errFlaky = errors.New("flaky outbox")
+
+func (o *flakyOutbox) Peek() (*protocol.Envelope, error) {
+	if o.failPeek {
+		o.failPeek = false
+		return nil, errFlaky
+	}
+	return o.MemoryOutbox.Peek()
+}
+
+func (o *flakyOutbox) Pop() error {
+	if o.failPop {
+		o.failPop = false
+		return errFlaky
+	}
+	return o.MemoryOutbox.Pop()
+}
+
+func testEnvelope() *protocol.Envelope {
+	return &protocol.Envelope{Topic: &protocol.Topic{TenantName: "t", ChannelName: "c", Entity: protocol.EntityThings, Criterion: protocol.CriterionEvents, Action: protocol.ActionModified}}
+}
+
+func TestFlushKeepsEntryOnOutboxError(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		outbox *flakyOutbox
+		sent   int
+	}{
+		{"peek", &flakyOutbox{MemoryOutbox: NewMemoryOutbox(), failPeek: true}, 2},
+		// the entry was delivered before Pop failed, so it is sent again
+		{"pop", &flakyOutbox{MemoryOutbox: NewMemoryOutbox(), failPop: true}, 3},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			inner := &recordingClient{}
+			var reported []error
+			c := NewClient(inner, NewOptions().WithOutbox(tc.outbox).WithOutboxErrorHandler(func(err error) {
+				reported = append(reported, err)
+			}))
+			tc.outbox.Push(testEnvelope())
+			tc.outbox.Push(testEnvelope())
+			if err := c.Connect(); err != nil {
+				t.Fatal(err)
+			}
+			if len(reported) != 1 || !errors.Is(reported[0], errFlaky) {
+				t.Fatalf("reported %v, want one flaky outbox error", reported)
+			}
+			if tc.outbox.Len() == 0 {
+				t.Fatal("outbox was emptied despite the error")
+			}
+			// the next send retries the flush behind the queued entries
+			if err := c.Send(testEnvelope()); err != nil {
+				t.Fatal(err)
+			}
+			if tc.outbox.Len() != 0 || inner.count() != tc.sent+1 {
+				t.Fatalf("outbox %d, sent %d; want 0, %d", tc.outbox.Len(), inner.count(), tc.sent+1)
+			}
+		})
+	}
+}
+
+var (
+	errLink   = errors.New("link down")
+	errPoison = errors.New("poison")
+)
+
+// linkClient fails Connect and Send with errLink while down and rejects
+// envelopes whose value is "poison".
+type linkClient struct {
+	client.Dispatcher
+	mu   sync.Mutex
+	down bool
+	sent []interface{}
+	lost client.ConnectionLostHandler
+}
+
+func (c *linkClient) Connect() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.down {
+		return errLink
+	}
+	return nil
+}
+
+func (c *linkClient) Disconnect() {}
+
+func (c *linkClient) Reply(requestId string, message *protocol.Envelope) error {
+	return c.Send(message)
+}
+
+func (c *linkClient) Send(message *protocol.Envelope) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.down {
+		return errLink
+	}
+	if message.Value == "poison" {
+		return client.Rejected(errPoison)
+	}
+	c.sent = append(c.sent, message.Value)
+	return nil
+}
+
+func (c *linkClient) SetConnectionLostHandler(handler client.ConnectionLostHandler) {
+	c.lost = handler
+}
+
+func (c *linkClient) setDown(down bool) {
+	c.mu.Lock()
+	c.down = down
+	c.mu.Unlock()
+	if down {
+		c.lost(errLink)
+	}
+}
+
+func (c *linkClient) values() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return fmt.Sprint(c.sent)
+}
+
+func valueEnvelope(value interface{}) *protocol.Envelope {
+	return testEnvelope().WithValue(value)
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func fastOptions() *Options {
+	return NewOptions().WithBackoff(time.Millisecond, 5*time.Millisecond).WithJitter(0)
+}
+
+func TestBackoff(t *testing.T) {
+	o := NewOptions().WithBackoff(100*time.Millisecond, time.Second)
+	var got []time.Duration
+	for b := o.InitialBackoff; len(got) < 6; b = o.next(b) {
+		got = append(got, b)
+	}
+	if fmt.Sprint(got) != "[100ms 200ms 400ms 800ms 1s 1s]" {
+		t.Fatalf("backoff %v", got)
+	}
+	for i := 0; i < 100; i++ {
+		if d := o.jittered(time.Second); d < 800*time.Millisecond || d > 1200*time.Millisecond {
+			t.Fatalf("jittered %v outside 20%%", d)
+		}
+	}
+}
+
+func TestFlushInOrderAfterOutage(t *testing.T) {
+	inner := &linkClient{}
+	var mu sync.Mutex
+	var statuses []model.ConnectivityStatus
+	c := NewClient(inner, fastOptions().WithStatusHandler(func(status model.ConnectivityStatus, err error) {
+		mu.Lock()
+		statuses = append(statuses, status)
+		mu.Unlock()
+	}))
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	c.Send(valueEnvelope(1))
+
+	inner.setDown(true)
+	if c.Status() != model.ConnectivityStatusFailed {
+		t.Fatalf("status %v after the link was lost", c.Status())
+	}
+	for i := 2; i <= 4; i++ {
+		if err := c.Send(valueEnvelope(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if c.Outbox().Len() != 3 {
+		t.Fatalf("outbox %d during outage, want 3", c.Outbox().Len())
+	}
+	inner.setDown(false)
+	waitFor(t, func() bool { return c.Outbox().Len() == 0 })
+	if got := inner.values(); got != "[1 2 3 4]" {
+		t.Fatalf("sent %s", got)
+	}
+	c.Disconnect()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if got := fmt.Sprint(statuses); got != fmt.Sprint([]model.ConnectivityStatus{
+		model.ConnectivityStatusOpen, model.ConnectivityStatusFailed, model.ConnectivityStatusOpen, model.ConnectivityStatusClosed,
+	}) {
+		t.Fatalf("statuses %s", got)
+	}
+}
+
+func TestRejectedEnvelopeDoesNotBlockOutbox(t *testing.T) {
+	inner := &linkClient{}
+	var rejected []error
+	c := NewClient(inner, fastOptions().WithRejectedHandler(func(message *protocol.Envelope, err error) {
+		rejected = append(rejected, err)
+	}))
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Disconnect()
+
+	if err := c.Send(valueEnvelope("poison")); !errors.Is(err, client.ErrRejected) {
+		t.Fatalf("send returned %v, want a rejection", err)
+	}
+	if c.Status() != model.ConnectivityStatusOpen || c.Outbox().Len() != 0 {
+		t.Fatal("a rejected envelope was treated as a link failure")
+	}
+
+	inner.setDown(true)
+	c.Send(valueEnvelope(1))
+	c.Send(valueEnvelope("poison"))
+	c.Send(valueEnvelope(2))
+	inner.setDown(false)
+	waitFor(t, func() bool { return c.Outbox().Len() == 0 })
+	if got := inner.values(); got != "[1 2]" {
+		t.Fatalf("sent %s", got)
+	}
+	if len(rejected) != 1 || !errors.Is(rejected[0], errPoison) {
+		t.Fatalf("rejected %v, want the poison envelope", rejected)
+	}
+}
+
+func TestFileOutboxReplaysAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+	outbox, err := NewFileOutbox(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inner := &linkClient{down: true}
+	c := NewClient(inner, fastOptions().WithOutbox(outbox))
+	c.Connect()
+	stamp := time.Date(2024, 5, 1, 12, 30, 0, 0, time.FixedZone("UTC+8", 8*60*60))
+	for i := 1; i <= 3; i++ {
+		if err := c.Send(valueEnvelope(i).WithTime(stamp)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c.Disconnect()
+
+	// a new process opens the same directory
+	outbox, err = NewFileOutbox(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if outbox.Len() != 3 {
+		t.Fatalf("reopened outbox holds %d entries, want 3", outbox.Len())
+	}
+	head, err := outbox.Peek()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !head.Time.Equal(stamp) {
+		t.Fatalf("replayed time %v, want %v", head.Time, stamp)
+	}
+	inner = &linkClient{}
+	c = NewClient(inner, fastOptions().WithOutbox(outbox))
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Disconnect()
+	if outbox.Len() != 0 {
+		t.Fatalf("outbox %d after reconnect, want 0", outbox.Len())
+	}
+	if got := inner.values(); got != "[1 2 3]" {
+		t.Fatalf("replayed %s", got)
+	}
+}
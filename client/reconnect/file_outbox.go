@@ -0,0 +1,142 @@
+package reconnect
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/flywave/go-twins"
+	"github.com/flywave/go-twins/protocol"
+)
+
+const outboxFileExt = ".json"
+
+// FileOutbox keeps one file per envelope in a directory, named after a
+// monotonically increasing sequence number, so queued envelopes survive a
+// restart and are replayed in the order they were pushed.
+type FileOutbox struct {
+	mu   sync.Mutex
+	dir  string
+	seqs []uint64
+	next uint64
+}
+
+func NewFileOutbox(dir string) (*FileOutbox, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	o := &FileOutbox{dir: dir}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, outboxFileExt) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, outboxFileExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		o.seqs = append(o.seqs, seq)
+	}
+	sort.Slice(o.seqs, func(i, j int) bool { return o.seqs[i] < o.seqs[j] })
+	if n := len(o.seqs); n > 0 {
+		o.next = o.seqs[n-1] + 1
+	}
+	return o, nil
+}
+
+func (o *FileOutbox) Dir() string {
+	return o.dir
+}
+
+func (o *FileOutbox) file(seq uint64) string {
+	return filepath.Join(o.dir, fmt.Sprintf("%020d%s", seq, outboxFileExt))
+}
+
+// Push writes the time as RFC 3339, which keeps its zone; the legacy
+// encoding would replay it shifted on a host outside UTC.
+func (o *FileOutbox) Push(message *protocol.Envelope) error {
+	buf, err := message.EncodeJSON(twins.TimeEncodingRFC3339Nano)
+	if err != nil {
+		return err
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	seq := o.next
+	tmp := filepath.Join(o.dir, fmt.Sprintf(".%020d.tmp", seq))
+	if err := writeFileSync(tmp, buf); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, o.file(seq)); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	o.next++
+	o.seqs = append(o.seqs, seq)
+	return nil
+}
+
+func (o *FileOutbox) Peek() (*protocol.Envelope, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if len(o.seqs) == 0 {
+		return nil, nil
+	}
+	buf, err := os.ReadFile(o.file(o.seqs[0]))
+	if err != nil {
+		return nil, err
+	}
+	message := &protocol.Envelope{}
+	if err := json.Unmarshal(buf, message); err != nil {
+		return nil, fmt.Errorf("%w %d: %v", ErrCorruptEntry, o.seqs[0], err)
+	}
+	return message, nil
+}
+
+func (o *FileOutbox) Pop() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if len(o.seqs) == 0 {
+		return nil
+	}
+	if err := os.Remove(o.file(o.seqs[0])); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	o.seqs = o.seqs[1:]
+	return nil
+}
+
+func (o *FileOutbox) Len() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return len(o.seqs)
+}
+
+func (o *FileOutbox) Close() error {
+	return nil
+}
+
+func writeFileSync(name string, buf []byte) error {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(buf); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
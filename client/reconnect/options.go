@@ -0,0 +1,101 @@
+package reconnect
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/flywave/go-twins/model"
+	"github.com/flywave/go-twins/protocol"
+)
+
+const (
+	DefaultInitialBackoff = 500 * time.Millisecond
+	DefaultMaxBackoff     = 30 * time.Second
+	DefaultMultiplier     = 2.0
+	DefaultJitter         = 0.2
+)
+
+type StatusHandler func(status model.ConnectivityStatus, err error)
+
+type Options struct {
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	Jitter         float64
+	// Outbox queues envelopes sent while disconnected. It defaults to a
+	// MemoryOutbox, which needs no configuration but loses the queue when
+	// the process exits; use a FileOutbox to keep it across restarts.
+	Outbox         Outbox
+	OnStatusChange StatusHandler
+	// OnOutboxError is told when the outbox cannot be read or trimmed
+	// while flushing. The flush stops and is retried on the next Send or
+	// reconnect, so no queued envelope is lost.
+	OnOutboxError func(err error)
+	// OnRejected is told about a queued envelope that the inner client
+	// rejected, see client.ErrRejected. It has been dropped from the outbox.
+	OnRejected func(message *protocol.Envelope, err error)
+}
+
+func NewOptions() *Options {
+	return &Options{
+		InitialBackoff: DefaultInitialBackoff,
+		MaxBackoff:     DefaultMaxBackoff,
+		Multiplier:     DefaultMultiplier,
+		Jitter:         DefaultJitter,
+	}
+}
+
+func (o *Options) WithBackoff(initial, max time.Duration) *Options {
+	o.InitialBackoff = initial
+	o.MaxBackoff = max
+	return o
+}
+
+func (o *Options) WithMultiplier(multiplier float64) *Options {
+	o.Multiplier = multiplier
+	return o
+}
+
+func (o *Options) WithJitter(jitter float64) *Options {
+	o.Jitter = jitter
+	return o
+}
+
+func (o *Options) WithOutbox(outbox Outbox) *Options {
+	o.Outbox = outbox
+	return o
+}
+
+func (o *Options) WithStatusHandler(handler StatusHandler) *Options {
+	o.OnStatusChange = handler
+	return o
+}
+
+func (o *Options) WithOutboxErrorHandler(handler func(err error)) *Options {
+	o.OnOutboxError = handler
+	return o
+}
+
+func (o *Options) WithRejectedHandler(handler func(message *protocol.Envelope, err error)) *Options {
+	o.OnRejected = handler
+	return o
+}
+
+func (o *Options) next(backoff time.Duration) time.Duration {
+	next := time.Duration(float64(backoff) * o.Multiplier)
+	if next > o.MaxBackoff {
+		next = o.MaxBackoff
+	}
+	if next < o.InitialBackoff {
+		next = o.InitialBackoff
+	}
+	return next
+}
+
+func (o *Options) jittered(backoff time.Duration) time.Duration {
+	if o.Jitter <= 0 {
+		return backoff
+	}
+	delta := o.Jitter * float64(backoff)
+	return backoff + time.Duration(delta*(2*rand.Float64()-1))
+}
@@ -0,0 +1,68 @@
+package reconnect
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/flywave/go-twins/protocol"
+)
+
+// ErrCorruptEntry is wrapped by Peek errors for entries that can never be
+// read back; the client drops them. Any other Peek or Pop error stops the
+// flush and leaves the entry queued.
+var ErrCorruptEntry = errors.New("reconnect: corrupt outbox entry")
+
+// Outbox is a FIFO queue of envelopes waiting to be sent. Peek returns nil
+// when the outbox is empty.
+type Outbox interface {
+	Push(message *protocol.Envelope) error
+	Peek() (*protocol.Envelope, error)
+	Pop() error
+	Len() int
+	Close() error
+}
+
+type MemoryOutbox struct {
+	mu       sync.Mutex
+	messages []*protocol.Envelope
+}
+
+func NewMemoryOutbox() *MemoryOutbox {
+	return &MemoryOutbox{}
+}
+
+func (o *MemoryOutbox) Push(message *protocol.Envelope) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.messages = append(o.messages, message)
+	return nil
+}
+
+func (o *MemoryOutbox) Peek() (*protocol.Envelope, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if len(o.messages) == 0 {
+		return nil, nil
+	}
+	return o.messages[0], nil
+}
+
+func (o *MemoryOutbox) Pop() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if len(o.messages) > 0 {
+		o.messages[0] = nil
+		o.messages = o.messages[1:]
+	}
+	return nil
+}
+
+func (o *MemoryOutbox) Len() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return len(o.messages)
+}
+
+func (o *MemoryOutbox) Close() error {
+	return nil
+}
@@ -0,0 +1,178 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/flywave/go-twins/protocol"
+	"github.com/flywave/go-twins/protocol/signals"
+)
+
+var ErrRequesterClosed = errors.New("client: requester closed")
+
+type ResponseError struct {
+	Response *protocol.Envelope
+}
+
+func (e *ResponseError) Error() string {
+	if e.Response.Value != nil {
+		return fmt.Sprintf("client: error response %d: %v", e.Response.Status, e.Response.Value)
+	}
+	return fmt.Sprintf("client: error response %d", e.Response.Status)
+}
+
+type pendingRequest struct {
+	topic    string
+	response chan *protocol.Envelope
+}
+
+// Requester pairs envelopes sent through a Client with their responses by
+// correlation id.
+type Requester struct {
+	client       Client
+	subscription *Subscription
+	mu           sync.Mutex
+	pending      map[string]*pendingRequest
+	closed       bool
+}
+
+func NewRequester(c Client) *Requester {
+	r := &Requester{client: c, pending: make(map[string]*pendingRequest)}
+	if s, ok := c.(Subscriber); ok {
+		// A nil filter matches everything and cannot fail to compile.
+		r.subscription, _ = s.SubscribeFilter(nil, r.handle)
+	} else {
+		// Unsubscribe would remove the handlers of every Requester on c, see
+		// Dispatcher.Unsubscribe, so this one stays registered after Close
+		// and ignores what it receives.
+		c.Subscribe(r.handle)
+	}
+	return r
+}
+
+func (r *Requester) Close() {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return
+	}
+	r.closed = true
+	pending := r.pending
+	r.pending = make(map[string]*pendingRequest)
+	r.mu.Unlock()
+	if r.subscription != nil {
+		r.subscription.Unsubscribe()
+	}
+	for _, p := range pending {
+		close(p.response)
+	}
+}
+
+func (r *Requester) Request(ctx context.Context, message *protocol.Envelope) (*protocol.Envelope, error) {
+	if message.Topic == nil {
+		return nil, errors.New("client: envelope without topic")
+	}
+	correlationId := CorrelationId(message)
+	if correlationId == "" {
+		correlationId = NewRequestId()
+	}
+	message = WithHeaders(message, signals.WithCorrelationId(correlationId), signals.WithResponseRequired(true))
+
+	if timeout := message.Headers.Timeout(); timeout != "" {
+		d, err := ParseTimeout(timeout)
+		if err != nil {
+			return nil, err
+		}
+		if d > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, d)
+			defer cancel()
+		}
+	}
+
+	p := &pendingRequest{topic: message.Topic.String(), response: make(chan *protocol.Envelope, 1)}
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil, ErrRequesterClosed
+	}
+	r.pending[correlationId] = p
+	r.mu.Unlock()
+
+	defer func() {
+		r.mu.Lock()
+		if r.pending[correlationId] == p {
+			delete(r.pending, correlationId)
+		}
+		r.mu.Unlock()
+	}()
+
+	if err := r.client.Send(message); err != nil {
+		return nil, err
+	}
+
+	select {
+	case res, ok := <-p.response:
+		if !ok {
+			return nil, ErrRequesterClosed
+		}
+		if res.Topic != nil && res.Topic.IsError() {
+			return res, &ResponseError{Response: res}
+		}
+		return res, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (r *Requester) handle(requestId string, message *protocol.Envelope) {
+	correlationId := CorrelationId(message)
+	if correlationId == "" {
+		correlationId = requestId
+	}
+	if correlationId == "" {
+		return
+	}
+	r.mu.Lock()
+	p, ok := r.pending[correlationId]
+	if !ok || isEcho(p, message) {
+		r.mu.Unlock()
+		return
+	}
+	delete(r.pending, correlationId)
+	r.mu.Unlock()
+	p.response <- message
+}
+
+func isEcho(p *pendingRequest, message *protocol.Envelope) bool {
+	return IsResponseRequired(message) && message.Topic != nil && message.Topic.String() == p.topic
+}
+
+// ParseTimeout reads a timeout header value, either a Go duration such as
+// "500ms" or a plain number of seconds. Zero means no timeout.
+func ParseTimeout(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if secs, err := strconv.ParseFloat(value, 64); err == nil {
+		if math.IsNaN(secs) || secs > math.MaxInt64/float64(time.Second) {
+			return 0, fmt.Errorf("client: invalid timeout %q", value)
+		}
+		if secs < 0 {
+			return 0, fmt.Errorf("client: negative timeout %q", value)
+		}
+		return time.Duration(secs * float64(time.Second)), nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("client: invalid timeout %q", value)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("client: negative timeout %q", value)
+	}
+	return d, nil
+}
@@ -0,0 +1,254 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/flywave/go-twins/protocol"
+	"github.com/flywave/go-twins/protocol/signals"
+)
+
+// replyClient hands every sent envelope to respond and delivers what it
+// returns to the subscribed handlers.
+type replyClient struct {
+	mu       sync.Mutex
+	handlers []Handler
+	respond  func(message *protocol.Envelope) []*protocol.Envelope
+}
+
+func (c *replyClient) Connect() error                                           { return nil }
+func (c *replyClient) Disconnect()                                              {}
+func (c *replyClient) Reply(requestId string, message *protocol.Envelope) error { return nil }
+
+func (c *replyClient) Send(message *protocol.Envelope) error {
+	if c.respond == nil {
+		return nil
+	}
+	responses := c.respond(message)
+	c.mu.Lock()
+	handlers := append([]Handler(nil), c.handlers...)
+	c.mu.Unlock()
+	go func() {
+		for _, res := range responses {
+			for _, h := range handlers {
+				h("", res)
+			}
+		}
+	}()
+	return nil
+}
+
+func (c *replyClient) Subscribe(handlers ...Handler) {
+	c.mu.Lock()
+	c.handlers = append(c.handlers, handlers...)
+	c.mu.Unlock()
+}
+
+func (c *replyClient) Unsubscribe(handlers ...Handler) {
+	c.mu.Lock()
+	c.handlers = nil
+	c.mu.Unlock()
+}
+
+func requestTopic() *protocol.Topic {
+	return (&protocol.Topic{}).WithTenantName("ns").WithEntity(protocol.EntityThings).
+		WithChannelName("twin").WithCriterion(protocol.CriterionCommands).WithAction(protocol.ActionRetrieve)
+}
+
+func responseTo(request *protocol.Envelope, value interface{}) *protocol.Envelope {
+	return &protocol.Envelope{
+		Topic:   requestTopic(),
+		Headers: signals.NewHeaders(signals.WithCorrelationId(CorrelationId(request))),
+		Status:  200,
+		Value:   value,
+	}
+}
+
+func TestRequestPairsByCorrelationId(t *testing.T) {
+	c := &replyClient{respond: func(message *protocol.Envelope) []*protocol.Envelope {
+		stray := responseTo(message, "stray")
+		stray.Headers.Set(protocol.HeaderCorrelationId, "other")
+		// the request itself comes back first on a shared topic
+		return []*protocol.Envelope{message, stray, responseTo(message, message.Value)}
+	}}
+	r := NewRequester(c)
+	defer r.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			res, err := r.Request(context.Background(), &protocol.Envelope{Topic: requestTopic(), Value: i})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if res.Value != i {
+				t.Errorf("request %d answered with %v", i, res.Value)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	request := WithHeaders(&protocol.Envelope{Topic: requestTopic()}, signals.WithCorrelationId("given"))
+	res, err := r.Request(context.Background(), request)
+	if err != nil || CorrelationId(res) != "given" {
+		t.Fatalf("kept correlation id: %v, %v", res, err)
+	}
+}
+
+func TestRequestExpires(t *testing.T) {
+	r := NewRequester(&replyClient{})
+	defer r.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := r.Request(ctx, &protocol.Envelope{Topic: requestTopic()}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("ctx deadline: got %v", err)
+	}
+
+	start := time.Now()
+	request := WithHeaders(&protocol.Envelope{Topic: requestTopic()}, signals.WithTimeout("20ms"))
+	if _, err := r.Request(context.Background(), request); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("timeout header: got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("timeout header took %v", elapsed)
+	}
+
+	for _, timeout := range []string{"NaN", "Inf", "-1", "1e300", "soon"} {
+		request := WithHeaders(&protocol.Envelope{Topic: requestTopic()}, signals.WithTimeout(timeout))
+		if _, err := r.Request(context.Background(), request); err == nil || errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("timeout %q: got %v", timeout, err)
+		}
+	}
+	if r.pendingCount() != 0 {
+		t.Fatalf("%d requests left pending", r.pendingCount())
+	}
+}
+
+func TestRequestZeroTimeoutWaitsForResponse(t *testing.T) {
+	c := &replyClient{respond: func(message *protocol.Envelope) []*protocol.Envelope {
+		time.Sleep(10 * time.Millisecond)
+		return []*protocol.Envelope{responseTo(message, "late")}
+	}}
+	r := NewRequester(c)
+	defer r.Close()
+	request := WithHeaders(&protocol.Envelope{Topic: requestTopic()}, signals.WithTimeout("0"))
+	if res, err := r.Request(context.Background(), request); err != nil || res.Value != "late" {
+		t.Fatalf("got %v, %v", res, err)
+	}
+}
+
+func TestRequestErrorResponse(t *testing.T) {
+	c := &replyClient{respond: func(message *protocol.Envelope) []*protocol.Envelope {
+		res := responseTo(message, "not found")
+		res.Topic.WithCriterion(protocol.CriterionErrors)
+		res.Status = 404
+		return []*protocol.Envelope{res}
+	}}
+	r := NewRequester(c)
+	defer r.Close()
+
+	res, err := r.Request(context.Background(), &protocol.Envelope{Topic: requestTopic()})
+	var responseErr *ResponseError
+	if !errors.As(err, &responseErr) {
+		t.Fatalf("got %v, want a ResponseError", err)
+	}
+	if res == nil || responseErr.Response != res || res.Status != 404 {
+		t.Fatalf("error response %v", res)
+	}
+	if err.Error() != "client: error response 404: not found" {
+		t.Fatalf("error %q", err)
+	}
+}
+
+func TestCloseFailsPendingRequests(t *testing.T) {
+	r := NewRequester(&replyClient{})
+	errs := make(chan error, 3)
+	for i := 0; i < cap(errs); i++ {
+		go func() {
+			_, err := r.Request(context.Background(), &protocol.Envelope{Topic: requestTopic()})
+			errs <- err
+		}()
+	}
+	for r.pendingCount() < cap(errs) {
+		time.Sleep(time.Millisecond)
+	}
+	r.Close()
+	for i := 0; i < cap(errs); i++ {
+		select {
+		case err := <-errs:
+			if !errors.Is(err, ErrRequesterClosed) {
+				t.Fatalf("got %v, want ErrRequesterClosed", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("pending request outlived Close")
+		}
+	}
+	if _, err := r.Request(context.Background(), &protocol.Envelope{Topic: requestTopic()}); !errors.Is(err, ErrRequesterClosed) {
+		t.Fatalf("request after Close: got %v", err)
+	}
+	r.Close()
+}
+
+func TestParseTimeout(t *testing.T) {
+	for _, tt := range []struct {
+		value string
+		want  time.Duration
+	}{
+		{"0", 0},
+		{"1.5", 1500 * time.Millisecond},
+		{" 2 ", 2 * time.Second},
+		{"500ms", 500 * time.Millisecond},
+		{"1m", time.Minute},
+	} {
+		if d, err := ParseTimeout(tt.value); err != nil || d != tt.want {
+			t.Errorf("ParseTimeout(%q) = %v, %v; want %v", tt.value, d, err, tt.want)
+		}
+	}
+	for _, value := range []string{"", "NaN", "nan", "Inf", "+Inf", "-Inf", "-1", "-1s", "1e300", "soon"} {
+		if d, err := ParseTimeout(value); err == nil {
+			t.Errorf("ParseTimeout(%q) = %v, want an error", value, d)
+		}
+	}
+}
+
+func (r *Requester) pendingCount() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.pending)
+}
+
+// dispatchClient answers every request through its Dispatcher.
+type dispatchClient struct {
+	Dispatcher
+}
+
+func (c *dispatchClient) Connect() error                                           { return nil }
+func (c *dispatchClient) Disconnect()                                              {}
+func (c *dispatchClient) Reply(requestId string, message *protocol.Envelope) error { return nil }
+
+func (c *dispatchClient) Send(message *protocol.Envelope) error {
+	go c.Dispatch(CorrelationId(message), responseTo(message, "ok"))
+	return nil
+}
+
+func TestClosingOneRequesterKeepsOthers(t *testing.T) {
+	c := &dispatchClient{}
+	closed, open := NewRequester(c), NewRequester(c)
+	defer open.Close()
+	closed.Close()
+	if n := len(c.Subscriptions()); n != 1 {
+		t.Fatalf("%d subscriptions left, want 1", n)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if res, err := open.Request(ctx, &protocol.Envelope{Topic: requestTopic()}); err != nil || res.Value != "ok" {
+		t.Fatalf("got %v, %v", res, err)
+	}
+}
@@ -0,0 +1,101 @@
+package client
+
+import (
+	"sync"
+	"time"
+
+	"github.com/flywave/go-twins/protocol"
+)
+
+// DefaultReplyTTL is how long a reply route is kept for a request that
+// carries no timeout header.
+const DefaultReplyTTL = time.Minute
+
+type route struct {
+	target  interface{}
+	expires time.Time
+}
+
+// Routes remembers where the reply to a received request goes until it is
+// taken. Entries expire after the request's timeout header, or the TTL
+// when it has none, so requests nobody answers do not accumulate.
+type Routes struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]route
+	sweep   time.Time
+}
+
+func NewRoutes(ttl time.Duration) *Routes {
+	if ttl <= 0 {
+		ttl = DefaultReplyTTL
+	}
+	return &Routes{ttl: ttl, entries: make(map[string]route)}
+}
+
+func (r *Routes) Put(requestId string, request *protocol.Envelope, target interface{}) {
+	ttl := r.ttl
+	if timeout := request.Headers.Timeout(); timeout != "" {
+		if d, err := ParseTimeout(timeout); err == nil && d > 0 {
+			ttl = d
+		}
+	}
+	now := time.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.expire(now)
+	r.entries[requestId] = route{target: target, expires: now.Add(ttl)}
+}
+
+// Take removes the route for requestId and returns its target, unless it
+// is unknown or expired.
+func (r *Routes) Take(requestId string) (interface{}, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, ok := r.entries[requestId]
+	if !ok {
+		return nil, false
+	}
+	delete(r.entries, requestId)
+	if time.Now().After(e.expires) {
+		return nil, false
+	}
+	return e.target, true
+}
+
+// Drop removes every route to target, for targets such as sessions that
+// go away.
+func (r *Routes) Drop(target interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, e := range r.entries {
+		if e.target == target {
+			delete(r.entries, id)
+		}
+	}
+}
+
+func (r *Routes) Reset() {
+	r.mu.Lock()
+	r.entries = make(map[string]route)
+	r.mu.Unlock()
+}
+
+func (r *Routes) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.entries)
+}
+
+// expire drops expired entries, scanning at most once per TTL.
+func (r *Routes) expire(now time.Time) {
+	if now.Before(r.sweep) {
+		return
+	}
+	r.sweep = now.Add(r.ttl)
+	for id, e := range r.entries {
+		if now.After(e.expires) {
+			delete(r.entries, id)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package client
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/flywave/go-twins/protocol"
+	"github.com/flywave/go-twins/protocol/signals"
+)
+
+func TestRoutesExpire(t *testing.T) {
+	r := NewRoutes(50 * time.Millisecond)
+	r.Put("kept", &protocol.Envelope{}, "a")
+	r.Put("short", WithHeaders(&protocol.Envelope{}, signals.WithTimeout("10ms")), "b")
+	if target, ok := r.Take("kept"); !ok || target != "a" {
+		t.Fatalf("took %v, %v; want a", target, ok)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, ok := r.Take("short"); ok {
+		t.Fatal("route outlived its timeout header")
+	}
+
+	for i := 0; i < 100; i++ {
+		r.Put(fmt.Sprint(i), &protocol.Envelope{}, "c")
+	}
+	time.Sleep(60 * time.Millisecond)
+	r.Put("late", &protocol.Envelope{}, "d")
+	if r.Len() != 1 {
+		t.Fatalf("%d routes after expiry, want 1", r.Len())
+	}
+}
+
+func TestRoutesDrop(t *testing.T) {
+	r := NewRoutes(0)
+	a, b := new(int), new(int)
+	r.Put("1", &protocol.Envelope{}, a)
+	r.Put("2", &protocol.Envelope{}, b)
+	r.Put("3", &protocol.Envelope{}, a)
+	r.Drop(a)
+	if r.Len() != 1 {
+		t.Fatalf("%d routes after drop, want 1", r.Len())
+	}
+	if target, ok := r.Take("2"); !ok || target != b {
+		t.Fatal("route to another target was dropped")
+	}
+}
@@ -0,0 +1,178 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/flywave/go-twins/client"
+	"github.com/flywave/go-twins/protocol"
+	"github.com/flywave/go-twins/protocol/signals"
+	"github.com/gorilla/websocket"
+)
+
+var ErrNotConnected = errors.New("websocket: not connected")
+
+type Client struct {
+	client.Dispatcher
+	opts    *Options
+	mu      sync.Mutex
+	conn    *conn
+	filters []*client.Filter
+	lost    client.ConnectionLostHandler
+}
+
+var (
+	_ client.Client             = (*Client)(nil)
+	_ client.ConnectionNotifier = (*Client)(nil)
+)
+
+func NewClient(opts *Options) *Client {
+	if opts == nil {
+		opts = NewOptions()
+	}
+	return &Client{opts: opts, filters: append([]*client.Filter(nil), opts.Filters...)}
+}
+
+func (c *Client) Options() *Options {
+	return c.opts
+}
+
+func (c *Client) SetConnectionLostHandler(handler client.ConnectionLostHandler) {
+	c.mu.Lock()
+	c.lost = handler
+	c.mu.Unlock()
+}
+
+func (c *Client) Connect() error {
+	if c.opts.URL == "" {
+		return errors.New("websocket: no url configured")
+	}
+	dialer := &websocket.Dialer{
+		HandshakeTimeout: c.opts.HandshakeTimeout,
+		TLSClientConfig:  c.opts.TLSConfig,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.opts.HandshakeTimeout)
+	defer cancel()
+	ws, _, err := dialer.DialContext(ctx, c.opts.URL, c.opts.Header)
+	if err != nil {
+		return err
+	}
+	cn := newConn(ws, c.opts)
+	c.mu.Lock()
+	previous := c.conn
+	c.conn = cn
+	filters := c.filters
+	c.mu.Unlock()
+	// a second Connect replaces the open connection
+	if previous != nil {
+		previous.close(ErrClosed)
+	}
+
+	go func() {
+		err := cn.run(c.receive)
+		c.mu.Lock()
+		current := c.conn == cn
+		if current {
+			c.conn = nil
+		}
+		lost := c.lost
+		c.mu.Unlock()
+		if current && lost != nil {
+			lost(err)
+		}
+	}()
+
+	for _, f := range filters {
+		if err := c.control(cn, f, protocol.ActionSubscribe); err != nil {
+			c.Disconnect()
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Client) Disconnect() {
+	c.mu.Lock()
+	cn := c.conn
+	c.conn = nil
+	c.mu.Unlock()
+	if cn != nil {
+		cn.close(ErrClosed)
+	}
+}
+
+// Listen asks the server to forward envelopes matching filter. The
+// subscription is kept and renewed on every reconnect.
+func (c *Client) Listen(filter *client.Filter) error {
+	c.mu.Lock()
+	c.filters = append(c.filters, filter)
+	cn := c.conn
+	c.mu.Unlock()
+	if cn == nil {
+		return nil
+	}
+	return c.control(cn, filter, protocol.ActionSubscribe)
+}
+
+func (c *Client) Unlisten(filter *client.Filter) error {
+	c.mu.Lock()
+	filters := make([]*client.Filter, 0, len(c.filters))
+	for _, f := range c.filters {
+		if !sameFilter(f, filter) {
+			filters = append(filters, f)
+		}
+	}
+	c.filters = filters
+	cn := c.conn
+	c.mu.Unlock()
+	if cn == nil {
+		return nil
+	}
+	return c.control(cn, filter, protocol.ActionUnSubscribe)
+}
+
+func (c *Client) control(cn *conn, filter *client.Filter, action protocol.TopicAction) error {
+	message, err := subscriptionEnvelope(filter, action)
+	if err != nil {
+		return err
+	}
+	return cn.send(message)
+}
+
+func (c *Client) current() (*conn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		return nil, ErrNotConnected
+	}
+	return c.conn, nil
+}
+
+func (c *Client) Send(message *protocol.Envelope) error {
+	if message.Topic == nil {
+		return errors.New("websocket: envelope without topic")
+	}
+	cn, err := c.current()
+	if err != nil {
+		return err
+	}
+	return cn.send(message)
+}
+
+func (c *Client) Reply(requestId string, message *protocol.Envelope) error {
+	cn, err := c.current()
+	if err != nil {
+		return err
+	}
+	return cn.send(client.WithHeaders(message, signals.WithCorrelationId(requestId)))
+}
+
+func (c *Client) receive(message *protocol.Envelope) {
+	requestId := client.CorrelationId(message)
+	if requestId == "" && client.IsResponseRequired(message) {
+		requestId = client.NewRequestId()
+		message = client.WithHeaders(message, signals.WithCorrelationId(requestId))
+	}
+	c.Dispatch(requestId, message)
+}
@@ -0,0 +1,136 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/flywave/go-twins/client"
+	"github.com/flywave/go-twins/protocol"
+)
+
+func commandTopic() *protocol.Topic {
+	return &protocol.Topic{TenantName: "t", ChannelName: "c", Entity: protocol.EntityThings, Criterion: protocol.CriterionCommands, Action: protocol.ActionCreateOrModify}
+}
+
+func startServer(t *testing.T) (*Server, string) {
+	t.Helper()
+	srv := NewServer(nil)
+	hs := httptest.NewServer(srv)
+	t.Cleanup(func() {
+		srv.Disconnect()
+		hs.Close()
+	})
+	return srv, "ws" + strings.TrimPrefix(hs.URL, "http")
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRequestReplyRoundTrip(t *testing.T) {
+	srv, url := startServer(t)
+	srv.Subscribe(func(requestId string, message *protocol.Envelope) {
+		if !client.IsResponseRequired(message) {
+			return
+		}
+		res := &protocol.Envelope{Topic: commandTopic(), Path: message.Path, Status: http.StatusNoContent}
+		if err := srv.Reply(requestId, res); err != nil {
+			t.Error(err)
+		}
+	})
+	c := NewClient(NewOptions().WithURL(url))
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Disconnect()
+
+	r := client.NewRequester(c)
+	defer r.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	res, err := r.Request(ctx, &protocol.Envelope{
+		Topic: commandTopic(),
+		Path:  (&protocol.Path{}).WithThingAttribute("thing1", "location"),
+		Value: "here",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != http.StatusNoContent {
+		t.Fatalf("status %d, want %d", res.Status, http.StatusNoContent)
+	}
+}
+
+func TestWildcardFilterSubscription(t *testing.T) {
+	srv, url := startServer(t)
+	filter := client.NewFilter(&protocol.Topic{Entity: protocol.EntityThings, Criterion: protocol.CriterionEvents}).
+		WithPath("@things/+/attributes/#")
+	c := NewClient(NewOptions().WithURL(url).WithFilter(filter))
+	received := make(chan *protocol.Envelope, 4)
+	c.Subscribe(func(_ string, message *protocol.Envelope) {
+		if message.Topic.Action == protocol.ActionModified {
+			received <- message
+		}
+	})
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Disconnect()
+
+	event := func(path *protocol.Path) *protocol.Envelope {
+		return &protocol.Envelope{
+			Topic: &protocol.Topic{TenantName: "t", ChannelName: "c", Entity: protocol.EntityThings, Criterion: protocol.CriterionEvents, Action: protocol.ActionModified},
+			Path:  path,
+		}
+	}
+	// the subscription is registered once the server has read it
+	waitFor(t, func() bool {
+		srv.mu.Lock()
+		defer srv.mu.Unlock()
+		for sess := range srv.sessions {
+			return len(sess.Subscriptions()) == 1
+		}
+		return false
+	})
+	srv.Send(event((&protocol.Path{}).WithThingFeature("thing1", "f1")))
+	srv.Send(event((&protocol.Path{}).WithThingAttribute("thing1", "location")))
+	select {
+	case message := <-received:
+		if message.Path.String() != "@things/thing1/attributes/location" {
+			t.Fatalf("received %s", message.Path)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event delivered")
+	}
+}
+
+func TestSubscriptionNeedsConcreteEntity(t *testing.T) {
+	_, err := subscriptionEnvelope(client.NewFilter(&protocol.Topic{Criterion: protocol.CriterionEvents}), protocol.ActionSubscribe)
+	if !errors.Is(err, ErrWildcardEntity) {
+		t.Fatalf("got %v, want ErrWildcardEntity", err)
+	}
+}
+
+func TestConnectTwiceReplacesConnection(t *testing.T) {
+	srv, url := startServer(t)
+	c := NewClient(NewOptions().WithURL(url))
+	for i := 0; i < 2; i++ {
+		if err := c.Connect(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer c.Disconnect()
+	waitFor(t, func() bool { return srv.Connections() == 1 })
+}
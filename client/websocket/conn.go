@@ -0,0 +1,148 @@
+package websocket
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/flywave/go-twins/client"
+	"github.com/flywave/go-twins/protocol"
+	"github.com/gorilla/websocket"
+)
+
+var (
+	ErrClosed       = errors.New("websocket: connection closed")
+	ErrSlowConsumer = errors.New("websocket: send buffer full")
+)
+
+// conn owns a single websocket. Frames are written by one goroutine that
+// also sends the keepalive pings, since gorilla allows one concurrent writer.
+type conn struct {
+	ws        *websocket.Conn
+	opts      *Options
+	out       chan frame
+	done      chan struct{}
+	closeOnce sync.Once
+	err       error
+}
+
+// frame is an encoded envelope: JSON travels as text frames, binary codecs
+// as binary frames.
+type frame struct {
+	typ  int
+	data []byte
+}
+
+func newConn(ws *websocket.Conn, opts *Options) *conn {
+	return &conn{
+		ws:   ws,
+		opts: opts,
+		out:  make(chan frame, opts.SendBuffer),
+		done: make(chan struct{}),
+	}
+}
+
+func (c *conn) send(message *protocol.Envelope) error {
+	contentType, data, err := protocol.EncodeEnvelope(c.opts.Codec, message)
+	if err != nil {
+		return client.Rejected(err)
+	}
+	f := frame{typ: websocket.BinaryMessage, data: data}
+	if contentType == protocol.ContentTypeJSON {
+		f.typ = websocket.TextMessage
+	}
+	select {
+	case <-c.done:
+		return ErrClosed
+	default:
+	}
+	select {
+	case c.out <- f:
+		return nil
+	case <-c.done:
+		return ErrClosed
+	default:
+		c.close(ErrSlowConsumer)
+		return ErrSlowConsumer
+	}
+}
+
+func (c *conn) close(err error) {
+	c.closeOnce.Do(func() {
+		c.err = err
+		close(c.done)
+	})
+}
+
+// run pumps frames until the connection fails or is closed and returns the
+// cause; envelopes that cannot be decoded are skipped.
+func (c *conn) run(recv func(message *protocol.Envelope)) error {
+	go c.write()
+	if c.opts.MaxMessageSize > 0 {
+		c.ws.SetReadLimit(c.opts.MaxMessageSize)
+	}
+	c.extendDeadline()
+	c.ws.SetPongHandler(func(string) error {
+		c.extendDeadline()
+		return nil
+	})
+	for {
+		typ, buf, err := c.ws.ReadMessage()
+		if err != nil {
+			c.close(err)
+			return c.err
+		}
+		contentType := ""
+		if typ == websocket.TextMessage {
+			contentType = protocol.ContentTypeJSON
+		}
+		message, err := protocol.DecodeEnvelope(contentType, buf)
+		if err != nil {
+			continue
+		}
+		recv(message)
+	}
+}
+
+func (c *conn) extendDeadline() {
+	if c.opts.PongWait > 0 {
+		c.ws.SetReadDeadline(time.Now().Add(c.opts.PongWait))
+	}
+}
+
+func (c *conn) write() {
+	var ping <-chan time.Time
+	if c.opts.PingInterval > 0 {
+		ticker := time.NewTicker(c.opts.PingInterval)
+		defer ticker.Stop()
+		ping = ticker.C
+	}
+	for {
+		select {
+		case <-c.done:
+			c.ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+			c.ws.Close()
+			return
+		case f := <-c.out:
+			c.ws.SetWriteDeadline(c.deadline())
+			if err := c.ws.WriteMessage(f.typ, f.data); err != nil {
+				c.close(err)
+				c.ws.Close()
+				return
+			}
+		case <-ping:
+			if err := c.ws.WriteControl(websocket.PingMessage, nil, c.deadline()); err != nil {
+				c.close(err)
+				c.ws.Close()
+				return
+			}
+		}
+	}
+}
+
+func (c *conn) deadline() time.Time {
+	if c.opts.WriteTimeout <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(c.opts.WriteTimeout)
+}
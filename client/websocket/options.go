@@ -0,0 +1,118 @@
+package websocket
+
+import (
+	"crypto/tls"
+	"net/http"
+	"time"
+
+	"github.com/flywave/go-twins/client"
+	"github.com/flywave/go-twins/protocol"
+)
+
+const (
+	DefaultPingInterval     = 30 * time.Second
+	DefaultPongWait         = 60 * time.Second
+	DefaultWriteTimeout     = 10 * time.Second
+	DefaultHandshakeTimeout = 10 * time.Second
+	DefaultMaxMessageSize   = 1 << 20
+	DefaultSendBuffer       = 256
+)
+
+type Options struct {
+	URL              string
+	Header           http.Header
+	Filters          []*client.Filter
+	PingInterval     time.Duration
+	PongWait         time.Duration
+	WriteTimeout     time.Duration
+	HandshakeTimeout time.Duration
+	MaxMessageSize   int64
+	SendBuffer       int
+	// Codec encodes outgoing envelopes; nil means protocol.DefaultCodec().
+	// Incoming envelopes are decoded by the content type they arrive with.
+	Codec protocol.Codec
+	// ReplyTTL bounds how long the server keeps the session a request
+	// without a timeout header came from for Reply.
+	ReplyTTL    time.Duration
+	CheckOrigin func(r *http.Request) bool
+	TLSConfig   *tls.Config
+}
+
+func NewOptions() *Options {
+	return &Options{
+		PingInterval:     DefaultPingInterval,
+		PongWait:         DefaultPongWait,
+		WriteTimeout:     DefaultWriteTimeout,
+		HandshakeTimeout: DefaultHandshakeTimeout,
+		MaxMessageSize:   DefaultMaxMessageSize,
+		SendBuffer:       DefaultSendBuffer,
+		ReplyTTL:         client.DefaultReplyTTL,
+	}
+}
+
+func (o *Options) WithURL(url string) *Options {
+	o.URL = url
+	return o
+}
+
+func (o *Options) WithHeader(key, value string) *Options {
+	if o.Header == nil {
+		o.Header = make(http.Header)
+	}
+	o.Header.Add(key, value)
+	return o
+}
+
+// WithFilter adds a subscription the client requests from the server on
+// every connect. A client without filters receives only replies. The
+// filter topic may leave any segment open but the entity.
+func (o *Options) WithFilter(filter *client.Filter) *Options {
+	o.Filters = append(o.Filters, filter)
+	return o
+}
+
+func (o *Options) WithKeepAlive(pingInterval, pongWait time.Duration) *Options {
+	o.PingInterval = pingInterval
+	o.PongWait = pongWait
+	return o
+}
+
+func (o *Options) WithWriteTimeout(timeout time.Duration) *Options {
+	o.WriteTimeout = timeout
+	return o
+}
+
+func (o *Options) WithHandshakeTimeout(timeout time.Duration) *Options {
+	o.HandshakeTimeout = timeout
+	return o
+}
+
+func (o *Options) WithMaxMessageSize(size int64) *Options {
+	o.MaxMessageSize = size
+	return o
+}
+
+func (o *Options) WithSendBuffer(size int) *Options {
+	o.SendBuffer = size
+	return o
+}
+
+func (o *Options) WithCodec(codec protocol.Codec) *Options {
+	o.Codec = codec
+	return o
+}
+
+func (o *Options) WithReplyTTL(ttl time.Duration) *Options {
+	o.ReplyTTL = ttl
+	return o
+}
+
+func (o *Options) WithCheckOrigin(check func(r *http.Request) bool) *Options {
+	o.CheckOrigin = check
+	return o
+}
+
+func (o *Options) WithTLSConfig(config *tls.Config) *Options {
+	o.TLSConfig = config
+	return o
+}
@@ -0,0 +1,187 @@
+package websocket
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+
+	"github.com/flywave/go-twins/client"
+	"github.com/flywave/go-twins/protocol"
+	"github.com/flywave/go-twins/protocol/signals"
+	"github.com/gorilla/websocket"
+)
+
+var (
+	ErrUnknownRequest = errors.New("websocket: unknown request id")
+	ErrServerClosed   = errors.New("websocket: server closed")
+)
+
+// Server accepts websocket connections and acts as a client.Client for the
+// application behind it: envelopes received from peers are dispatched to its
+// handlers, and Send delivers to every peer holding a matching subscription.
+type Server struct {
+	client.Dispatcher
+	opts     *Options
+	upgrader websocket.Upgrader
+	mu       sync.Mutex
+	sessions map[*session]struct{}
+	routes   *client.Routes
+	closed   bool
+}
+
+type session struct {
+	client.Dispatcher
+	conn *conn
+}
+
+var (
+	_ client.Client = (*Server)(nil)
+	_ http.Handler  = (*Server)(nil)
+)
+
+func NewServer(opts *Options) *Server {
+	if opts == nil {
+		opts = NewOptions()
+	}
+	return &Server{
+		opts: opts,
+		upgrader: websocket.Upgrader{
+			HandshakeTimeout: opts.HandshakeTimeout,
+			CheckOrigin:      opts.CheckOrigin,
+		},
+		sessions: make(map[*session]struct{}),
+		routes:   client.NewRoutes(opts.ReplyTTL),
+	}
+}
+
+func (s *Server) Options() *Options {
+	return s.opts
+}
+
+func (s *Server) Connect() error {
+	s.mu.Lock()
+	s.closed = false
+	s.mu.Unlock()
+	return nil
+}
+
+// Disconnect closes every open connection and rejects new ones until the
+// next Connect.
+func (s *Server) Disconnect() {
+	s.mu.Lock()
+	s.closed = true
+	sessions := s.sessions
+	s.sessions = make(map[*session]struct{})
+	s.mu.Unlock()
+	s.routes.Reset()
+	for sess := range sessions {
+		sess.conn.close(ErrServerClosed)
+	}
+}
+
+func (s *Server) Connections() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.sessions)
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	closed := s.closed
+	s.mu.Unlock()
+	if closed {
+		http.Error(w, ErrServerClosed.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	ws, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	sess := &session{conn: newConn(ws, s.opts)}
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		ws.Close()
+		return
+	}
+	s.sessions[sess] = struct{}{}
+	s.mu.Unlock()
+
+	sess.conn.run(func(message *protocol.Envelope) {
+		s.receive(sess, message)
+	})
+
+	s.mu.Lock()
+	delete(s.sessions, sess)
+	s.mu.Unlock()
+	s.routes.Drop(sess)
+}
+
+func (s *Server) Send(message *protocol.Envelope) error {
+	if message.Topic == nil {
+		return errors.New("websocket: envelope without topic")
+	}
+	s.mu.Lock()
+	sessions := make([]*session, 0, len(s.sessions))
+	for sess := range s.sessions {
+		sessions = append(sessions, sess)
+	}
+	s.mu.Unlock()
+	for _, sess := range sessions {
+		sess.Dispatch("", message)
+	}
+	return nil
+}
+
+func (s *Server) Reply(requestId string, message *protocol.Envelope) error {
+	route, ok := s.routes.Take(requestId)
+	if !ok {
+		return ErrUnknownRequest
+	}
+	sess := route.(*session)
+	return sess.conn.send(client.WithHeaders(message, signals.WithCorrelationId(requestId)))
+}
+
+func (s *Server) receive(sess *session, message *protocol.Envelope) {
+	if isSubscription(message) {
+		sess.control(message)
+		return
+	}
+	requestId := client.CorrelationId(message)
+	if client.IsResponseRequired(message) {
+		if requestId == "" {
+			requestId = client.NewRequestId()
+			message = client.WithHeaders(message, signals.WithCorrelationId(requestId))
+		}
+		s.routes.Put(requestId, message, sess)
+	}
+	s.Dispatch(requestId, message)
+}
+
+func (sess *session) control(message *protocol.Envelope) {
+	filter := subscriptionFilter(message)
+	var found bool
+	for _, sub := range sess.Subscriptions() {
+		if sameFilter(sub.Filter(), filter) {
+			found = true
+			if message.Topic.Action == protocol.ActionUnSubscribe {
+				sub.Unsubscribe()
+			}
+		}
+	}
+	if message.Topic.Action == protocol.ActionSubscribe {
+		status := ackStatus(true)
+		if !found {
+			if _, err := sess.SubscribeFilter(filter, sess.deliver); err != nil {
+				status = http.StatusBadRequest
+			}
+		}
+		sess.conn.send(subscriptionAck(message, protocol.ActionSubscribed, status))
+		return
+	}
+	sess.conn.send(subscriptionAck(message, protocol.ActionUnSubscribed, ackStatus(found)))
+}
+
+func (sess *session) deliver(requestId string, message *protocol.Envelope) {
+	sess.conn.send(message)
+}
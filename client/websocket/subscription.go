@@ -0,0 +1,104 @@
+package websocket
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/flywave/go-twins/client"
+	"github.com/flywave/go-twins/protocol"
+	"github.com/flywave/go-twins/protocol/signals"
+)
+
+var ErrWildcardEntity = errors.New("websocket: subscription topic needs a concrete entity")
+
+// subscriptionEnvelope encodes a filter as a subscribe or unsubscribe
+// request: the filter topic carries the control action, with "_" for open
+// segments, and the path pattern travels as a string value because a
+// pattern need not be a valid path.
+func subscriptionEnvelope(filter *client.Filter, action protocol.TopicAction) (*protocol.Envelope, error) {
+	if filter == nil || filter.Topic == nil {
+		return nil, errors.New("websocket: subscription without topic")
+	}
+	topic := *filter.Topic
+	if wildcardSegment(string(topic.Entity)) {
+		return nil, ErrWildcardEntity
+	}
+	topic.TenantName = controlSegment(topic.TenantName)
+	topic.ChannelName = controlSegment(topic.ChannelName)
+	topic.Criterion = protocol.TopicCriterion(controlSegment(string(topic.Criterion)))
+	topic.Action = action
+	message := &protocol.Envelope{
+		Topic:   &topic,
+		Headers: signals.NewHeaders(signals.WithCorrelationId(client.NewRequestId())),
+	}
+	if filter.Path != "" {
+		if _, err := protocol.CompileMatcher(filter.Path); err != nil {
+			return nil, fmt.Errorf("websocket: invalid path filter %q: %w", filter.Path, err)
+		}
+		message.Value = filter.Path
+	}
+	return message, nil
+}
+
+func wildcardSegment(segment string) bool {
+	return segment == "" || segment == protocol.TopicPlaceholder || segment == "*"
+}
+
+func controlSegment(segment string) string {
+	if wildcardSegment(segment) {
+		return protocol.TopicPlaceholder
+	}
+	return segment
+}
+
+// subscriptionFilter decodes a subscription request. Peers that send the
+// pattern as the envelope path are still understood.
+func subscriptionFilter(message *protocol.Envelope) *client.Filter {
+	topic := *message.Topic
+	topic.Action = ""
+	filter := client.NewFilter(&topic)
+	if pattern, ok := message.Value.(string); ok && pattern != "" {
+		filter.WithPath(pattern)
+	} else if message.Path != nil && !message.Path.Empty() {
+		filter.WithPath(message.Path.String())
+	}
+	return filter
+}
+
+func isSubscription(message *protocol.Envelope) bool {
+	return message.Topic != nil &&
+		(message.Topic.Action == protocol.ActionSubscribe || message.Topic.Action == protocol.ActionUnSubscribe)
+}
+
+func sameFilter(a, b *client.Filter) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if (a.Topic == nil) != (b.Topic == nil) {
+		return false
+	}
+	if a.Topic != nil && a.Topic.String() != b.Topic.String() {
+		return false
+	}
+	return a.Path == b.Path
+}
+
+func subscriptionAck(message *protocol.Envelope, action protocol.TopicAction, status int) *protocol.Envelope {
+	topic := *message.Topic
+	topic.Action = action
+	return &protocol.Envelope{
+		Topic:   &topic,
+		Headers: signals.NewHeaders(signals.WithCorrelationId(client.CorrelationId(message))),
+		Path:    message.Path,
+		Value:   message.Value,
+		Status:  status,
+	}
+}
+
+func ackStatus(found bool) int {
+	if found {
+		return http.StatusOK
+	}
+	return http.StatusNotFound
+}
@@ -1,5 +1,88 @@
 package twins
 
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+)
+
 const (
 	DateTimeFormat = "2006-01-02 15:04:05.999999"
 )
+
+// TimeEncoding selects how a time is written. It is chosen per encoder, e.g.
+// protocol.JSONCodec, and defaults to TimeEncodingLegacy; parsing accepts
+// every encoding regardless.
+type TimeEncoding int
+
+const (
+	// TimeEncodingLegacy writes DateTimeFormat in the time's own location
+	// without its zone, as go-twins always has.
+	TimeEncodingLegacy TimeEncoding = iota
+	TimeEncodingRFC3339Nano
+	TimeEncodingEpochMillis
+)
+
+var ErrInvalidTime = errors.New("twins: invalid time")
+
+func FormatTime(t time.Time, encoding TimeEncoding) string {
+	switch encoding {
+	case TimeEncodingRFC3339Nano:
+		return t.Format(time.RFC3339Nano)
+	case TimeEncodingEpochMillis:
+		return strconv.FormatInt(t.UnixMilli(), 10)
+	}
+	return t.Format(DateTimeFormat)
+}
+
+// EncodeTime returns the JSON value of t: nil for the zero time, so that
+// omitempty drops it, a number for epoch millis and a string otherwise.
+func EncodeTime(t time.Time, encoding TimeEncoding) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	if encoding == TimeEncodingEpochMillis {
+		return t.UnixMilli()
+	}
+	return FormatTime(t, encoding)
+}
+
+// ParseTime accepts RFC 3339, DateTimeFormat (as UTC) and epoch millis.
+func ParseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(DateTimeFormat, s); err == nil {
+		return t, nil
+	}
+	if millis, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.UnixMilli(millis).UTC(), nil
+	}
+	return time.Time{}, ErrInvalidTime
+}
+
+// UnmarshalTime decodes a JSON time written in any TimeEncoding; null and
+// "" yield the zero time.
+func UnmarshalTime(data []byte) (time.Time, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return time.Time{}, nil
+	}
+	if data[0] != '"' {
+		millis, err := strconv.ParseInt(string(data), 10, 64)
+		if err != nil {
+			return time.Time{}, ErrInvalidTime
+		}
+		return time.UnixMilli(millis).UTC(), nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return time.Time{}, err
+	}
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return ParseTime(s)
+}
@@ -0,0 +1,23 @@
+package twins
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLegacyTimeKeepsLocation(t *testing.T) {
+	zone := time.FixedZone("UTC+8", 8*60*60)
+	tm := time.Date(2024, 5, 1, 12, 30, 0, 0, zone)
+	if s := FormatTime(tm, TimeEncodingLegacy); s != "2024-05-01 12:30:00" {
+		t.Fatalf("legacy %q, want the local wall clock", s)
+	}
+	if s := FormatTime(tm, TimeEncodingRFC3339Nano); s != "2024-05-01T12:30:00+08:00" {
+		t.Fatalf("rfc3339 %q", s)
+	}
+	for _, encoding := range []TimeEncoding{TimeEncodingRFC3339Nano, TimeEncodingEpochMillis} {
+		parsed, err := ParseTime(FormatTime(tm, encoding))
+		if err != nil || !parsed.Equal(tm) {
+			t.Fatalf("encoding %d parsed %v, %v; want %v", encoding, parsed, err, tm)
+		}
+	}
+}
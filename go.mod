@@ -1,3 +1,14 @@
 module github.com/flywave/go-twins
 
-go 1.12
+go 1.24.0
+
+require (
+	github.com/fxamacker/cbor/v2 v2.9.0
+	github.com/vmihailenco/msgpack/v5 v5.4.1
+)
+
+require (
+	github.com/stretchr/testify v1.11.1 // indirect
+	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
+	github.com/x448/float16 v0.8.4 // indirect
+)
@@ -16,21 +16,18 @@ type SeriesPoint struct {
 
 func (p *SeriesPoint) UnmarshalJSON(d []byte) error {
 	ps := &struct {
-		Time       string     `json:"time,omitempty"`
-		Name       string     `json:"name"`
-		Dimensions Dimensions `json:"dimensions,omitempty"`
-		Metrics    Metrics    `json:"metrics,omitempty"`
+		Time       json.RawMessage `json:"time,omitempty"`
+		Name       string          `json:"name"`
+		Dimensions Dimensions      `json:"dimensions,omitempty"`
+		Metrics    Metrics         `json:"metrics,omitempty"`
 	}{}
 
 	err := json.Unmarshal(d, ps)
 	if err != nil {
 		return err
 	}
-	if ps.Time != "" {
-		p.Time, err = time.Parse(twins.DateTimeFormat, ps.Time)
-		if err != nil {
-			return err
-		}
+	if p.Time, err = twins.UnmarshalTime(ps.Time); err != nil {
+		return err
 	}
 	p.Name = ps.Name
 	p.Dimensions = ps.Dimensions
@@ -39,13 +36,19 @@ func (p *SeriesPoint) UnmarshalJSON(d []byte) error {
 }
 
 func (p *SeriesPoint) MarshalJSON() ([]byte, error) {
+	return p.EncodeJSON(twins.TimeEncodingLegacy)
+}
+
+// EncodeJSON marshals the point like MarshalJSON, writing its time with the
+// given encoding.
+func (p *SeriesPoint) EncodeJSON(encoding twins.TimeEncoding) ([]byte, error) {
 	ps := struct {
-		Time       string     `json:"time,omitempty"`
-		Name       string     `json:"name"`
-		Dimensions Dimensions `json:"dimensions,omitempty"`
-		Metrics    Metrics    `json:"metrics,omitempty"`
+		Time       interface{} `json:"time,omitempty"`
+		Name       string      `json:"name"`
+		Dimensions Dimensions  `json:"dimensions,omitempty"`
+		Metrics    Metrics     `json:"metrics,omitempty"`
 	}{
-		Time:       p.Time.Format(twins.DateTimeFormat),
+		Time:       twins.EncodeTime(p.Time, encoding),
 		Name:       p.Name,
 		Dimensions: p.Dimensions,
 		Metrics:    p.Metrics,
@@ -77,3 +80,17 @@ func UnmarshalSeries(buf []byte, msg *Series) error {
 func MarshalSeries(msg *Series) ([]byte, error) {
 	return json.Marshal(msg)
 }
+
+// EncodeSeries marshals the points like MarshalSeries, writing their times
+// with the given encoding.
+func EncodeSeries(msg Series, encoding twins.TimeEncoding) ([]byte, error) {
+	points := make([]json.RawMessage, len(msg))
+	for i := range msg {
+		data, err := msg[i].EncodeJSON(encoding)
+		if err != nil {
+			return nil, err
+		}
+		points[i] = data
+	}
+	return json.Marshal(points)
+}
@@ -0,0 +1,227 @@
+package protocol
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/flywave/go-twins"
+)
+
+const (
+	ContentTypeJSON    = "application/json"
+	ContentTypeCBOR    = "application/cbor"
+	ContentTypeMsgPack = "application/msgpack"
+)
+
+// Codec encodes whole envelopes for the wire. Codecs are registered by
+// content type. Transports encode with the codec they are configured with
+// and carry its content type themselves, e.g. as an MQTT 5 property, so that
+// the receiver can pick the codec to decode with. The content-type header of
+// an envelope describes its value and never selects the codec.
+type Codec interface {
+	ContentType() string
+	Marshal(message *Envelope) ([]byte, error)
+	Unmarshal(data []byte, message *Envelope) error
+}
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = make(map[string]Codec)
+)
+
+func init() {
+	RegisterCodec(JSONCodec{})
+	RegisterCodec(CBORCodec{})
+	RegisterCodec(MsgPackCodec{})
+	RegisterCodecAlias("application/x-msgpack", ContentTypeMsgPack)
+	RegisterCodecAlias("application/vnd.msgpack", ContentTypeMsgPack)
+}
+
+func RegisterCodec(codec Codec) {
+	codecsMu.Lock()
+	codecs[mediaType(codec.ContentType())] = codec
+	codecsMu.Unlock()
+}
+
+func RegisterCodecAlias(alias, contentType string) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	if codec, ok := codecs[mediaType(contentType)]; ok {
+		codecs[mediaType(alias)] = codec
+	}
+}
+
+// LookupCodec finds the codec for a content type; parameters such as
+// charset are ignored.
+func LookupCodec(contentType string) (Codec, bool) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	codec, ok := codecs[mediaType(contentType)]
+	return codec, ok
+}
+
+// DefaultCodec returns the codec registered for JSON, which transports use
+// unless they are configured with another one.
+func DefaultCodec() Codec {
+	if codec, ok := LookupCodec(ContentTypeJSON); ok {
+		return codec
+	}
+	return JSONCodec{}
+}
+
+// SniffCodec guesses the codec from the first byte of an encoded envelope,
+// which is a JSON object, a CBOR map or a MessagePack map.
+func SniffCodec(data []byte) (Codec, bool) {
+	for _, b := range data {
+		switch {
+		case b == ' ' || b == '\t' || b == '\r' || b == '\n':
+			continue
+		case b == '{':
+			return JSONCodec{}, true
+		case b >= 0xa0 && b <= 0xbb, b == 0xbf:
+			return CBORCodec{}, true
+		case b >= 0x80 && b <= 0x8f, b == 0xde, b == 0xdf:
+			return MsgPackCodec{}, true
+		}
+		return nil, false
+	}
+	return nil, false
+}
+
+// EncodeEnvelope marshals the envelope with codec, or the default codec when
+// it is nil, and returns the content type the transport should carry. It
+// fails when the path does not validate, see Path.Validate.
+func EncodeEnvelope(codec Codec, message *Envelope) (string, []byte, error) {
+	if codec == nil {
+		codec = DefaultCodec()
+	}
+	if message.Path != nil {
+		if err := message.Path.Validate(); err != nil {
+			return "", nil, err
+		}
+	}
+	data, err := codec.Marshal(message)
+	if err != nil {
+		return "", nil, err
+	}
+	return codec.ContentType(), data, nil
+}
+
+// DecodeEnvelope unmarshals data with the codec registered for contentType,
+// sniffing the encoding when the transport did not carry one.
+func DecodeEnvelope(contentType string, data []byte) (*Envelope, error) {
+	var codec Codec
+	if contentType != "" {
+		c, ok := LookupCodec(contentType)
+		if !ok {
+			return nil, fmt.Errorf("protocol: no codec for content type %q", contentType)
+		}
+		codec = c
+	} else {
+		c, ok := SniffCodec(data)
+		if !ok {
+			return nil, errors.New("protocol: unrecognized envelope encoding")
+		}
+		codec = c
+	}
+	message := &Envelope{}
+	if err := codec.Unmarshal(data, message); err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+func mediaType(contentType string) string {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(contentType))
+	}
+	return mt
+}
+
+// JSONCodec writes the envelope time with TimeEncoding. Register a codec
+// with another encoding to change it for every JSON envelope, e.g.
+// RegisterCodec(JSONCodec{TimeEncoding: twins.TimeEncodingRFC3339Nano}).
+type JSONCodec struct {
+	TimeEncoding twins.TimeEncoding
+}
+
+func (JSONCodec) ContentType() string {
+	return ContentTypeJSON
+}
+
+func (c JSONCodec) Marshal(message *Envelope) ([]byte, error) {
+	return message.EncodeJSON(c.TimeEncoding)
+}
+
+func (JSONCodec) Unmarshal(data []byte, message *Envelope) error {
+	return json.Unmarshal(data, message)
+}
+
+// envelopeWire is the layout binary codecs share: topic and path as their
+// string forms and the time as a native timestamp.
+type envelopeWire struct {
+	Topic    string                 `cbor:"topic" msgpack:"topic"`
+	Headers  map[string]interface{} `cbor:"headers,omitempty" msgpack:"headers,omitempty"`
+	Path     string                 `cbor:"path,omitempty" msgpack:"path,omitempty"`
+	Value    interface{}            `cbor:"value,omitempty" msgpack:"value,omitempty"`
+	Status   int                    `cbor:"status,omitempty" msgpack:"status,omitempty"`
+	Revision int64                  `cbor:"revision,omitempty" msgpack:"revision,omitempty"`
+	Time     *time.Time             `cbor:"time,omitempty" msgpack:"time,omitempty"`
+}
+
+func newEnvelopeWire(message *Envelope) *envelopeWire {
+	w := &envelopeWire{
+		Value:    message.Value,
+		Status:   message.Status,
+		Revision: message.Revision,
+	}
+	if message.Topic != nil {
+		w.Topic = message.Topic.String()
+	}
+	if message.Headers != nil {
+		w.Headers = message.Headers.Values
+	}
+	if message.Path != nil {
+		w.Path = message.Path.String()
+	}
+	if !message.Time.IsZero() {
+		t := message.Time
+		w.Time = &t
+	}
+	return w
+}
+
+func (w *envelopeWire) envelope(message *Envelope) error {
+	*message = Envelope{
+		Value:    w.Value,
+		Status:   w.Status,
+		Revision: w.Revision,
+	}
+	if w.Topic != "" {
+		topic, err := NewTopic(w.Topic)
+		if err != nil {
+			return err
+		}
+		message.Topic = topic
+	}
+	if w.Headers != nil {
+		message.Headers = NewHeadersWithValues(w.Headers)
+	}
+	if w.Path != "" {
+		path, err := NewPath(w.Path)
+		if err != nil {
+			return err
+		}
+		message.Path = path
+	}
+	if w.Time != nil {
+		message.Time = *w.Time
+	}
+	return nil
+}
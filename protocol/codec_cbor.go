@@ -0,0 +1,42 @@
+package protocol
+
+import (
+	"reflect"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+var (
+	cborEncMode cbor.EncMode
+	cborDecMode cbor.DecMode
+)
+
+// Times are written as tagged RFC 3339 strings, which keep nanoseconds; a
+// fractional epoch would round them through a float64.
+func init() {
+	var err error
+	if cborEncMode, err = (cbor.EncOptions{Time: cbor.TimeRFC3339Nano, TimeTag: cbor.EncTagRequired}).EncMode(); err != nil {
+		panic(err)
+	}
+	if cborDecMode, err = (cbor.DecOptions{DefaultMapType: reflect.TypeOf(map[string]interface{}(nil))}).DecMode(); err != nil {
+		panic(err)
+	}
+}
+
+type CBORCodec struct{}
+
+func (CBORCodec) ContentType() string {
+	return ContentTypeCBOR
+}
+
+func (CBORCodec) Marshal(message *Envelope) ([]byte, error) {
+	return cborEncMode.Marshal(newEnvelopeWire(message))
+}
+
+func (CBORCodec) Unmarshal(data []byte, message *Envelope) error {
+	w := &envelopeWire{}
+	if err := cborDecMode.Unmarshal(data, w); err != nil {
+		return err
+	}
+	return w.envelope(message)
+}
@@ -0,0 +1,27 @@
+package protocol
+
+import (
+	"bytes"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+type MsgPackCodec struct{}
+
+func (MsgPackCodec) ContentType() string {
+	return ContentTypeMsgPack
+}
+
+func (MsgPackCodec) Marshal(message *Envelope) ([]byte, error) {
+	return msgpack.Marshal(newEnvelopeWire(message))
+}
+
+func (MsgPackCodec) Unmarshal(data []byte, message *Envelope) error {
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	dec.UseLooseInterfaceDecoding(true)
+	w := &envelopeWire{}
+	if err := dec.Decode(w); err != nil {
+		return err
+	}
+	return w.envelope(message)
+}
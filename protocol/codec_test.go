@@ -0,0 +1,107 @@
+package protocol
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/flywave/go-twins"
+)
+
+func TestJSONCodecTimeEncoding(t *testing.T) {
+	tm := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	msg := &Envelope{Topic: &Topic{TenantName: "t", ChannelName: "c", Entity: EntityThings, Criterion: CriterionEvents, Action: ActionModified}, Time: tm}
+	for encoding, want := range map[twins.TimeEncoding]string{
+		twins.TimeEncodingLegacy:      `"time":"2024-05-01 12:30:00"`,
+		twins.TimeEncodingRFC3339Nano: `"time":"2024-05-01T12:30:00Z"`,
+		twins.TimeEncodingEpochMillis: `"time":1714566600000`,
+	} {
+		codec := JSONCodec{TimeEncoding: encoding}
+		data, err := codec.Marshal(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("encoding %d wrote %s, want %s", encoding, data, want)
+		}
+		decoded := &Envelope{}
+		if err := codec.Unmarshal(data, decoded); err != nil {
+			t.Fatal(err)
+		}
+		if !decoded.Time.Equal(tm) {
+			t.Fatalf("encoding %d decoded %v, want %v", encoding, decoded.Time, tm)
+		}
+	}
+}
+
+func TestBinaryCodecRoundTrip(t *testing.T) {
+	tm := time.Date(2024, 5, 1, 12, 30, 0, 123000000, time.UTC)
+	msg := &Envelope{
+		Topic:    &Topic{TenantName: "t", ChannelName: "c", Entity: EntityThings, Criterion: CriterionCommands, Action: ActionCreateOrModify},
+		Headers:  NewHeadersWithValues(map[string]interface{}{HeaderCorrelationId: "r1", HeaderResponseRequired: true, HeaderSchemaVersion: int64(2), HeaderContentType: "application/merge-patch+json"}),
+		Path:     (&Path{}).WithThingFeaturePropertie("thing/1", "f1", "temp"),
+		Value:    map[string]interface{}{"celsius": 21.5, "unit": "C"},
+		Status:   200,
+		Revision: 7,
+		Time:     tm,
+	}
+	for _, codec := range []Codec{CBORCodec{}, MsgPackCodec{}} {
+		t.Run(codec.ContentType(), func(t *testing.T) {
+			contentType, data, err := EncodeEnvelope(codec, msg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if contentType != codec.ContentType() {
+				t.Fatalf("content type %s, want %s", contentType, codec.ContentType())
+			}
+			decoded, err := DecodeEnvelope(contentType, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if decoded.Topic.String() != msg.Topic.String() || decoded.Path.String() != msg.Path.String() {
+				t.Fatalf("decoded %s %s", decoded.Topic, decoded.Path)
+			}
+			if decoded.Status != msg.Status || decoded.Revision != msg.Revision || !decoded.Time.Equal(tm) {
+				t.Fatalf("decoded status %d, revision %d, time %v", decoded.Status, decoded.Revision, decoded.Time)
+			}
+			if !reflect.DeepEqual(decoded.Value, msg.Value) {
+				t.Fatalf("value %#v, want %#v", decoded.Value, msg.Value)
+			}
+			if id, _ := decoded.Headers.GetString(HeaderCorrelationId); id != "r1" {
+				t.Fatalf("correlation-id %q", id)
+			}
+			if required, _ := decoded.Headers.GetBool(HeaderResponseRequired); !required {
+				t.Fatal("response-required lost")
+			}
+			if version, err := decoded.Headers.GetInt64(HeaderSchemaVersion); err != nil || version != 2 {
+				t.Fatalf("schema-version %v, %v", version, err)
+			}
+			if decoded.Headers.ContentType() != "application/merge-patch+json" {
+				t.Fatalf("content-type %q", decoded.Headers.ContentType())
+			}
+			// sniffing works without the transport content type
+			if sniffed, err := DecodeEnvelope("", data); err != nil || sniffed.Path.String() != msg.Path.String() {
+				t.Fatalf("sniffed %v, %v", sniffed, err)
+			}
+		})
+	}
+}
+
+func TestContentTypeHeaderDoesNotSelectCodec(t *testing.T) {
+	msg := &Envelope{
+		Topic:   &Topic{TenantName: "t", ChannelName: "c", Entity: EntityThings, Criterion: CriterionEvents, Action: ActionModified},
+		Headers: NewHeadersWithValues(map[string]interface{}{HeaderContentType: "text/plain"}),
+		Value:   "hello",
+	}
+	contentType, data, err := EncodeEnvelope(nil, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contentType != ContentTypeJSON {
+		t.Fatalf("encoded as %s, want JSON", contentType)
+	}
+	if _, err := DecodeEnvelope(contentType, data); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,54 @@
+package ditto
+
+import (
+	"strings"
+
+	"github.com/flywave/go-twins/protocol"
+)
+
+// Ditto names its message headers ditto-message-*; all other shared header
+// names only differ in case, which protocol.Headers ignores.
+var messageHeaders = map[string]string{
+	"ditto-message-subject":    protocol.HeaderMessageSubject,
+	"ditto-message-id":         protocol.HeaderMessageId,
+	"ditto-message-direction":  protocol.HeaderMessageDirection,
+	"ditto-message-thing-id":   protocol.HeaderMessageThingId,
+	"ditto-message-feature-id": protocol.HeaderMessageFeatureId,
+}
+
+// toHeaders maps header names onto the go-twins spelling and coerces the
+// values of declared headers: Ditto may send booleans and numbers as
+// strings. Values that do not coerce are kept as sent.
+func toHeaders(values map[string]interface{}) map[string]interface{} {
+	headers := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		name := headerName(key)
+		if coerced, err := protocol.CoerceHeader(name, value); err == nil {
+			value = coerced
+		}
+		headers[name] = value
+	}
+	return headers
+}
+
+func fromHeaders(values map[string]interface{}) map[string]interface{} {
+	headers := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		name := strings.ToLower(key)
+		for dittoName, twinsName := range messageHeaders {
+			if key == twinsName {
+				name = dittoName
+			}
+		}
+		headers[name] = value
+	}
+	return headers
+}
+
+func headerName(key string) string {
+	lower := strings.ToLower(key)
+	if name, ok := messageHeaders[lower]; ok {
+		return name
+	}
+	return protocol.CanonicalHeaderName(key)
+}
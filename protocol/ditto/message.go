@@ -0,0 +1,130 @@
+package ditto
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/flywave/go-twins/protocol"
+)
+
+var ErrMissingTopic = errors.New("ditto: envelope without topic")
+
+// Message is a Ditto Protocol message. fields and extra are not carried by
+// envelopes and are dropped on conversion.
+type Message struct {
+	Topic     string                 `json:"topic"`
+	Headers   map[string]interface{} `json:"headers,omitempty"`
+	Path      string                 `json:"path"`
+	Value     interface{}            `json:"value,omitempty"`
+	Status    int                    `json:"status,omitempty"`
+	Revision  int64                  `json:"revision,omitempty"`
+	Timestamp string                 `json:"timestamp,omitempty"`
+}
+
+func Unmarshal(data []byte) (*protocol.Envelope, error) {
+	m := &Message{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, err
+	}
+	return ToEnvelope(m)
+}
+
+func Marshal(msg *protocol.Envelope) ([]byte, error) {
+	m, err := FromEnvelope(msg)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(m)
+}
+
+func ToEnvelope(m *Message) (*protocol.Envelope, error) {
+	t, err := ParseTopic(m.Topic)
+	if err != nil {
+		return nil, err
+	}
+	topic, create, err := toTopic(t)
+	if err != nil {
+		return nil, err
+	}
+	path, err := ToPath(t.ThingId(), m.Path)
+	if err != nil {
+		return nil, err
+	}
+	msg := &protocol.Envelope{
+		Topic:    topic,
+		Path:     path,
+		Value:    m.Value,
+		Status:   m.Status,
+		Revision: m.Revision,
+	}
+	headers := toHeaders(m.Headers)
+	if create {
+		headers[protocol.HeaderIfNoneMatch] = "*"
+	}
+	if len(headers) > 0 {
+		msg.Headers = protocol.NewHeadersWithValues(headers)
+	}
+	if m.Timestamp != "" {
+		if msg.Time, err = time.Parse(time.RFC3339Nano, m.Timestamp); err != nil {
+			return nil, err
+		}
+	}
+	return msg, nil
+}
+
+// FromEnvelope converts a thing envelope. A createmodify command guarded by
+// If-None-Match "*" becomes a Ditto create; the guard is implied by the
+// action and not repeated in the headers.
+func FromEnvelope(msg *protocol.Envelope) (*Message, error) {
+	if msg.Topic == nil {
+		return nil, ErrMissingTopic
+	}
+	thing, path, err := FromPath(msg.Path)
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]interface{}
+	if msg.Headers != nil {
+		values = msg.Headers.Values
+	}
+	create := msg.Topic.Criterion == protocol.CriterionCommands &&
+		msg.Topic.Action == protocol.ActionCreateOrModify &&
+		msg.Headers.IfNoneMatch() == "*"
+	t, err := fromTopic(msg.Topic, thing, create)
+	if err != nil {
+		return nil, err
+	}
+	if t.Criterion == string(protocol.CriterionMessages) && t.Action == "" {
+		var subject string
+		switch e := msg.Path.Entity.(type) {
+		case *protocol.ThingMessagesPath:
+			subject = e.Subject
+		case *protocol.ThingFeatureMessagesPath:
+			subject = e.Subject
+		}
+		if t.Action, err = protocol.UnescapePathName(subject); err != nil {
+			return nil, err
+		}
+	}
+	m := &Message{
+		Topic:    t.String(),
+		Path:     path,
+		Value:    msg.Value,
+		Status:   msg.Status,
+		Revision: msg.Revision,
+	}
+	if len(values) > 0 {
+		m.Headers = fromHeaders(values)
+		if create {
+			delete(m.Headers, "if-none-match")
+		}
+		if len(m.Headers) == 0 {
+			m.Headers = nil
+		}
+	}
+	if !msg.Time.IsZero() {
+		m.Timestamp = msg.Time.UTC().Format(time.RFC3339Nano)
+	}
+	return m, nil
+}
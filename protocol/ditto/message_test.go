@@ -0,0 +1,45 @@
+package ditto
+
+import (
+	"testing"
+
+	"github.com/flywave/go-twins/protocol"
+)
+
+func TestHeadersUseCanonicalNames(t *testing.T) {
+	msg, err := Unmarshal([]byte(`{"topic":"org.example/thing1/things/twin/commands/modify","headers":{"correlation-id":"c1","etag":"\"r1\"","response-required":"false"},"path":"/attributes/location","value":"here"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := msg.Headers.Values[protocol.HeaderETag]; !ok {
+		t.Fatalf("headers %v, want %s", msg.Headers.Values, protocol.HeaderETag)
+	}
+	if required, err := msg.Headers.GetBool(protocol.HeaderResponseRequired); err != nil || required {
+		t.Fatalf("response-required %v, %v", required, err)
+	}
+}
+
+func TestMessageRoundTrip(t *testing.T) {
+	const data = `{"topic":"org.example/thing1/things/live/messages/alarms/fire","path":"/features/smoke/outbox/messages/alarms/fire","value":"on"}`
+	msg, err := Unmarshal([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := (&protocol.Path{}).WithThingFeatureMessages("org.example:thing1", "smoke", protocol.DirectionOutgoing, "alarms/fire")
+	if msg.Path.String() != want.String() {
+		t.Fatalf("path %s, want %s", msg.Path, want)
+	}
+	if msg.Topic.TenantName != "org.example" {
+		t.Fatalf("tenant %q, want org.example", msg.Topic.TenantName)
+	}
+	m, err := FromEnvelope(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Topic != "org.example/thing1/things/live/messages/alarms/fire" || m.Path != "/features/smoke/outbox/messages/alarms/fire" {
+		t.Fatalf("converted back to %s %s", m.Topic, m.Path)
+	}
+	if id := protocol.NewEnvelopeValueFinder(msg).GetValue(protocol.PLACE_HOLDERS_THING_ID); id != "org.example:thing1" {
+		t.Fatalf("thing:id %q, want org.example:thing1", id)
+	}
+}
@@ -0,0 +1,112 @@
+package ditto
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/flywave/go-twins/protocol"
+)
+
+var ErrUnsupportedPath = errors.New("ditto: unsupported path")
+
+var directions = map[string]protocol.DirectionType{
+	"inbox":  protocol.DirectionIncoming,
+	"outbox": protocol.DirectionOutgoing,
+}
+
+// ToPath resolves a Ditto path, relative to the thing, to a go-twins thing
+// path. desiredProperties becomes desired and inbox/outbox become the
+// incoming/outgoing message directions; a message subject may span several
+// segments. thing is the thing id, see Topic.ThingId.
+func ToPath(thing string, path string) (*protocol.Path, error) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segments) == 1 && segments[0] == "" {
+		segments = nil
+	}
+	p := &protocol.Path{}
+	switch {
+	case len(segments) == 0:
+		return p.WithThing(thing), nil
+	case segments[0] == "attributes":
+		return p.WithThingAttribute(thing, strings.Join(segments[1:], "/")), nil
+	case len(segments) >= 3 && segments[1] == "messages" && directions[segments[0]] != "":
+		return p.WithThingMessages(thing, directions[segments[0]], strings.Join(segments[2:], "/")), nil
+	case segments[0] != "features":
+		return nil, ErrUnsupportedPath
+	case len(segments) == 1:
+		return p.WithThingFeatures(thing), nil
+	case len(segments) == 2:
+		return p.WithThingFeature(thing, segments[1]), nil
+	}
+
+	feature, rest := segments[1], segments[2:]
+	switch {
+	case rest[0] == "properties":
+		return p.WithThingFeaturePropertie(thing, feature, strings.Join(rest[1:], "/")), nil
+	case rest[0] == "desiredProperties":
+		return p.WithThingFeatureDesired(thing, feature, strings.Join(rest[1:], "/")), nil
+	case len(rest) >= 3 && rest[1] == "messages" && directions[rest[0]] != "":
+		return p.WithThingFeatureMessages(thing, feature, directions[rest[0]], strings.Join(rest[2:], "/")), nil
+	}
+	return nil, ErrUnsupportedPath
+}
+
+// FromPath returns the thing name and the Ditto path of a go-twins thing
+// path, with the path escaping undone. Feature attributes and time series
+// have no Ditto counterpart.
+func FromPath(p *protocol.Path) (thing string, path string, err error) {
+	thing, path, err = fromPath(p)
+	if err != nil {
+		return "", "", err
+	}
+	if thing, err = protocol.UnescapePathName(thing); err != nil {
+		return "", "", err
+	}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if segments[i], err = protocol.UnescapePathName(segment); err != nil {
+			return "", "", err
+		}
+	}
+	return thing, strings.Join(segments, "/"), nil
+}
+
+func fromPath(p *protocol.Path) (thing string, path string, err error) {
+	if p == nil || p.Empty() {
+		return "", "", ErrUnsupportedPath
+	}
+	switch e := p.Entity.(type) {
+	case *protocol.ThingPath:
+		return e.Thing, "/", nil
+	case *protocol.ThingAttributesPath:
+		return e.Thing, join("/attributes", e.Attribute), nil
+	case *protocol.ThingMessagesPath:
+		return e.Thing, join("/"+outbox(e.Direction)+"/messages", e.Subject), nil
+	case *protocol.ThingFeaturesPath:
+		return e.Thing, join("/features", e.Feature), nil
+	case *protocol.ThingFeaturePropertiesPath:
+		if e.TimeSeries {
+			return "", "", ErrUnsupportedPath
+		}
+		return e.Thing, join(join("/features", e.Feature)+"/properties", e.Propertie), nil
+	case *protocol.ThingFeatureDesiredPath:
+		return e.Thing, join(join("/features", e.Feature)+"/desiredProperties", e.Propertie), nil
+	case *protocol.ThingFeatureMessagesPath:
+		return e.Thing, join(join("/features", e.Feature)+"/"+outbox(e.Direction)+"/messages", e.Subject), nil
+	}
+	return "", "", ErrUnsupportedPath
+}
+
+func outbox(direction protocol.DirectionType) string {
+	if direction == protocol.DirectionOutgoing {
+		return "outbox"
+	}
+	return "inbox"
+}
+
+func join(base, sub string) string {
+	if sub == "" {
+		return base
+	}
+	return base + "/" + sub
+}
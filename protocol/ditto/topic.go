@@ -0,0 +1,194 @@
+package ditto
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/flywave/go-twins/protocol"
+)
+
+const (
+	GroupThings = "things"
+
+	ChannelTwin = "twin"
+	ChannelLive = "live"
+
+	ActionCreate   = "create"
+	ActionModify   = "modify"
+	ActionMerge    = "merge"
+	ActionRetrieve = "retrieve"
+	ActionDelete   = "delete"
+	ActionCreated  = "created"
+	ActionModified = "modified"
+	ActionMerged   = "merged"
+	ActionDeleted  = "deleted"
+)
+
+var (
+	ErrInvalidTopic     = errors.New("ditto: invalid topic")
+	ErrUnsupportedGroup = errors.New("ditto: only the things group is supported")
+	ErrUnsupportedTopic = errors.New("ditto: unsupported topic")
+)
+
+// Topic is a Ditto Protocol topic:
+// {namespace}/{name}/{group}/{channel}/{criterion}/{action}.
+type Topic struct {
+	Namespace string
+	Name      string
+	Group     string
+	Channel   string
+	Criterion string
+	Action    string
+}
+
+func ParseTopic(s string) (*Topic, error) {
+	segments := strings.SplitN(s, "/", 6)
+	if len(segments) < 5 {
+		return nil, ErrInvalidTopic
+	}
+	for _, segment := range segments {
+		if segment == "" {
+			return nil, ErrInvalidTopic
+		}
+	}
+	topic := &Topic{
+		Namespace: segments[0],
+		Name:      segments[1],
+		Group:     segments[2],
+		Channel:   segments[3],
+		Criterion: segments[4],
+	}
+	if len(segments) == 6 {
+		topic.Action = segments[5]
+	}
+	return topic, nil
+}
+
+// ThingId returns the Ditto thing id {namespace}:{name}, which is also the
+// thing name of the go-twins path.
+func (t *Topic) ThingId() string {
+	if t.Name == protocol.TopicPlaceholder {
+		return t.Name
+	}
+	return t.Namespace + ":" + t.Name
+}
+
+// splitThingId splits a thing id at its first ":"; a name without one has
+// no namespace.
+func splitThingId(thing string) (namespace, name string) {
+	if i := strings.IndexByte(thing, ':'); i >= 0 {
+		return thing[:i], thing[i+1:]
+	}
+	return "", thing
+}
+
+func (t *Topic) String() string {
+	s := strings.Join([]string{t.Namespace, t.Name, t.Group, t.Channel, t.Criterion}, "/")
+	if t.Action != "" {
+		s += "/" + t.Action
+	}
+	return s
+}
+
+var commandActions = map[string]protocol.TopicAction{
+	ActionCreate:   protocol.ActionCreateOrModify,
+	ActionModify:   protocol.ActionCreateOrModify,
+	ActionMerge:    protocol.ActionMerge,
+	ActionRetrieve: protocol.ActionRetrieve,
+	ActionDelete:   protocol.ActionDelete,
+}
+
+var eventActions = map[string]protocol.TopicAction{
+	ActionCreated:  protocol.ActionCreated,
+	ActionModified: protocol.ActionModified,
+	ActionMerged:   protocol.ActionMerged,
+	ActionDeleted:  protocol.ActionDeleted,
+}
+
+// toTopic converts the Ditto topic. Creating is expressed in go-twins as
+// createmodify guarded by If-None-Match "*", so create reports create=true
+// for the caller to set the header.
+func toTopic(t *Topic) (topic *protocol.Topic, create bool, err error) {
+	if t.Group != GroupThings {
+		return nil, false, ErrUnsupportedGroup
+	}
+	topic = &protocol.Topic{
+		TenantName:  t.Namespace,
+		ChannelName: t.Channel,
+		Entity:      protocol.EntityThings,
+		Criterion:   protocol.TopicCriterion(t.Criterion),
+	}
+	switch topic.Criterion {
+	case protocol.CriterionCommands:
+		action, ok := commandActions[t.Action]
+		if !ok {
+			return nil, false, ErrUnsupportedTopic
+		}
+		topic.Action = action
+		create = t.Action == ActionCreate
+	case protocol.CriterionEvents:
+		action, ok := eventActions[t.Action]
+		if !ok {
+			return nil, false, ErrUnsupportedTopic
+		}
+		topic.Action = action
+	case protocol.CriterionMessages:
+		topic.Action = protocol.TopicAction(t.Action)
+	case protocol.CriterionErrors:
+		if t.Action != "" {
+			return nil, false, ErrUnsupportedTopic
+		}
+	default:
+		return nil, false, ErrUnsupportedTopic
+	}
+	return topic, create, nil
+}
+
+// fromTopic builds the Ditto topic for a thing; the namespace of its thing
+// id takes precedence over the tenant.
+func fromTopic(topic *protocol.Topic, thing string, create bool) (*Topic, error) {
+	if topic.Entity != protocol.EntityThings {
+		return nil, ErrUnsupportedGroup
+	}
+	namespace, name := splitThingId(thing)
+	if namespace == "" {
+		namespace = topic.TenantName
+	}
+	t := &Topic{
+		Namespace: namespace,
+		Name:      name,
+		Group:     GroupThings,
+		Channel:   topic.ChannelName,
+		Criterion: string(topic.Criterion),
+	}
+	if t.Name == "" {
+		t.Name = protocol.TopicPlaceholder
+	}
+	switch topic.Criterion {
+	case protocol.CriterionCommands:
+		switch topic.Action {
+		case protocol.ActionCreateOrModify:
+			t.Action = ActionModify
+			if create {
+				t.Action = ActionCreate
+			}
+		case protocol.ActionMerge, protocol.ActionRetrieve, protocol.ActionDelete:
+			t.Action = string(topic.Action)
+		default:
+			return nil, ErrUnsupportedTopic
+		}
+	case protocol.CriterionEvents:
+		switch topic.Action {
+		case protocol.ActionCreated, protocol.ActionModified, protocol.ActionMerged, protocol.ActionDeleted:
+			t.Action = string(topic.Action)
+		default:
+			return nil, ErrUnsupportedTopic
+		}
+	case protocol.CriterionMessages:
+		t.Action = string(topic.Action)
+	case protocol.CriterionErrors:
+	default:
+		return nil, ErrUnsupportedTopic
+	}
+	return t, nil
+}
@@ -54,24 +54,21 @@ func (msg *Envelope) WithTime(t time.Time) *Envelope {
 
 func (msg *Envelope) UnmarshalJSON(d []byte) error {
 	ps := &struct {
-		Time     string      `json:"time,omitempty"`
-		Topic    *Topic      `json:"topic"`
-		Headers  *Headers    `json:"headers,omitempty"`
-		Path     *Path       `json:"path"`
-		Value    interface{} `json:"value,omitempty"`
-		Status   int         `json:"status,omitempty"`
-		Revision int64       `json:"revision,omitempty"`
+		Time     json.RawMessage `json:"time,omitempty"`
+		Topic    *Topic          `json:"topic"`
+		Headers  *Headers        `json:"headers,omitempty"`
+		Path     *Path           `json:"path"`
+		Value    interface{}     `json:"value,omitempty"`
+		Status   int             `json:"status,omitempty"`
+		Revision int64           `json:"revision,omitempty"`
 	}{}
 
 	err := json.Unmarshal(d, ps)
 	if err != nil {
 		return err
 	}
-	if ps.Time != "" {
-		msg.Time, err = time.Parse(twins.DateTimeFormat, ps.Time)
-		if err != nil {
-			return err
-		}
+	if msg.Time, err = twins.UnmarshalTime(ps.Time); err != nil {
+		return err
 	}
 	msg.Topic = ps.Topic
 	msg.Headers = ps.Headers
@@ -83,8 +80,14 @@ func (msg *Envelope) UnmarshalJSON(d []byte) error {
 }
 
 func (msg *Envelope) MarshalJSON() ([]byte, error) {
+	return msg.EncodeJSON(twins.TimeEncodingLegacy)
+}
+
+// EncodeJSON marshals the envelope like MarshalJSON, writing its time with
+// the given encoding.
+func (msg *Envelope) EncodeJSON(encoding twins.TimeEncoding) ([]byte, error) {
 	ps := struct {
-		Time     string      `json:"time,omitempty"`
+		Time     interface{} `json:"time,omitempty"`
 		Topic    *Topic      `json:"topic"`
 		Headers  *Headers    `json:"headers,omitempty"`
 		Path     *Path       `json:"path"`
@@ -92,7 +95,7 @@ func (msg *Envelope) MarshalJSON() ([]byte, error) {
 		Status   int         `json:"status,omitempty"`
 		Revision int64       `json:"revision,omitempty"`
 	}{
-		Time:     msg.Time.Format(twins.DateTimeFormat),
+		Time:     twins.EncodeTime(msg.Time, encoding),
 		Topic:    msg.Topic,
 		Headers:  msg.Headers,
 		Path:     msg.Path,
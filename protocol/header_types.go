@@ -0,0 +1,254 @@
+package protocol
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type HeaderType int
+
+const (
+	HeaderTypeString HeaderType = iota
+	HeaderTypeBool
+	HeaderTypeInt
+)
+
+func (t HeaderType) String() string {
+	switch t {
+	case HeaderTypeBool:
+		return "bool"
+	case HeaderTypeInt:
+		return "int"
+	}
+	return "string"
+}
+
+var ErrInvalidHeader = errors.New("invalid header")
+
+// headerTypesMu guards headerTypes and canonicalHeaders, which every header
+// access reads and RegisterHeaderType writes.
+var headerTypesMu sync.RWMutex
+
+var headerTypes = map[string]HeaderType{
+	HeaderCorrelationId:    HeaderTypeString,
+	HeaderResponseRequired: HeaderTypeBool,
+	HeaderChannel:          HeaderTypeString,
+	HeaderDryRun:           HeaderTypeBool,
+	HeaderOrigin:           HeaderTypeString,
+	HeaderOriginator:       HeaderTypeString,
+	HeaderETag:             HeaderTypeString,
+	HeaderIfMatch:          HeaderTypeString,
+	HeaderIfNoneMatch:      HeaderTypeString,
+	HeaderReplyTarget:      HeaderTypeInt,
+	HeaderReplyTo:          HeaderTypeString,
+	HeaderTimeout:          HeaderTypeString,
+	HeaderSchemaVersion:    HeaderTypeInt,
+	HeaderContentType:      HeaderTypeString,
+	HeaderStatus:           HeaderTypeInt,
+	HeaderMessageSubject:   HeaderTypeString,
+	HeaderMessageId:        HeaderTypeString,
+	HeaderMessageDirection: HeaderTypeString,
+	HeaderMessageThingId:   HeaderTypeString,
+	HeaderMessageFeatureId: HeaderTypeString,
+}
+
+var canonicalHeaders = func() map[string]string {
+	names := make(map[string]string, len(headerTypes))
+	for name := range headerTypes {
+		names[strings.ToLower(name)] = name
+	}
+	return names
+}()
+
+// RegisterHeaderType declares the type of an application header so that
+// Validate and CoerceHeader treat it like the built-in ones. It is usually
+// called from init functions but is safe to call at any time.
+func RegisterHeaderType(name string, typ HeaderType) {
+	headerTypesMu.Lock()
+	defer headerTypesMu.Unlock()
+	headerTypes[name] = typ
+	canonicalHeaders[strings.ToLower(name)] = name
+}
+
+func LookupHeaderType(name string) (HeaderType, bool) {
+	headerTypesMu.RLock()
+	defer headerTypesMu.RUnlock()
+	canonical, ok := canonicalHeaders[strings.ToLower(name)]
+	if !ok {
+		canonical = name
+	}
+	typ, ok := headerTypes[canonical]
+	return typ, ok
+}
+
+func lookupCanonicalHeader(name string) (string, bool) {
+	headerTypesMu.RLock()
+	defer headerTypesMu.RUnlock()
+	canonical, ok := canonicalHeaders[strings.ToLower(name)]
+	return canonical, ok
+}
+
+// CoerceHeader converts value to the declared type of the header: JSON
+// numbers, numeric and boolean strings and booleans are accepted where they
+// are unambiguous. Undeclared headers are returned unchanged.
+func CoerceHeader(name string, value interface{}) (interface{}, error) {
+	typ, ok := LookupHeaderType(name)
+	if !ok || value == nil {
+		return value, nil
+	}
+	var (
+		coerced interface{}
+		err     error
+	)
+	switch typ {
+	case HeaderTypeBool:
+		coerced, err = coerceBool(value)
+	case HeaderTypeInt:
+		coerced, err = coerceInt(value)
+	default:
+		coerced, err = coerceString(value)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w %s: %v is not of type %s", ErrInvalidHeader, name, value, typ)
+	}
+	return coerced, nil
+}
+
+func coerceString(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32), nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, json.Number:
+		return fmt.Sprint(v), nil
+	}
+	return "", ErrInvalidHeader
+}
+
+func coerceBool(value interface{}) (bool, error) {
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case string:
+		return strconv.ParseBool(v)
+	}
+	if i, err := coerceInt(value); err == nil && (i == 0 || i == 1) {
+		return i == 1, nil
+	}
+	return false, ErrInvalidHeader
+}
+
+func coerceInt(value interface{}) (int64, error) {
+	switch v := value.(type) {
+	case int64:
+		return v, nil
+	case int:
+		return int64(v), nil
+	case int8:
+		return int64(v), nil
+	case int16:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case uint8:
+		return int64(v), nil
+	case uint16:
+		return int64(v), nil
+	case uint32:
+		return int64(v), nil
+	case uint:
+		if uint64(v) <= math.MaxInt64 {
+			return int64(v), nil
+		}
+	case uint64:
+		if v <= math.MaxInt64 {
+			return int64(v), nil
+		}
+	case float32:
+		return coerceInt(float64(v))
+	case float64:
+		if v == math.Trunc(v) && v >= math.MinInt64 && v < math.MaxInt64 {
+			return int64(v), nil
+		}
+	case json.Number:
+		return v.Int64()
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	}
+	return 0, ErrInvalidHeader
+}
+
+func (h *Headers) GetString(name string) (string, error) {
+	value, err := h.coerced(name)
+	if value == nil || err != nil {
+		return "", err
+	}
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	return coerceString(value)
+}
+
+func (h *Headers) GetBool(name string) (bool, error) {
+	value, err := h.coerced(name)
+	if value == nil || err != nil {
+		return false, err
+	}
+	if b, ok := value.(bool); ok {
+		return b, nil
+	}
+	return coerceBool(value)
+}
+
+func (h *Headers) GetInt64(name string) (int64, error) {
+	value, err := h.coerced(name)
+	if value == nil || err != nil {
+		return 0, err
+	}
+	if i, ok := value.(int64); ok {
+		return i, nil
+	}
+	return coerceInt(value)
+}
+
+func (h *Headers) coerced(name string) (interface{}, error) {
+	value, _ := h.Get(name)
+	return CoerceHeader(name, value)
+}
+
+// Validate checks every declared header against its type and reports all
+// offending headers.
+func (h *Headers) Validate() error {
+	if h == nil {
+		return nil
+	}
+	var errs []error
+	for _, name := range h.Names() {
+		if _, err := CoerceHeader(name, h.Values[name]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Normalize replaces the values of declared headers by their coerced form,
+// e.g. after decoding JSON where every number is a float64.
+func (h *Headers) Normalize() error {
+	if err := h.Validate(); err != nil {
+		return err
+	}
+	for name, value := range h.Values {
+		h.Values[name], _ = CoerceHeader(name, value)
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package protocol
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestRegisterHeaderTypeWhileInUse(t *testing.T) {
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			RegisterHeaderType(fmt.Sprintf("X-Test-%d", i), HeaderTypeInt)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		h := &Headers{}
+		for i := 0; i < 100; i++ {
+			h.Set(fmt.Sprintf("x-test-%d", i), "1")
+			if err := h.Validate(); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+	wg.Wait()
+
+	h := NewHeadersWithValues(map[string]interface{}{"x-test-7": "7"})
+	if v, err := h.GetInt64("X-Test-7"); err != nil || v != 7 {
+		t.Fatalf("got %v, %v; want 7", v, err)
+	}
+	if _, ok := h.Values["X-Test-7"]; !ok {
+		t.Fatalf("stored %v, want the registered spelling", h.Values)
+	}
+}
+
+func TestTypedHeadersSurviveJSON(t *testing.T) {
+	h := NewHeadersWithValues(map[string]interface{}{
+		HeaderReplyTarget:      int64(3),
+		HeaderSchemaVersion:    2,
+		HeaderStatus:           uint16(404),
+		HeaderResponseRequired: true,
+		HeaderDryRun:           "true",
+		HeaderCorrelationId:    "c1",
+		HeaderTimeout:          "1s",
+	})
+	data, err := json.Marshal(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Headers
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := decoded.Values[HeaderReplyTarget].(float64); !ok {
+		t.Fatalf("reply-target decoded as %T, want float64", decoded.Values[HeaderReplyTarget])
+	}
+	if decoded.ReplyTarget() != 3 || decoded.Version() != 2 || !decoded.IsResponseRequired() || !decoded.IsDryRun() {
+		t.Fatalf("typed getters after JSON: %v", decoded.Values)
+	}
+	if status, err := decoded.GetInt64(HeaderStatus); err != nil || status != 404 {
+		t.Fatalf("status %v, %v", status, err)
+	}
+	if decoded.CorrelationId() != "c1" || decoded.Timeout() != "1s" {
+		t.Fatalf("string getters after JSON: %v", decoded.Values)
+	}
+
+	if err := decoded.Normalize(); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		HeaderReplyTarget:      int64(3),
+		HeaderSchemaVersion:    int64(2),
+		HeaderStatus:           int64(404),
+		HeaderResponseRequired: true,
+		HeaderDryRun:           true,
+		HeaderCorrelationId:    "c1",
+		HeaderTimeout:          "1s",
+	}
+	if !reflect.DeepEqual(decoded.Values, want) {
+		t.Fatalf("normalized %#v, want %#v", decoded.Values, want)
+	}
+}
+
+func TestCoerceHeader(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		value interface{}
+		want  interface{}
+	}{
+		{HeaderSchemaVersion, float64(2), int64(2)},
+		{HeaderSchemaVersion, "2", int64(2)},
+		{HeaderSchemaVersion, json.Number("2"), int64(2)},
+		{HeaderResponseRequired, "false", false},
+		{HeaderResponseRequired, float64(1), true},
+		{HeaderCorrelationId, float64(12), "12"},
+		{HeaderCorrelationId, true, "true"},
+		{"x-undeclared", float64(1.5), float64(1.5)},
+	} {
+		got, err := CoerceHeader(tt.name, tt.value)
+		if err != nil || got != tt.want {
+			t.Errorf("CoerceHeader(%s, %#v) = %#v, %v; want %#v", tt.name, tt.value, got, err, tt.want)
+		}
+	}
+	for _, tt := range []struct {
+		name  string
+		value interface{}
+	}{
+		{HeaderSchemaVersion, float64(2.5)},
+		{HeaderSchemaVersion, "two"},
+		{HeaderSchemaVersion, float64(1e19)},
+		{HeaderSchemaVersion, uint64(math.MaxUint64)},
+		{HeaderResponseRequired, float64(2)},
+		{HeaderResponseRequired, "maybe"},
+		{HeaderCorrelationId, map[string]interface{}{}},
+	} {
+		if got, err := CoerceHeader(tt.name, tt.value); !errors.Is(err, ErrInvalidHeader) {
+			t.Errorf("CoerceHeader(%s, %#v) = %#v, %v; want ErrInvalidHeader", tt.name, tt.value, got, err)
+		}
+	}
+}
+
+func TestHeadersValidateReportsEveryHeader(t *testing.T) {
+	h := NewHeadersWithValues(map[string]interface{}{
+		HeaderSchemaVersion:    "two",
+		HeaderResponseRequired: "maybe",
+		HeaderCorrelationId:    "c1",
+	})
+	err := h.Validate()
+	if !errors.Is(err, ErrInvalidHeader) {
+		t.Fatalf("got %v, want ErrInvalidHeader", err)
+	}
+	for _, name := range []string{HeaderSchemaVersion, HeaderResponseRequired} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("%q does not name %s", err, name)
+		}
+	}
+	if err := h.Normalize(); !errors.Is(err, ErrInvalidHeader) {
+		t.Fatalf("normalize: got %v", err)
+	}
+	if h.Values[HeaderSchemaVersion] != "two" {
+		t.Fatal("a failed Normalize changed the headers")
+	}
+	if v, err := h.GetInt64(HeaderSchemaVersion); !errors.Is(err, ErrInvalidHeader) || h.Version() != 0 {
+		t.Fatalf("GetInt64 = %v, %v", v, err)
+	}
+	if v, err := h.GetInt64("missing"); err != nil || v != 0 {
+		t.Fatalf("missing header: %v, %v", v, err)
+	}
+}
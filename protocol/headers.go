@@ -2,6 +2,8 @@ package protocol
 
 import (
 	"encoding/json"
+	"sort"
+	"strings"
 )
 
 const (
@@ -27,117 +29,219 @@ const (
 	HeaderMessageFeatureId = "flywave-message-feature-id"
 )
 
+// Headers are looked up case-insensitively, since HTTP and AMQP bridges do
+// not preserve the casing. Known headers are stored under their canonical
+// name, other names as first set.
 type Headers struct {
 	Values map[string]interface{}
 }
 
-func (h *Headers) MessageId() string {
-	if _, ok := h.Values[HeaderMessageId]; !ok {
-		return ""
+func NewHeadersWithValues(values map[string]interface{}) *Headers {
+	h := &Headers{Values: make(map[string]interface{}, len(values))}
+	h.setAll(values)
+	return h
+}
+
+// setAll sets values in name order. Of names differing only in case, the
+// canonical spelling of a known header wins, e.g. "ETag" over "etag", and
+// the first name in order otherwise.
+func (h *Headers) setAll(values map[string]interface{}) {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		canonical, known := lookupCanonicalHeader(name)
+		if _, ok := h.Get(name); ok && (!known || canonical != name) {
+			continue
+		}
+		h.Set(name, values[name])
 	}
-	return h.Values[HeaderMessageId].(string)
 }
 
-func (h *Headers) CorrelationId() string {
-	if h.Values[HeaderCorrelationId] == nil {
-		return ""
+// CanonicalHeaderName returns the declared spelling of a known header, e.g.
+// "ETag" for "etag", and name itself otherwise.
+func CanonicalHeaderName(name string) string {
+	if canonical, ok := lookupCanonicalHeader(name); ok {
+		return canonical
 	}
-	return h.Values[HeaderCorrelationId].(string)
+	return name
 }
 
-func (h *Headers) Timeout() string {
-	if h.Values[HeaderTimeout] == nil {
-		return ""
+func (h *Headers) Get(name string) (interface{}, bool) {
+	if h == nil {
+		return nil, false
+	}
+	if value, ok := h.Values[name]; ok {
+		return value, true
+	}
+	for key, value := range h.Values {
+		if strings.EqualFold(key, name) {
+			return value, true
+		}
 	}
-	return h.Values[HeaderTimeout].(string)
+	return nil, false
 }
 
-func (h *Headers) IsResponseRequired() bool {
-	if h.Values[HeaderResponseRequired] == nil {
-		return false
+// Set replaces any value stored under another casing of name, keeping the
+// stored name unless name is a known header. It does nothing on nil
+// Headers.
+func (h *Headers) Set(name string, value interface{}) {
+	if h == nil {
+		return
+	}
+	if h.Values == nil {
+		h.Values = make(map[string]interface{})
+	}
+	key, known := lookupCanonicalHeader(name)
+	if !known {
+		key = name
+	}
+	for stored := range h.Values {
+		if strings.EqualFold(stored, name) {
+			if !known {
+				key = stored
+			}
+			delete(h.Values, stored)
+		}
 	}
-	return h.Values[HeaderResponseRequired].(bool)
+	h.Values[key] = value
 }
 
-func (h *Headers) Channel() string {
-	if h.Values[HeaderChannel] == nil {
-		return ""
+func (h *Headers) Delete(name string) {
+	if h == nil {
+		return
+	}
+	for key := range h.Values {
+		if strings.EqualFold(key, name) {
+			delete(h.Values, key)
+		}
 	}
-	return h.Values[HeaderChannel].(string)
 }
 
-func (h *Headers) IsDryRun() bool {
-	if h.Values[HeaderDryRun] == nil {
-		return false
+func (h *Headers) Len() int {
+	if h == nil {
+		return 0
 	}
-	return h.Values[HeaderDryRun].(bool)
+	return len(h.Values)
 }
 
-func (h *Headers) Origin() string {
-	if h.Values[HeaderOrigin] == nil {
-		return ""
+// Names returns the stored header names in sorted order.
+func (h *Headers) Names() []string {
+	if h == nil {
+		return nil
 	}
-	return h.Values[HeaderOrigin].(string)
+	names := make([]string, 0, len(h.Values))
+	for name := range h.Values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
 
-func (h *Headers) Originator() string {
-	if h.Values[HeaderOriginator] == nil {
-		return ""
+// Range calls f for each header in name order until f returns false.
+func (h *Headers) Range(f func(name string, value interface{}) bool) {
+	for _, name := range h.Names() {
+		if !f(name, h.Values[name]) {
+			return
+		}
 	}
-	return h.Values[HeaderOriginator].(string)
 }
 
-func (h *Headers) ETag() string {
-	if h.Values[HeaderETag] == nil {
-		return ""
+func (h *Headers) Clone() *Headers {
+	if h == nil {
+		return nil
+	}
+	res := &Headers{Values: make(map[string]interface{}, len(h.Values))}
+	for name, value := range h.Values {
+		res.Values[name] = value
 	}
-	return h.Values[HeaderETag].(string)
+	return res
+}
+
+// The accessors below return the zero value for missing headers and for
+// values that cannot be coerced to the header's type; use GetString, GetBool
+// or GetInt64 to see the error.
+
+func (h *Headers) MessageId() string {
+	value, _ := h.GetString(HeaderMessageId)
+	return value
+}
+
+func (h *Headers) CorrelationId() string {
+	value, _ := h.GetString(HeaderCorrelationId)
+	return value
+}
+
+func (h *Headers) Timeout() string {
+	value, _ := h.GetString(HeaderTimeout)
+	return value
+}
+
+func (h *Headers) IsResponseRequired() bool {
+	value, _ := h.GetBool(HeaderResponseRequired)
+	return value
+}
+
+func (h *Headers) Channel() string {
+	value, _ := h.GetString(HeaderChannel)
+	return value
+}
+
+func (h *Headers) IsDryRun() bool {
+	value, _ := h.GetBool(HeaderDryRun)
+	return value
+}
+
+func (h *Headers) Origin() string {
+	value, _ := h.GetString(HeaderOrigin)
+	return value
+}
+
+func (h *Headers) Originator() string {
+	value, _ := h.GetString(HeaderOriginator)
+	return value
+}
+
+func (h *Headers) ETag() string {
+	value, _ := h.GetString(HeaderETag)
+	return value
 }
 
 func (h *Headers) IfMatch() string {
-	if h.Values[HeaderIfMatch] == nil {
-		return ""
-	}
-	return h.Values[HeaderIfMatch].(string)
+	value, _ := h.GetString(HeaderIfMatch)
+	return value
 }
 
 func (h *Headers) IfNoneMatch() string {
-	if h.Values[HeaderIfNoneMatch] == nil {
-		return ""
-	}
-	return h.Values[HeaderIfNoneMatch].(string)
+	value, _ := h.GetString(HeaderIfNoneMatch)
+	return value
 }
 
 func (h *Headers) ReplyTarget() int64 {
-	if h.Values[HeaderReplyTarget] == nil {
-		return 0
-	}
-	return h.Values[HeaderReplyTarget].(int64)
+	value, _ := h.GetInt64(HeaderReplyTarget)
+	return value
 }
 
 func (h *Headers) ReplyTo() string {
-	if h.Values[HeaderReplyTo] == nil {
-		return ""
-	}
-	return h.Values[HeaderReplyTo].(string)
+	value, _ := h.GetString(HeaderReplyTo)
+	return value
 }
 
 func (h *Headers) Version() int64 {
-	if h.Values[HeaderSchemaVersion] == nil {
-		return 0
-	}
-	return h.Values[HeaderSchemaVersion].(int64)
+	value, _ := h.GetInt64(HeaderSchemaVersion)
+	return value
 }
 
 func (h *Headers) ContentType() string {
-	if h.Values[HeaderContentType] == nil {
-		return ""
-	}
-	return h.Values[HeaderContentType].(string)
+	value, _ := h.GetString(HeaderContentType)
+	return value
 }
 
 func (h *Headers) Generic(id string) interface{} {
-	return h.Values[id]
+	value, _ := h.Get(id)
+	return value
 }
 
 func (h *Headers) MarshalJSON() ([]byte, error) {
@@ -149,6 +253,7 @@ func (h *Headers) UnmarshalJSON(data []byte) error {
 	if err := json.Unmarshal(data, &v); err != nil {
 		return err
 	}
-	h.Values = v
+	h.Values = make(map[string]interface{}, len(v))
+	h.setAll(v)
 	return nil
 }
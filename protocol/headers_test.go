@@ -0,0 +1,86 @@
+package protocol
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestNilHeaders(t *testing.T) {
+	var h *Headers
+	h.Set(HeaderETag, "1")
+	h.Delete(HeaderETag)
+	if _, ok := h.Get(HeaderETag); ok || h.Len() != 0 {
+		t.Fatal("nil headers hold a value")
+	}
+}
+
+func TestHeadersCaseInsensitive(t *testing.T) {
+	h := NewHeadersWithValues(map[string]interface{}{"etag": "1", "X-Custom": "a"})
+	for _, name := range []string{"ETag", "etag", "ETAG"} {
+		if value, ok := h.Get(name); !ok || value != "1" {
+			t.Fatalf("Get(%q) = %v, %v", name, value, ok)
+		}
+	}
+	if value, ok := h.Get("x-custom"); !ok || value != "a" {
+		t.Fatalf("Get(x-custom) = %v, %v", value, ok)
+	}
+	h.Set("x-custom", "b")
+	h.Set("IF-MATCH", "*")
+	if got := h.Names(); !reflect.DeepEqual(got, []string{"ETag", "If-Match", "X-Custom"}) {
+		t.Fatalf("names %v", got)
+	}
+	data, err := json.Marshal(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"ETag":"1","If-Match":"*","X-Custom":"b"}` {
+		t.Fatalf("marshaled %s", data)
+	}
+
+	h.Delete("ETAG")
+	h.Delete("missing")
+	if _, ok := h.Get(HeaderETag); ok || h.Len() != 2 {
+		t.Fatalf("after delete %v", h.Values)
+	}
+	var names []string
+	h.Range(func(name string, value interface{}) bool {
+		names = append(names, name)
+		return false
+	})
+	if !reflect.DeepEqual(names, []string{"If-Match"}) {
+		t.Fatalf("range visited %v", names)
+	}
+}
+
+func TestHeadersUnmarshalPrefersCanonicalName(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		var h Headers
+		if err := json.Unmarshal([]byte(`{"etag":"lower","ETag":"canonical","etAG":"mixed","x-a":"1","X-A":"2"}`), &h); err != nil {
+			t.Fatal(err)
+		}
+		if h.Len() != 2 || h.ETag() != "canonical" || h.Values[HeaderETag] != "canonical" {
+			t.Fatalf("etag %v", h.Values)
+		}
+		if h.Values["X-A"] != "2" {
+			t.Fatalf("x-a %v", h.Values)
+		}
+	}
+}
+
+func TestHeadersClone(t *testing.T) {
+	h := NewHeadersWithValues(map[string]interface{}{HeaderCorrelationId: "c1"})
+	clone := h.Clone()
+	clone.Set(HeaderCorrelationId, "c2")
+	clone.Set(HeaderTimeout, "1s")
+	if h.CorrelationId() != "c1" || h.Len() != 1 {
+		t.Fatalf("clone changed the original: %v", h.Values)
+	}
+	if clone.CorrelationId() != "c2" || clone.Len() != 2 {
+		t.Fatalf("clone %v", clone.Values)
+	}
+	var nilHeaders *Headers
+	if nilHeaders.Clone() != nil || nilHeaders.Names() != nil {
+		t.Fatal("nil headers cloned")
+	}
+}
@@ -0,0 +1,228 @@
+package protocol
+
+import (
+	"container/list"
+	"errors"
+	"path"
+	"strings"
+	"sync"
+)
+
+const (
+	WildcardSingleLevel = "+"
+	WildcardMultiLevel  = "#"
+)
+
+var ErrInvalidPattern = errors.New("invalid pattern: # must be the last segment")
+
+// Matcher matches "/"-separated names such as topic and path strings
+// segment by segment. A "+", "_" or "*" segment matches exactly one
+// segment, a trailing "#" matches any number of remaining segments,
+// including none, and "*" inside a segment never crosses a "/".
+type Matcher struct {
+	pattern  string
+	segments []string
+	multi    bool
+}
+
+func CompileMatcher(pattern string) (*Matcher, error) {
+	segments := strings.Split(pattern, "/")
+	m := &Matcher{pattern: pattern}
+	for i, segment := range segments {
+		if segment == WildcardMultiLevel {
+			if i != len(segments)-1 {
+				return nil, ErrInvalidPattern
+			}
+			m.multi = true
+			break
+		}
+		m.segments = append(m.segments, segment)
+	}
+	return m, nil
+}
+
+func MustCompileMatcher(pattern string) *Matcher {
+	m, err := CompileMatcher(pattern)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func (m *Matcher) String() string {
+	return m.pattern
+}
+
+func (m *Matcher) Match(name string) bool {
+	rest, last := name, false
+	for _, pattern := range m.segments {
+		if last {
+			return false
+		}
+		segment := rest
+		if i := strings.IndexByte(rest, '/'); i >= 0 {
+			segment, rest = rest[:i], rest[i+1:]
+		} else {
+			last = true
+		}
+		if !matchSegment(pattern, segment) {
+			return false
+		}
+	}
+	return last || m.multi
+}
+
+func (m *Matcher) MatchTopic(topic *Topic) bool {
+	return topic != nil && m.Match(topic.String())
+}
+
+func (m *Matcher) MatchPath(p *Path) bool {
+	return p != nil && m.Match(p.String())
+}
+
+func hasWildcard(name string, placeholder bool) bool {
+	for _, segment := range strings.Split(name, "/") {
+		switch {
+		case segment == WildcardSingleLevel, segment == WildcardMultiLevel, strings.Contains(segment, pathWillCard):
+			return true
+		case placeholder && segment == TopicPlaceholder:
+			return true
+		}
+	}
+	return false
+}
+
+func matchSegment(pattern, segment string) bool {
+	switch pattern {
+	case WildcardSingleLevel, TopicPlaceholder, pathWillCard:
+		return true
+	}
+	if !strings.Contains(pattern, pathWillCard) {
+		return pattern == segment
+	}
+	ok, _ := path.Match(pattern, segment)
+	return ok
+}
+
+// matcherCacheSize bounds the patterns cachedMatcher keeps, since they may
+// come from user input.
+const matcherCacheSize = 256
+
+type matcherEntry struct {
+	pattern string
+	matcher *Matcher
+}
+
+var matchers = struct {
+	sync.Mutex
+	lru     *list.List
+	entries map[string]*list.Element
+}{lru: list.New(), entries: make(map[string]*list.Element)}
+
+// cachedMatcher compiles pattern once; Topic.Match and Path.Match are
+// usually called with a handful of fixed patterns. The least recently used
+// patterns are evicted beyond matcherCacheSize.
+func cachedMatcher(pattern string) (*Matcher, error) {
+	matchers.Lock()
+	if e, ok := matchers.entries[pattern]; ok {
+		matchers.lru.MoveToFront(e)
+		m := e.Value.(*matcherEntry).matcher
+		matchers.Unlock()
+		return m, nil
+	}
+	matchers.Unlock()
+
+	m, err := CompileMatcher(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	matchers.Lock()
+	defer matchers.Unlock()
+	if _, ok := matchers.entries[pattern]; !ok {
+		matchers.entries[pattern] = matchers.lru.PushFront(&matcherEntry{pattern: pattern, matcher: m})
+		for matchers.lru.Len() > matcherCacheSize {
+			oldest := matchers.lru.Back()
+			matchers.lru.Remove(oldest)
+			delete(matchers.entries, oldest.Value.(*matcherEntry).pattern)
+		}
+	}
+	return m, nil
+}
+
+// TopicToMQTTFilter converts a topic pattern into an MQTT topic filter
+// below prefix: empty, "_" and "*" segments become "+" and a missing action
+// becomes "#".
+func TopicToMQTTFilter(prefix string, topic *Topic) string {
+	segments := []string{
+		mqttFilterSegment(topic.TenantName),
+		mqttFilterSegment(topic.ChannelName),
+		mqttFilterSegment(string(topic.Entity)),
+		mqttFilterSegment(string(topic.Criterion)),
+	}
+	if topic.Action == "" {
+		segments = append(segments, WildcardMultiLevel)
+	} else {
+		segments = append(segments, mqttFilterSegment(string(topic.Action)))
+	}
+	name := strings.Join(segments, "/")
+	if prefix != "" {
+		return prefix + "/" + name
+	}
+	return name
+}
+
+// TopicFromMQTTFilter is the inverse of TopicToMQTTFilter. "+" becomes "_"
+// and "#" leaves the remaining segments, and the action, open; a filter
+// without an action segment matches any action as well.
+func TopicFromMQTTFilter(prefix string, filter string) (*Topic, error) {
+	if prefix != "" {
+		if !strings.HasPrefix(filter, prefix+"/") {
+			return nil, errors.New("invalid topic filter: " + filter)
+		}
+		filter = strings.TrimPrefix(filter, prefix+"/")
+	}
+	segments := strings.Split(filter, "/")
+	if len(segments) > 5 {
+		return nil, errors.New("invalid topic filter: " + filter)
+	}
+	fields := make([]string, 5)
+	for i := range fields[:4] {
+		fields[i] = TopicPlaceholder
+	}
+	for i, segment := range segments {
+		switch segment {
+		case WildcardMultiLevel:
+			if i != len(segments)-1 {
+				return nil, ErrInvalidPattern
+			}
+		case WildcardSingleLevel:
+			if i < 4 {
+				fields[i] = TopicPlaceholder
+			}
+		case "":
+			return nil, errors.New("invalid topic filter: " + filter)
+		default:
+			fields[i] = segment
+		}
+	}
+	switch EntityType(fields[2]) {
+	case EntityThings, EntityStreams, EntityConnections, EntityDevices, TopicPlaceholder:
+	default:
+		return nil, errors.New("invalid topic filter: " + filter)
+	}
+	return &Topic{
+		TenantName:  fields[0],
+		ChannelName: fields[1],
+		Entity:      EntityType(fields[2]),
+		Criterion:   TopicCriterion(fields[3]),
+		Action:      TopicAction(fields[4]),
+	}, nil
+}
+
+func mqttFilterSegment(segment string) string {
+	if segment == "" || segment == TopicPlaceholder || segment == pathWillCard {
+		return WildcardSingleLevel
+	}
+	return segment
+}
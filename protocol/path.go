@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"reflect"
 	"regexp"
 	"strings"
 )
@@ -80,18 +81,6 @@ const (
 	pathSubscribers                         = "@subscribers"
 )
 
-var regexThingsPath = regexp.MustCompile("^@(things)/([^/]+)(/(features)/([^/]+)/(messages)/(incoming|outgoing)/([^/]+)|/(features)(/([^/]+)(/(properties)(/([^/]{1}.*)/(timeseries))?)?)?|/(features)(/([^/]+)(/(properties|desired|attributes)(/([^/]{1}.*))?)?)?|/(attributes)(/([^/]{1}.*))?|/(messages)/(incoming|outgoing)/([^/]+))?$")
-var regexDevicesPath = regexp.MustCompile("^@(devices)/([^/]+)(/(status)|/(strategys)(/([^/]+)(/(indicators|attributes)(/([^/]+)(/(timeseries))?)?)?)?|/(attributes)(/([^/]{1}.*))?|/(profiles)(/(name|product|manufacturer|version|firmware|protocol|transport|tags))?)?$")
-var regexConnectionsPath = regexp.MustCompile("^@(connections)/([^/]+)(/(status))?$")
-var regexStreamsPath = regexp.MustCompile("^@(streams)/([^/]+)(/(status|videos|audios|subscribers))?$")
-var regexFeaturesPath = regexp.MustCompile("^@(features)(/([^/]+)(/(properties|desired|attributes)(/([^/]{1}.*))?)?)?$")
-var regexPropertiesPath = regexp.MustCompile("^@(properties)(/([^/]{1}.*))?$")
-var regexDesiredPath = regexp.MustCompile("^@(desired)(/([^/]{1}.*))?$")
-var regexAttributesPath = regexp.MustCompile("^@(attributes)(/([^/]{1}.*))?$")
-var regexStrategysPath = regexp.MustCompile("^@(strategys)(/([^/]+)(/(indicators|attributes)(/([^/]+))?)?)?$")
-var regexIndicatorsPath = regexp.MustCompile("^@(indicators)(/([^/]+)?)?$")
-var regexProfilesPath = regexp.MustCompile("^@(profiles)(/([^/]+)?)?$")
-
 type PathType string
 
 const (
@@ -136,19 +125,161 @@ const (
 	PathTypeSubscribers                   PathType = "subscribers_path"
 )
 
+// The built-in paths; applications add their own with RegisterPath.
+func init() {
+	for _, def := range []*PathDefinition{
+		{Type: PathTypeRoot, Patterns: []string{""}, Joins: []PathType{PathTypeThing, PathTypeDevice, PathTypeStream, PathTypeConnection},
+			New: func(v PathVars) EntityPath { return &RootPath{} }},
+		{Type: PathTypeThing, Patterns: []string{"things/{thing}"}, Joins: []PathType{PathTypeFeatures, PathTypeFeaturesProperties, PathTypeFeaturesDesiredProperties, PathTypeFeaturesAttributes, PathTypeAttributes},
+			New: func(v PathVars) EntityPath { return &ThingPath{Thing: v["thing"]} }},
+		{Type: PathTypeThingAttributes, Patterns: []string{"things/{thing}/attributes/{attribute*}"},
+			New: func(v PathVars) EntityPath {
+				return &ThingAttributesPath{ThingPath: ThingPath{Thing: v["thing"]}, Attribute: v["attribute"]}
+			}},
+		{Type: PathTypeThingMessages, Patterns: []string{"things/{thing}/messages/{direction:incoming|outgoing}/{subject}"},
+			New: func(v PathVars) EntityPath {
+				return &ThingMessagesPath{ThingPath: ThingPath{Thing: v["thing"]}, Direction: DirectionType(v["direction"]), Subject: v["subject"]}
+			}},
+		{Type: PathTypeThingFeatures, Patterns: []string{"things/{thing}/features/{feature?}"}, Joins: []PathType{PathTypeProperties, PathTypeDesiredProperties, PathTypeAttributes},
+			New: func(v PathVars) EntityPath {
+				return &ThingFeaturesPath{ThingPath: ThingPath{Thing: v["thing"]}, Feature: v["feature"]}
+			}},
+		{Type: PathTypeThingFeatureMessages, Patterns: []string{"things/{thing}/features/{feature}/messages/{direction:incoming|outgoing}/{subject}"},
+			New: func(v PathVars) EntityPath {
+				return &ThingFeatureMessagesPath{ThingFeaturesPath: thingFeaturesPath(v), Direction: DirectionType(v["direction"]), Subject: v["subject"]}
+			}},
+		{Type: PathTypeThingFeatureProperties, Patterns: []string{"things/{thing}/features/{feature}/properties/{propertie*}", "things/{thing}/features/{feature}/properties/{propertie+}/{timeseries:timeseries}"},
+			New: func(v PathVars) EntityPath {
+				_, timeseries := v["timeseries"]
+				return &ThingFeaturePropertiesPath{ThingFeaturesPath: thingFeaturesPath(v), Propertie: v["propertie"], TimeSeries: timeseries}
+			}},
+		{Type: PathTypeThingFeatureDesiredProperties, Patterns: []string{"things/{thing}/features/{feature}/desired/{propertie*}"},
+			New: func(v PathVars) EntityPath {
+				return &ThingFeatureDesiredPath{ThingFeaturesPath: thingFeaturesPath(v), Propertie: v["propertie"]}
+			}},
+		{Type: PathTypeThingFeatureAttributes, Patterns: []string{"things/{thing}/features/{feature}/attributes/{attribute*}"},
+			New: func(v PathVars) EntityPath {
+				return &ThingFeatureAttributesPath{ThingFeaturesPath: thingFeaturesPath(v), Attribute: v["attribute"]}
+			}},
+		{Type: PathTypeDevice, Patterns: []string{"devices/{device}"}, Joins: []PathType{PathTypeStrategys, PathTypeStrategyIndicators, PathTypeStrategyAttributes, PathTypeAttributes, PathTypeProfiles, PathTypeStatus},
+			New: func(v PathVars) EntityPath { return &DevicePath{Device: v["device"]} }},
+		{Type: PathTypeDeviceStatus, Patterns: []string{"devices/{device}/status"},
+			New: func(v PathVars) EntityPath { return &DeviceStatusPath{DevicePath: DevicePath{Device: v["device"]}} }},
+		{Type: PathTypeDeviceAttributes, Patterns: []string{"devices/{device}/attributes/{attribute*}"},
+			New: func(v PathVars) EntityPath {
+				return &DeviceAttributesPath{DevicePath: DevicePath{Device: v["device"]}, Attribute: v["attribute"]}
+			}},
+		{Type: PathTypeDeviceStrategys, Patterns: []string{"devices/{device}/strategys/{strategy?}"}, Joins: []PathType{PathTypeIndicators, PathTypeAttributes},
+			New: func(v PathVars) EntityPath {
+				return &DeviceStrategysPath{DevicePath: DevicePath{Device: v["device"]}, Strategy: v["strategy"]}
+			}},
+		{Type: PathTypeDeviceStrategyIndicators, Patterns: []string{"devices/{device}/strategys/{strategy}/indicators/{indicator?}", "devices/{device}/strategys/{strategy}/indicators/{indicator}/{timeseries:timeseries}"},
+			New: func(v PathVars) EntityPath {
+				_, timeseries := v["timeseries"]
+				return &DeviceStrategyIndicatorsPath{DeviceStrategysPath: deviceStrategysPath(v), Indicator: v["indicator"], TimeSeries: timeseries}
+			}},
+		{Type: PathTypeDeviceStrategyAttributes, Patterns: []string{"devices/{device}/strategys/{strategy}/attributes/{attribute?}"},
+			New: func(v PathVars) EntityPath {
+				return &DeviceStrategyAttributesPath{DeviceStrategysPath: deviceStrategysPath(v), Attribute: v["attribute"]}
+			}},
+		{Type: PathTypeDeviceProfiles, Patterns: []string{"devices/{device}/profiles/{profile?:name|product|manufacturer|version|firmware|protocol|transport|tags}"},
+			New: func(v PathVars) EntityPath {
+				return &DeviceProfilesPath{DevicePath: DevicePath{Device: v["device"]}, Profile: v["profile"]}
+			}},
+		{Type: PathTypeConnection, Patterns: []string{"connections/{connection}"}, Joins: []PathType{PathTypeStatus},
+			New: func(v PathVars) EntityPath { return &ConnectionPath{Connection: v["connection"]} }},
+		{Type: PathTypeConnectionStatus, Patterns: []string{"connections/{connection}/status"},
+			New: func(v PathVars) EntityPath {
+				return &ConnectionStatusPath{ConnectionPath: ConnectionPath{Connection: v["connection"]}}
+			}},
+		{Type: PathTypeStream, Patterns: []string{"streams/{stream}"}, Joins: []PathType{PathTypeStatus, PathTypeVideos, PathTypeAudios, PathTypeSubscribers},
+			New: func(v PathVars) EntityPath { return &StreamPath{Stream: v["stream"]} }},
+		{Type: PathTypeStreamStatus, Patterns: []string{"streams/{stream}/status"},
+			New: func(v PathVars) EntityPath { return &StreamStatusPath{StreamPath: StreamPath{Stream: v["stream"]}} }},
+		{Type: PathTypeStreamVideos, Patterns: []string{"streams/{stream}/videos"},
+			New: func(v PathVars) EntityPath { return &StreamVideosPath{StreamPath: StreamPath{Stream: v["stream"]}} }},
+		{Type: PathTypeStreamAudios, Patterns: []string{"streams/{stream}/audios"},
+			New: func(v PathVars) EntityPath { return &StreamAudiosPath{StreamPath: StreamPath{Stream: v["stream"]}} }},
+		{Type: PathTypeStream_SUBSCRIBERS, Patterns: []string{"streams/{stream}/subscribers"},
+			New: func(v PathVars) EntityPath {
+				return &StreamSubscribersPath{StreamPath: StreamPath{Stream: v["stream"]}}
+			}},
+		{Type: PathTypeFeatures, Patterns: []string{"features/{feature?}"}, Joins: []PathType{PathTypeAttributes, PathTypeProperties, PathTypeDesiredProperties},
+			New: func(v PathVars) EntityPath { return &FeaturesPath{Feature: v["feature"]} }},
+		{Type: PathTypeFeaturesProperties, Patterns: []string{"features/{feature}/properties/{propertie*}"},
+			New: func(v PathVars) EntityPath {
+				return &FeaturePropertiesPath{FeaturesPath: FeaturesPath{Feature: v["feature"]}, Propertie: v["propertie"]}
+			}},
+		{Type: PathTypeFeaturesDesiredProperties, Patterns: []string{"features/{feature}/desired/{propertie*}"},
+			New: func(v PathVars) EntityPath {
+				return &FeatureDesiredPath{FeaturesPath: FeaturesPath{Feature: v["feature"]}, Propertie: v["propertie"]}
+			}},
+		{Type: PathTypeFeaturesAttributes, Patterns: []string{"features/{feature}/attributes/{attribute*}"},
+			New: func(v PathVars) EntityPath {
+				return &FeatureAttributesPath{FeaturesPath: FeaturesPath{Feature: v["feature"]}, Attribute: v["attribute"]}
+			}},
+		{Type: PathTypeProperties, Patterns: []string{"properties/{propertie*}"},
+			New: func(v PathVars) EntityPath { return &PropertiesPath{Propertie: v["propertie"]} }},
+		{Type: PathTypeDesiredProperties, Patterns: []string{"desired/{propertie*}"},
+			New: func(v PathVars) EntityPath { return &DesiredPath{Propertie: v["propertie"]} }},
+		{Type: PathTypeAttributes, Patterns: []string{"attributes/{attribute*}"},
+			New: func(v PathVars) EntityPath { return &AttributesPath{Attribute: v["attribute"]} }},
+		{Type: PathTypeStrategys, Patterns: []string{"strategys/{strategy?}"}, Joins: []PathType{PathTypeIndicators, PathTypeAttributes},
+			New: func(v PathVars) EntityPath { return &StrategysPath{Strategy: v["strategy"]} }},
+		{Type: PathTypeStrategyIndicators, Patterns: []string{"strategys/{strategy}/indicators/{indicator?}"},
+			New: func(v PathVars) EntityPath {
+				return &StrategyIndicatorsPath{StrategysPath: StrategysPath{Strategy: v["strategy"]}, Indicator: v["indicator"]}
+			}},
+		{Type: PathTypeStrategyAttributes, Patterns: []string{"strategys/{strategy}/attributes/{attribute?}"},
+			New: func(v PathVars) EntityPath {
+				return &StrategyAttributesPath{StrategysPath: StrategysPath{Strategy: v["strategy"]}, Attribute: v["attribute"]}
+			}},
+		{Type: PathTypeIndicators, Patterns: []string{"indicators/{indicator?}"},
+			New: func(v PathVars) EntityPath { return &IndicatorsPath{Indicator: v["indicator"]} }},
+		{Type: PathTypeProfiles, Patterns: []string{"profiles/{profile?}"},
+			New: func(v PathVars) EntityPath { return &ProfilesPath{Profile: v["profile"]} }},
+		{Type: PathTypeStatus, Patterns: []string{"status"},
+			New: func(v PathVars) EntityPath { return &StatusPath{} }},
+		{Type: PathTypeVideos, Patterns: []string{"videos"},
+			New: func(v PathVars) EntityPath { return &VideosPath{} }},
+		{Type: PathTypeAudios, Patterns: []string{"audios"},
+			New: func(v PathVars) EntityPath { return &AudiosPath{} }},
+		{Type: PathTypeSubscribers, Patterns: []string{"subscribers"},
+			New: func(v PathVars) EntityPath { return &SubscribersPath{} }},
+	} {
+		MustRegisterPath(def)
+	}
+}
+
+func thingFeaturesPath(v PathVars) ThingFeaturesPath {
+	return ThingFeaturesPath{ThingPath: ThingPath{Thing: v["thing"]}, Feature: v["feature"]}
+}
+
+func deviceStrategysPath(v PathVars) DeviceStrategysPath {
+	return DeviceStrategysPath{DevicePath: DevicePath{Device: v["device"]}, Strategy: v["strategy"]}
+}
+
 type Path struct {
 	Entity EntityPath
+
+	// err records a name a With* builder could not represent, see Validate.
+	err error
 }
 
 func NewRootPath() *Path {
 	return &Path{Entity: &RootPath{}}
 }
 
+// NewPath parses a path string such as "@things/t1/features/f1". It fails
+// with ErrInvalidPathName when no registered pattern matches str.
 func NewPath(str string) (*Path, error) {
 	e, err := parseEntityPath(str)
 	if err != nil {
 		return nil, err
 	}
+	if e == nil {
+		return nil, fmt.Errorf("%w: %q is not a path", ErrInvalidPathName, str)
+	}
 	return &Path{Entity: e}, nil
 }
 
@@ -168,7 +299,11 @@ func (p *Path) String() string {
 }
 
 func (p *Path) Clone() *Path {
-	po, _ := NewPath(p.String())
+	po, err := NewPath(p.String())
+	if err != nil {
+		po = &Path{}
+	}
+	po.err = p.err
 	return po
 }
 
@@ -180,17 +315,28 @@ func (p *Path) IsJoinOf(target *Path) bool {
 	return isJoinOf(p.Type(), target.Type())
 }
 
+// Join appends target below p. It fails with ErrInvalidPathJoin unless the
+// definition of p lists the type of target in its Joins and the combined
+// path matches a registered pattern.
 func (p *Path) Join(target *Path) (*Path, error) {
+	if p.Empty() || target.Empty() {
+		return nil, fmt.Errorf("%w: empty path", ErrInvalidPathJoin)
+	}
+	if !p.IsJoinOf(target) {
+		return nil, fmt.Errorf("%w: %s onto %s", ErrInvalidPathJoin, target.Type(), p.Type())
+	}
 	src := p.String()
 	targetPath := target.String()
 
-	if strings.HasPrefix(targetPath, "@") {
-		targetPath = strings.ReplaceAll(targetPath, "@", "")
-	}
+	targetPath = strings.TrimPrefix(targetPath, pathRoot)
 
 	newPath := strings.Join([]string{src, targetPath}, "/")
 
-	return NewPath(newPath)
+	res, err := NewPath(newPath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPathJoin, err)
+	}
+	return res, nil
 }
 
 func (p *Path) UnmarshalJSON(data []byte) error {
@@ -198,11 +344,15 @@ func (p *Path) UnmarshalJSON(data []byte) error {
 	if err := json.Unmarshal(data, &v); err != nil {
 		return err
 	}
-	e, err := parseEntityPath(v)
+	if v == "" {
+		*p = Path{}
+		return nil
+	}
+	res, err := NewPath(v)
 	if err != nil {
 		return err
 	}
-	p.Entity = e
+	*p = *res
 	return nil
 }
 
@@ -240,14 +390,25 @@ func (p *Path) EntityType() EntityType {
 	return p.Entity.EntityType()
 }
 
-func (p *Path) WithThings() *Path {
-	p.Entity = &ThingPath{}
+// with sets the entity built by a With* builder and records an error when
+// one of the names it was given is empty.
+func (p *Path) with(e EntityPath, names ...string) *Path {
+	p.Entity, p.err = e, nil
+	for _, name := range names {
+		if name == "" {
+			p.err = fmt.Errorf("%w: empty name for %s %q", ErrInvalidPathName, e.Type(), e.String())
+			break
+		}
+	}
 	return p
 }
 
+func (p *Path) WithThings() *Path {
+	return p.with(&ThingPath{})
+}
+
 func (p *Path) WithThing(thing string) *Path {
-	p.Entity = &ThingPath{Thing: thing}
-	return p
+	return p.with(&ThingPath{Thing: EscapePathName(thing)}, thing)
 }
 
 func (p *Path) GetThing() *ThingPath {
@@ -258,13 +419,11 @@ func (p *Path) GetThing() *ThingPath {
 }
 
 func (p *Path) WithThingAttributes(thing string) *Path {
-	p.Entity = &ThingAttributesPath{ThingPath: ThingPath{Thing: thing}}
-	return p
+	return p.with(&ThingAttributesPath{ThingPath: ThingPath{Thing: EscapePathName(thing)}}, thing)
 }
 
 func (p *Path) WithThingAttribute(thing, attribute string) *Path {
-	p.Entity = &ThingAttributesPath{ThingPath: ThingPath{Thing: thing}, Attribute: attribute}
-	return p
+	return p.with(&ThingAttributesPath{ThingPath: ThingPath{Thing: EscapePathName(thing)}, Attribute: escapePathNames(attribute)}, thing, attribute)
 }
 
 func (p *Path) GetThingAttributes() *ThingAttributesPath {
@@ -275,8 +434,7 @@ func (p *Path) GetThingAttributes() *ThingAttributesPath {
 }
 
 func (p *Path) WithThingMessages(thing string, direction DirectionType, subject string) *Path {
-	p.Entity = &ThingMessagesPath{ThingPath: ThingPath{Thing: thing}, Direction: direction, Subject: subject}
-	return p
+	return p.with(&ThingMessagesPath{ThingPath: ThingPath{Thing: EscapePathName(thing)}, Direction: direction, Subject: EscapePathName(subject)}, thing, subject)
 }
 
 func (p *Path) GetThingMessages() *ThingMessagesPath {
@@ -287,13 +445,11 @@ func (p *Path) GetThingMessages() *ThingMessagesPath {
 }
 
 func (p *Path) WithThingFeatures(thing string) *Path {
-	p.Entity = &ThingFeaturesPath{ThingPath: ThingPath{Thing: thing}}
-	return p
+	return p.with(&ThingFeaturesPath{ThingPath: ThingPath{Thing: EscapePathName(thing)}}, thing)
 }
 
 func (p *Path) WithThingFeature(thing, feature string) *Path {
-	p.Entity = &ThingFeaturesPath{ThingPath: ThingPath{Thing: thing}, Feature: feature}
-	return p
+	return p.with(&ThingFeaturesPath{ThingPath: ThingPath{Thing: EscapePathName(thing)}, Feature: EscapePathName(feature)}, thing, feature)
 }
 
 func (p *Path) GetThingFeatures() *ThingFeaturesPath {
@@ -304,8 +460,7 @@ func (p *Path) GetThingFeatures() *ThingFeaturesPath {
 }
 
 func (p *Path) WithThingFeatureMessages(thing, feature string, direction DirectionType, subject string) *Path {
-	p.Entity = &ThingFeatureMessagesPath{ThingFeaturesPath: ThingFeaturesPath{ThingPath: ThingPath{Thing: thing}, Feature: feature}, Direction: direction, Subject: subject}
-	return p
+	return p.with(&ThingFeatureMessagesPath{ThingFeaturesPath: ThingFeaturesPath{ThingPath: ThingPath{Thing: EscapePathName(thing)}, Feature: EscapePathName(feature)}, Direction: direction, Subject: EscapePathName(subject)}, thing, feature, subject)
 }
 
 func (p *Path) GetThingFeatureMessages() *ThingFeatureMessagesPath {
@@ -316,18 +471,15 @@ func (p *Path) GetThingFeatureMessages() *ThingFeatureMessagesPath {
 }
 
 func (p *Path) WithThingFeatureProperties(thing, feature string) *Path {
-	p.Entity = &ThingFeaturePropertiesPath{ThingFeaturesPath: ThingFeaturesPath{ThingPath: ThingPath{Thing: thing}, Feature: feature}, TimeSeries: false}
-	return p
+	return p.with(&ThingFeaturePropertiesPath{ThingFeaturesPath: ThingFeaturesPath{ThingPath: ThingPath{Thing: EscapePathName(thing)}, Feature: EscapePathName(feature)}, TimeSeries: false}, thing, feature)
 }
 
 func (p *Path) WithThingFeaturePropertie(thing, feature, propertie string) *Path {
-	p.Entity = &ThingFeaturePropertiesPath{ThingFeaturesPath: ThingFeaturesPath{ThingPath: ThingPath{Thing: thing}, Feature: feature}, Propertie: propertie, TimeSeries: false}
-	return p
+	return p.with(&ThingFeaturePropertiesPath{ThingFeaturesPath: ThingFeaturesPath{ThingPath: ThingPath{Thing: EscapePathName(thing)}, Feature: EscapePathName(feature)}, Propertie: escapePathNames(propertie), TimeSeries: false}, thing, feature, propertie)
 }
 
 func (p *Path) WithThingFeaturePropertiesTimeSeries(thing, feature, propertie string) *Path {
-	p.Entity = &ThingFeaturePropertiesPath{ThingFeaturesPath: ThingFeaturesPath{ThingPath: ThingPath{Thing: thing}, Feature: feature}, Propertie: propertie, TimeSeries: true}
-	return p
+	return p.with(&ThingFeaturePropertiesPath{ThingFeaturesPath: ThingFeaturesPath{ThingPath: ThingPath{Thing: EscapePathName(thing)}, Feature: EscapePathName(feature)}, Propertie: escapePathNames(propertie), TimeSeries: true}, thing, feature, propertie)
 }
 
 func (p *Path) GetThingFeatureProperties() *ThingFeaturePropertiesPath {
@@ -338,13 +490,11 @@ func (p *Path) GetThingFeatureProperties() *ThingFeaturePropertiesPath {
 }
 
 func (p *Path) WithThingFeatureDesireds(thing, feature string) *Path {
-	p.Entity = &ThingFeatureDesiredPath{ThingFeaturesPath: ThingFeaturesPath{ThingPath: ThingPath{Thing: thing}, Feature: feature}}
-	return p
+	return p.with(&ThingFeatureDesiredPath{ThingFeaturesPath: ThingFeaturesPath{ThingPath: ThingPath{Thing: EscapePathName(thing)}, Feature: EscapePathName(feature)}}, thing, feature)
 }
 
 func (p *Path) WithThingFeatureDesired(thing, feature, propertie string) *Path {
-	p.Entity = &ThingFeatureDesiredPath{ThingFeaturesPath: ThingFeaturesPath{ThingPath: ThingPath{Thing: thing}, Feature: feature}, Propertie: propertie}
-	return p
+	return p.with(&ThingFeatureDesiredPath{ThingFeaturesPath: ThingFeaturesPath{ThingPath: ThingPath{Thing: EscapePathName(thing)}, Feature: EscapePathName(feature)}, Propertie: escapePathNames(propertie)}, thing, feature, propertie)
 }
 
 func (p *Path) GetThingFeatureDesired() *ThingFeatureDesiredPath {
@@ -355,13 +505,11 @@ func (p *Path) GetThingFeatureDesired() *ThingFeatureDesiredPath {
 }
 
 func (p *Path) WithThingFeatureAttributes(thing, feature string) *Path {
-	p.Entity = &ThingFeatureAttributesPath{ThingFeaturesPath: ThingFeaturesPath{ThingPath: ThingPath{Thing: thing}, Feature: feature}}
-	return p
+	return p.with(&ThingFeatureAttributesPath{ThingFeaturesPath: ThingFeaturesPath{ThingPath: ThingPath{Thing: EscapePathName(thing)}, Feature: EscapePathName(feature)}}, thing, feature)
 }
 
 func (p *Path) WithThingFeatureAttribute(thing, feature, attribute string) *Path {
-	p.Entity = &ThingFeatureAttributesPath{ThingFeaturesPath: ThingFeaturesPath{ThingPath: ThingPath{Thing: thing}, Feature: feature}, Attribute: attribute}
-	return p
+	return p.with(&ThingFeatureAttributesPath{ThingFeaturesPath: ThingFeaturesPath{ThingPath: ThingPath{Thing: EscapePathName(thing)}, Feature: EscapePathName(feature)}, Attribute: escapePathNames(attribute)}, thing, feature, attribute)
 }
 
 func (p *Path) GetThingFeatureAttributes() *ThingFeatureAttributesPath {
@@ -372,13 +520,11 @@ func (p *Path) GetThingFeatureAttributes() *ThingFeatureAttributesPath {
 }
 
 func (p *Path) WithDevices() *Path {
-	p.Entity = &DevicePath{}
-	return p
+	return p.with(&DevicePath{})
 }
 
 func (p *Path) WithDevice(device string) *Path {
-	p.Entity = &DevicePath{Device: device}
-	return p
+	return p.with(&DevicePath{Device: EscapePathName(device)}, device)
 }
 
 func (p *Path) GetDevice() *DevicePath {
@@ -389,8 +535,7 @@ func (p *Path) GetDevice() *DevicePath {
 }
 
 func (p *Path) WithDeviceStatus(device string) *Path {
-	p.Entity = &DeviceStatusPath{DevicePath: DevicePath{Device: device}}
-	return p
+	return p.with(&DeviceStatusPath{DevicePath: DevicePath{Device: EscapePathName(device)}}, device)
 }
 
 func (p *Path) GetDeviceStatus() *DeviceStatusPath {
@@ -401,13 +546,11 @@ func (p *Path) GetDeviceStatus() *DeviceStatusPath {
 }
 
 func (p *Path) WithDeviceAttributes(device string) *Path {
-	p.Entity = &DeviceAttributesPath{DevicePath: DevicePath{Device: device}}
-	return p
+	return p.with(&DeviceAttributesPath{DevicePath: DevicePath{Device: EscapePathName(device)}}, device)
 }
 
 func (p *Path) WithDeviceAttribute(device, attribute string) *Path {
-	p.Entity = &DeviceAttributesPath{DevicePath: DevicePath{Device: device}, Attribute: attribute}
-	return p
+	return p.with(&DeviceAttributesPath{DevicePath: DevicePath{Device: EscapePathName(device)}, Attribute: escapePathNames(attribute)}, device, attribute)
 }
 
 func (p *Path) GetDeviceAttributes() *DeviceAttributesPath {
@@ -418,13 +561,11 @@ func (p *Path) GetDeviceAttributes() *DeviceAttributesPath {
 }
 
 func (p *Path) WithDeviceStrategys(device string) *Path {
-	p.Entity = &DeviceStrategysPath{DevicePath: DevicePath{Device: device}}
-	return p
+	return p.with(&DeviceStrategysPath{DevicePath: DevicePath{Device: EscapePathName(device)}}, device)
 }
 
 func (p *Path) WithDeviceStrategy(device, strategy string) *Path {
-	p.Entity = &DeviceStrategysPath{DevicePath: DevicePath{Device: device}, Strategy: strategy}
-	return p
+	return p.with(&DeviceStrategysPath{DevicePath: DevicePath{Device: EscapePathName(device)}, Strategy: EscapePathName(strategy)}, device, strategy)
 }
 
 func (p *Path) GetDeviceStrategys() *DeviceStrategysPath {
@@ -435,18 +576,15 @@ func (p *Path) GetDeviceStrategys() *DeviceStrategysPath {
 }
 
 func (p *Path) WithDeviceStrategyIndicators(device, strategy string) *Path {
-	p.Entity = &DeviceStrategyIndicatorsPath{DeviceStrategysPath: DeviceStrategysPath{DevicePath: DevicePath{Device: device}, Strategy: strategy}, TimeSeries: false}
-	return p
+	return p.with(&DeviceStrategyIndicatorsPath{DeviceStrategysPath: DeviceStrategysPath{DevicePath: DevicePath{Device: EscapePathName(device)}, Strategy: EscapePathName(strategy)}, TimeSeries: false}, device, strategy)
 }
 
 func (p *Path) WithDeviceStrategyIndicator(device, strategy, indicator string) *Path {
-	p.Entity = &DeviceStrategyIndicatorsPath{DeviceStrategysPath: DeviceStrategysPath{DevicePath: DevicePath{Device: device}, Strategy: strategy}, Indicator: indicator, TimeSeries: false}
-	return p
+	return p.with(&DeviceStrategyIndicatorsPath{DeviceStrategysPath: DeviceStrategysPath{DevicePath: DevicePath{Device: EscapePathName(device)}, Strategy: EscapePathName(strategy)}, Indicator: EscapePathName(indicator), TimeSeries: false}, device, strategy, indicator)
 }
 
 func (p *Path) WithDeviceStrategyIndicatorTimeSeries(device, strategy, indicator string) *Path {
-	p.Entity = &DeviceStrategyIndicatorsPath{DeviceStrategysPath: DeviceStrategysPath{DevicePath: DevicePath{Device: device}, Strategy: strategy}, Indicator: indicator, TimeSeries: true}
-	return p
+	return p.with(&DeviceStrategyIndicatorsPath{DeviceStrategysPath: DeviceStrategysPath{DevicePath: DevicePath{Device: EscapePathName(device)}, Strategy: EscapePathName(strategy)}, Indicator: EscapePathName(indicator), TimeSeries: true}, device, strategy, indicator)
 }
 
 func (p *Path) GetDeviceStrategyIndicators() *DeviceStrategyIndicatorsPath {
@@ -457,13 +595,11 @@ func (p *Path) GetDeviceStrategyIndicators() *DeviceStrategyIndicatorsPath {
 }
 
 func (p *Path) WithDeviceStrategyAttributes(device, strategy string) *Path {
-	p.Entity = &DeviceStrategyAttributesPath{DeviceStrategysPath: DeviceStrategysPath{DevicePath: DevicePath{Device: device}, Strategy: strategy}}
-	return p
+	return p.with(&DeviceStrategyAttributesPath{DeviceStrategysPath: DeviceStrategysPath{DevicePath: DevicePath{Device: EscapePathName(device)}, Strategy: EscapePathName(strategy)}}, device, strategy)
 }
 
 func (p *Path) WithDeviceStrategyAttribute(device, strategy, attribute string) *Path {
-	p.Entity = &DeviceStrategyAttributesPath{DeviceStrategysPath: DeviceStrategysPath{DevicePath: DevicePath{Device: device}, Strategy: strategy}, Attribute: attribute}
-	return p
+	return p.with(&DeviceStrategyAttributesPath{DeviceStrategysPath: DeviceStrategysPath{DevicePath: DevicePath{Device: EscapePathName(device)}, Strategy: EscapePathName(strategy)}, Attribute: EscapePathName(attribute)}, device, strategy, attribute)
 }
 
 func (p *Path) GetDeviceStrategyAttributes() *DeviceStrategyAttributesPath {
@@ -474,13 +610,11 @@ func (p *Path) GetDeviceStrategyAttributes() *DeviceStrategyAttributesPath {
 }
 
 func (p *Path) WithDeviceProfiles(device string) *Path {
-	p.Entity = &DeviceProfilesPath{DevicePath: DevicePath{Device: device}}
-	return p
+	return p.with(&DeviceProfilesPath{DevicePath: DevicePath{Device: EscapePathName(device)}}, device)
 }
 
 func (p *Path) WithDeviceProfile(device, profile string) *Path {
-	p.Entity = &DeviceProfilesPath{DevicePath: DevicePath{Device: device}, Profile: profile}
-	return p
+	return p.with(&DeviceProfilesPath{DevicePath: DevicePath{Device: EscapePathName(device)}, Profile: EscapePathName(profile)}, device, profile)
 }
 
 func (p *Path) GetDeviceProfiles() *DeviceProfilesPath {
@@ -491,13 +625,11 @@ func (p *Path) GetDeviceProfiles() *DeviceProfilesPath {
 }
 
 func (p *Path) WithConnections() *Path {
-	p.Entity = &ConnectionPath{}
-	return p
+	return p.with(&ConnectionPath{})
 }
 
 func (p *Path) WithConnection(connection string) *Path {
-	p.Entity = &ConnectionPath{Connection: connection}
-	return p
+	return p.with(&ConnectionPath{Connection: EscapePathName(connection)}, connection)
 }
 
 func (p *Path) GetConnection() *ConnectionPath {
@@ -508,8 +640,7 @@ func (p *Path) GetConnection() *ConnectionPath {
 }
 
 func (p *Path) WithConnectionStatus(connection string) *Path {
-	p.Entity = &ConnectionStatusPath{ConnectionPath: ConnectionPath{Connection: connection}}
-	return p
+	return p.with(&ConnectionStatusPath{ConnectionPath: ConnectionPath{Connection: EscapePathName(connection)}}, connection)
 }
 
 func (p *Path) GetConnectionStatus() *ConnectionStatusPath {
@@ -520,13 +651,11 @@ func (p *Path) GetConnectionStatus() *ConnectionStatusPath {
 }
 
 func (p *Path) WithStreams() *Path {
-	p.Entity = &StreamPath{}
-	return p
+	return p.with(&StreamPath{})
 }
 
 func (p *Path) WithStream(stream string) *Path {
-	p.Entity = &StreamPath{Stream: stream}
-	return p
+	return p.with(&StreamPath{Stream: EscapePathName(stream)}, stream)
 }
 
 func (p *Path) GetStream() *StreamPath {
@@ -537,8 +666,7 @@ func (p *Path) GetStream() *StreamPath {
 }
 
 func (p *Path) WithStreamStatus(stream string) *Path {
-	p.Entity = &StreamStatusPath{StreamPath: StreamPath{Stream: stream}}
-	return p
+	return p.with(&StreamStatusPath{StreamPath: StreamPath{Stream: EscapePathName(stream)}}, stream)
 }
 
 func (p *Path) GetStreamStatus() *StreamStatusPath {
@@ -549,8 +677,7 @@ func (p *Path) GetStreamStatus() *StreamStatusPath {
 }
 
 func (p *Path) WithStreamVideos(stream string) *Path {
-	p.Entity = &StreamVideosPath{StreamPath: StreamPath{Stream: stream}}
-	return p
+	return p.with(&StreamVideosPath{StreamPath: StreamPath{Stream: EscapePathName(stream)}}, stream)
 }
 
 func (p *Path) GetStreamVideos() *StreamVideosPath {
@@ -561,8 +688,7 @@ func (p *Path) GetStreamVideos() *StreamVideosPath {
 }
 
 func (p *Path) WithStreamAudios(stream string) *Path {
-	p.Entity = &StreamAudiosPath{StreamPath: StreamPath{Stream: stream}}
-	return p
+	return p.with(&StreamAudiosPath{StreamPath: StreamPath{Stream: EscapePathName(stream)}}, stream)
 }
 
 func (p *Path) GetStreamAudios() *StreamAudiosPath {
@@ -573,8 +699,7 @@ func (p *Path) GetStreamAudios() *StreamAudiosPath {
 }
 
 func (p *Path) WithStreamSubscribers(stream string) *Path {
-	p.Entity = &StreamSubscribersPath{StreamPath: StreamPath{Stream: stream}}
-	return p
+	return p.with(&StreamSubscribersPath{StreamPath: StreamPath{Stream: EscapePathName(stream)}}, stream)
 }
 
 func (p *Path) GetStreamSubscribers() *StreamSubscribersPath {
@@ -585,13 +710,11 @@ func (p *Path) GetStreamSubscribers() *StreamSubscribersPath {
 }
 
 func (p *Path) WithFeatures() *Path {
-	p.Entity = &FeaturesPath{}
-	return p
+	return p.with(&FeaturesPath{})
 }
 
 func (p *Path) WithFeature(feature string) *Path {
-	p.Entity = &FeaturesPath{Feature: feature}
-	return p
+	return p.with(&FeaturesPath{Feature: EscapePathName(feature)}, feature)
 }
 
 func (p *Path) GetFeatures() *FeaturesPath {
@@ -602,13 +725,11 @@ func (p *Path) GetFeatures() *FeaturesPath {
 }
 
 func (p *Path) WithFeatureProperties(feature string) *Path {
-	p.Entity = &FeaturePropertiesPath{FeaturesPath: FeaturesPath{Feature: feature}}
-	return p
+	return p.with(&FeaturePropertiesPath{FeaturesPath: FeaturesPath{Feature: EscapePathName(feature)}}, feature)
 }
 
 func (p *Path) WithFeaturePropertie(feature, propertie string) *Path {
-	p.Entity = &FeaturePropertiesPath{FeaturesPath: FeaturesPath{Feature: feature}, Propertie: propertie}
-	return p
+	return p.with(&FeaturePropertiesPath{FeaturesPath: FeaturesPath{Feature: EscapePathName(feature)}, Propertie: escapePathNames(propertie)}, feature, propertie)
 }
 
 func (p *Path) GetFeatureProperties() *FeaturePropertiesPath {
@@ -619,13 +740,11 @@ func (p *Path) GetFeatureProperties() *FeaturePropertiesPath {
 }
 
 func (p *Path) WithFeatureDesireds(feature string) *Path {
-	p.Entity = &FeatureDesiredPath{FeaturesPath: FeaturesPath{Feature: feature}}
-	return p
+	return p.with(&FeatureDesiredPath{FeaturesPath: FeaturesPath{Feature: EscapePathName(feature)}}, feature)
 }
 
 func (p *Path) WithFeatureDesired(feature, propertie string) *Path {
-	p.Entity = &FeatureDesiredPath{FeaturesPath: FeaturesPath{Feature: feature}, Propertie: propertie}
-	return p
+	return p.with(&FeatureDesiredPath{FeaturesPath: FeaturesPath{Feature: EscapePathName(feature)}, Propertie: escapePathNames(propertie)}, feature, propertie)
 }
 
 func (p *Path) GetFeatureDesired() *FeatureDesiredPath {
@@ -636,13 +755,11 @@ func (p *Path) GetFeatureDesired() *FeatureDesiredPath {
 }
 
 func (p *Path) WithFeatureAttributes(feature string) *Path {
-	p.Entity = &FeatureAttributesPath{FeaturesPath: FeaturesPath{Feature: feature}}
-	return p
+	return p.with(&FeatureAttributesPath{FeaturesPath: FeaturesPath{Feature: EscapePathName(feature)}}, feature)
 }
 
 func (p *Path) WithFeatureAttribute(feature, attribute string) *Path {
-	p.Entity = &FeatureAttributesPath{FeaturesPath: FeaturesPath{Feature: feature}, Attribute: attribute}
-	return p
+	return p.with(&FeatureAttributesPath{FeaturesPath: FeaturesPath{Feature: EscapePathName(feature)}, Attribute: escapePathNames(attribute)}, feature, attribute)
 }
 
 func (p *Path) GetFeatureAttributes() *FeatureAttributesPath {
@@ -653,13 +770,11 @@ func (p *Path) GetFeatureAttributes() *FeatureAttributesPath {
 }
 
 func (p *Path) WithProperties() *Path {
-	p.Entity = &PropertiesPath{}
-	return p
+	return p.with(&PropertiesPath{})
 }
 
 func (p *Path) WithPropertie(propertie string) *Path {
-	p.Entity = &PropertiesPath{Propertie: propertie}
-	return p
+	return p.with(&PropertiesPath{Propertie: escapePathNames(propertie)}, propertie)
 }
 
 func (p *Path) GetProperties() *PropertiesPath {
@@ -670,13 +785,11 @@ func (p *Path) GetProperties() *PropertiesPath {
 }
 
 func (p *Path) WithDesireds() *Path {
-	p.Entity = &DesiredPath{}
-	return p
+	return p.with(&DesiredPath{})
 }
 
 func (p *Path) WithDesired(propertie string) *Path {
-	p.Entity = &DesiredPath{Propertie: propertie}
-	return p
+	return p.with(&DesiredPath{Propertie: escapePathNames(propertie)}, propertie)
 }
 
 func (p *Path) GetDesired() *DesiredPath {
@@ -687,13 +800,11 @@ func (p *Path) GetDesired() *DesiredPath {
 }
 
 func (p *Path) WithAttributes() *Path {
-	p.Entity = &AttributesPath{}
-	return p
+	return p.with(&AttributesPath{})
 }
 
 func (p *Path) WithAttribute(attribute string) *Path {
-	p.Entity = &AttributesPath{Attribute: attribute}
-	return p
+	return p.with(&AttributesPath{Attribute: escapePathNames(attribute)}, attribute)
 }
 
 func (p *Path) GetAttribute() *AttributesPath {
@@ -704,13 +815,11 @@ func (p *Path) GetAttribute() *AttributesPath {
 }
 
 func (p *Path) WithStrategys() *Path {
-	p.Entity = &StrategysPath{}
-	return p
+	return p.with(&StrategysPath{})
 }
 
 func (p *Path) WithStrategy(strategy string) *Path {
-	p.Entity = &StrategysPath{Strategy: strategy}
-	return p
+	return p.with(&StrategysPath{Strategy: EscapePathName(strategy)}, strategy)
 }
 
 func (p *Path) GetStrategys() *StrategysPath {
@@ -721,13 +830,11 @@ func (p *Path) GetStrategys() *StrategysPath {
 }
 
 func (p *Path) WithStrategyIndicators(strategy string) *Path {
-	p.Entity = &StrategyIndicatorsPath{StrategysPath: StrategysPath{Strategy: strategy}}
-	return p
+	return p.with(&StrategyIndicatorsPath{StrategysPath: StrategysPath{Strategy: EscapePathName(strategy)}}, strategy)
 }
 
 func (p *Path) WithStrategyIndicator(strategy, indicator string) *Path {
-	p.Entity = &StrategyIndicatorsPath{StrategysPath: StrategysPath{Strategy: strategy}, Indicator: indicator}
-	return p
+	return p.with(&StrategyIndicatorsPath{StrategysPath: StrategysPath{Strategy: EscapePathName(strategy)}, Indicator: EscapePathName(indicator)}, strategy, indicator)
 }
 
 func (p *Path) GetStrategyIndicators() *StrategyIndicatorsPath {
@@ -738,13 +845,11 @@ func (p *Path) GetStrategyIndicators() *StrategyIndicatorsPath {
 }
 
 func (p *Path) WithStrategyAttributes(strategy string) *Path {
-	p.Entity = &StrategyAttributesPath{StrategysPath: StrategysPath{Strategy: strategy}}
-	return p
+	return p.with(&StrategyAttributesPath{StrategysPath: StrategysPath{Strategy: EscapePathName(strategy)}}, strategy)
 }
 
 func (p *Path) WithStrategyAttribute(strategy, attribute string) *Path {
-	p.Entity = &StrategyAttributesPath{StrategysPath: StrategysPath{Strategy: strategy}, Attribute: attribute}
-	return p
+	return p.with(&StrategyAttributesPath{StrategysPath: StrategysPath{Strategy: EscapePathName(strategy)}, Attribute: EscapePathName(attribute)}, strategy, attribute)
 }
 
 func (p *Path) GetStrategyAttributes() *StrategyAttributesPath {
@@ -755,8 +860,7 @@ func (p *Path) GetStrategyAttributes() *StrategyAttributesPath {
 }
 
 func (p *Path) WithIndicators(strategy, indicator string) *Path {
-	p.Entity = &IndicatorsPath{Indicator: indicator}
-	return p
+	return p.with(&IndicatorsPath{Indicator: EscapePathName(indicator)}, indicator)
 }
 
 func (p *Path) GetIndicators() *IndicatorsPath {
@@ -767,13 +871,11 @@ func (p *Path) GetIndicators() *IndicatorsPath {
 }
 
 func (p *Path) WithProfiles() *Path {
-	p.Entity = &ProfilesPath{}
-	return p
+	return p.with(&ProfilesPath{})
 }
 
 func (p *Path) WithProfile(profile string) *Path {
-	p.Entity = &ProfilesPath{Profile: profile}
-	return p
+	return p.with(&ProfilesPath{Profile: EscapePathName(profile)}, profile)
 }
 
 func (p *Path) GetProfile() *ProfilesPath {
@@ -784,8 +886,7 @@ func (p *Path) GetProfile() *ProfilesPath {
 }
 
 func (p *Path) WithStatus(profile string) *Path {
-	p.Entity = &StatusPath{}
-	return p
+	return p.with(&StatusPath{})
 }
 
 func (p *Path) GetStatus() *StatusPath {
@@ -796,8 +897,7 @@ func (p *Path) GetStatus() *StatusPath {
 }
 
 func (p *Path) WithVideos() *Path {
-	p.Entity = &VideosPath{}
-	return p
+	return p.with(&VideosPath{})
 }
 
 func (p *Path) GetVideos() *VideosPath {
@@ -808,8 +908,7 @@ func (p *Path) GetVideos() *VideosPath {
 }
 
 func (p *Path) WithAudios() *Path {
-	p.Entity = &AudiosPath{}
-	return p
+	return p.with(&AudiosPath{})
 }
 
 func (p *Path) GetAudios() *AudiosPath {
@@ -820,8 +919,7 @@ func (p *Path) GetAudios() *AudiosPath {
 }
 
 func (p *Path) WithSubscribers() *Path {
-	p.Entity = &SubscribersPath{}
-	return p
+	return p.with(&SubscribersPath{})
 }
 
 func (p *Path) GetSubscribers() *SubscribersPath {
@@ -845,218 +943,41 @@ func GetPathEntityType(str string) EntityType {
 }
 
 func ValidPath(str string) bool {
-	if strings.HasPrefix(str, "@things") {
-		return regexThingsPath.Match([]byte(str))
-	} else if strings.HasPrefix(str, "@devices") {
-		return regexDevicesPath.Match([]byte(str))
-	} else if strings.HasPrefix(str, "@connections") {
-		return regexConnectionsPath.Match([]byte(str))
-	} else if strings.HasPrefix(str, "@streams") {
-		return regexStreamsPath.Match([]byte(str))
-	} else if strings.HasPrefix(str, "@features") {
-		return regexFeaturesPath.Match([]byte(str))
-	} else if strings.HasPrefix(str, "@properties") {
-		return regexPropertiesPath.Match([]byte(str))
-	} else if strings.HasPrefix(str, "@desired") {
-		return regexDesiredPath.Match([]byte(str))
-	} else if strings.HasPrefix(str, "@attributes") {
-		return regexAttributesPath.Match([]byte(str))
-	} else if strings.HasPrefix(str, "@strategys") {
-		return regexStrategysPath.Match([]byte(str))
-	} else if strings.HasPrefix(str, "@indicators") {
-		return regexIndicatorsPath.Match([]byte(str))
-	} else if strings.HasPrefix(str, "@profiles") {
-		return regexProfilesPath.Match([]byte(str))
-	} else if str == "@" || str == "@status" || str == "@videos" || str == "@audios" || str == "@subscribers" {
-		return true
-	}
-	return false
-}
-
-func parseEntityPath(str string) (EntityPath, error) {
-	if strings.HasPrefix(str, "@things") && regexThingsPath.Match([]byte(str)) {
-		matches := regexThingsPath.FindAllStringSubmatch(str, -1)
-		if matches != nil {
-			if matches[0][1] == "things" {
-				if matches[0][24] == "attributes" {
-					return &ThingAttributesPath{ThingPath: ThingPath{Thing: matches[0][2]}, Attribute: matches[0][26]}, nil
-				}
-				if matches[0][4] == "features" {
-					if matches[0][6] == "messages" {
-						return &ThingFeatureMessagesPath{ThingFeaturesPath: ThingFeaturesPath{ThingPath: ThingPath{Thing: matches[0][2]}, Feature: matches[0][5]}, Direction: DirectionType(matches[0][7]), Subject: matches[0][8]}, nil
-					}
-					return &ThingFeaturesPath{ThingPath: ThingPath{Thing: matches[0][2]}, Feature: matches[0][5]}, nil
-				}
-				if matches[0][9] == "features" {
-					if matches[0][13] == "properties" {
-						timeseries := false
-						if matches[0][16] == "timeseries" {
-							timeseries = true
-						}
-						return &ThingFeaturePropertiesPath{ThingFeaturesPath: ThingFeaturesPath{ThingPath: ThingPath{Thing: matches[0][2]}, Feature: matches[0][11]}, Propertie: matches[0][15], TimeSeries: timeseries}, nil
-					}
-					return &ThingFeaturesPath{ThingPath: ThingPath{Thing: matches[0][2]}, Feature: matches[0][11]}, nil
-				}
-				if matches[0][17] == "features" {
-					if matches[0][21] == "desired" {
-						return &ThingFeatureDesiredPath{ThingFeaturesPath: ThingFeaturesPath{ThingPath: ThingPath{Thing: matches[0][2]}, Feature: matches[0][19]}, Propertie: matches[0][23]}, nil
-					} else if matches[0][21] == "properties" {
-						return &ThingFeaturePropertiesPath{ThingFeaturesPath: ThingFeaturesPath{ThingPath: ThingPath{Thing: matches[0][2]}, Feature: matches[0][19]}, Propertie: matches[0][23], TimeSeries: false}, nil
-					} else if matches[0][21] == "attributes" {
-						return &ThingFeatureAttributesPath{ThingFeaturesPath: ThingFeaturesPath{ThingPath: ThingPath{Thing: matches[0][2]}, Feature: matches[0][19]}, Attribute: matches[0][23]}, nil
-					}
-					return &ThingFeaturesPath{ThingPath: ThingPath{Thing: matches[0][2]}, Feature: matches[0][19]}, nil
-				}
-				if matches[0][27] == "messages" {
-					return &ThingMessagesPath{ThingPath: ThingPath{Thing: matches[0][2]}, Direction: DirectionType(matches[0][28]), Subject: matches[0][29]}, nil
-				}
-				return &ThingPath{Thing: matches[0][2]}, nil
-			}
-		}
-		return nil, errors.New("error parse things")
-	} else if strings.HasPrefix(str, "@devices") && regexDevicesPath.Match([]byte(str)) {
-		matches := regexDevicesPath.FindAllStringSubmatch(str, -1)
-		if matches != nil {
-			if matches[0][1] == "devices" {
-				if matches[0][4] == "status" {
-					return &DeviceStatusPath{DevicePath: DevicePath{Device: matches[0][2]}}, nil
-				}
-				if matches[0][14] == "attributes" {
-					return &DeviceAttributesPath{DevicePath: DevicePath{Device: matches[0][2]}, Attribute: matches[0][16]}, nil
-				}
-				if matches[0][5] == "strategys" {
-					if matches[0][9] == "indicators" {
-						timeseries := false
-						if matches[0][13] == "timeseries" {
-							timeseries = true
-						}
-						return &DeviceStrategyIndicatorsPath{DeviceStrategysPath: DeviceStrategysPath{DevicePath: DevicePath{Device: matches[0][2]}, Strategy: matches[0][7]}, Indicator: matches[0][11], TimeSeries: timeseries}, nil
-					} else if matches[0][9] == "attributes" {
-						return &DeviceStrategyAttributesPath{DeviceStrategysPath: DeviceStrategysPath{DevicePath: DevicePath{Device: matches[0][2]}, Strategy: matches[0][7]}, Attribute: matches[0][11]}, nil
-					}
-					return &DeviceStrategysPath{DevicePath: DevicePath{Device: matches[0][2]}, Strategy: matches[0][7]}, nil
-				}
-				if matches[0][17] == "profiles" {
-					return &DeviceProfilesPath{DevicePath: DevicePath{Device: matches[0][2]}, Profile: matches[0][19]}, nil
-				}
-				return &DevicePath{Device: matches[0][2]}, nil
-			}
-		}
-		return nil, errors.New("error parse devices")
-	} else if strings.HasPrefix(str, "@connections") && regexConnectionsPath.Match([]byte(str)) {
-		matches := regexConnectionsPath.FindAllStringSubmatch(str, -1)
-		if matches != nil {
-			if matches[0][1] == "connections" {
-				if matches[0][4] == "status" {
-					return &ConnectionStatusPath{ConnectionPath: ConnectionPath{Connection: matches[0][2]}}, nil
-				}
-				return &ConnectionPath{Connection: matches[0][2]}, nil
-			}
-		}
-		return nil, errors.New("error parse connections")
-	} else if strings.HasPrefix(str, "@attributes") && regexAttributesPath.Match([]byte(str)) {
-		matches := regexAttributesPath.FindAllStringSubmatch(str, -1)
-		if matches != nil {
-			if matches[0][1] == "attributes" {
-				return &AttributesPath{Attribute: matches[0][3]}, nil
-			}
-		}
-		return nil, errors.New("error parse attributes")
-	} else if strings.HasPrefix(str, "@features") && regexFeaturesPath.Match([]byte(str)) {
-		matches := regexFeaturesPath.FindAllStringSubmatch(str, -1)
-		if matches != nil {
-			if matches[0][1] == "features" {
-				if matches[0][5] == "properties" {
-					return &FeaturePropertiesPath{FeaturesPath: FeaturesPath{Feature: matches[0][3]}, Propertie: matches[0][7]}, nil
-				} else if matches[0][5] == "desired" {
-					return &FeatureDesiredPath{FeaturesPath: FeaturesPath{Feature: matches[0][3]}, Propertie: matches[0][7]}, nil
-				} else if matches[0][5] == "attributes" {
-					return &FeatureAttributesPath{FeaturesPath: FeaturesPath{Feature: matches[0][3]}, Attribute: matches[0][7]}, nil
-				} else {
-					return &FeaturesPath{Feature: matches[0][3]}, nil
-				}
-			}
-		}
-		return nil, errors.New("error parse features")
-	} else if strings.HasPrefix(str, "@properties") && regexPropertiesPath.Match([]byte(str)) {
-		matches := regexPropertiesPath.FindAllStringSubmatch(str, -1)
-		if matches != nil {
-			if matches[0][1] == "properties" {
-				return &PropertiesPath{Propertie: matches[0][3]}, nil
-			}
-		}
-		return nil, errors.New("error parse attributes")
-	} else if strings.HasPrefix(str, "@desired") && regexDesiredPath.Match([]byte(str)) {
-		matches := regexDesiredPath.FindAllStringSubmatch(str, -1)
-		if matches != nil {
-			if matches[0][1] == "desired" {
-				return &DesiredPath{Propertie: matches[0][3]}, nil
-			}
-		}
-		return nil, errors.New("error parse attributes")
-	} else if strings.HasPrefix(str, "@streams") && regexStreamsPath.Match([]byte(str)) {
-		matches := regexStreamsPath.FindAllStringSubmatch(str, -1)
-		if matches != nil {
-			if matches[0][1] == "streams" {
-				if matches[0][4] == "status" {
-					return &StreamStatusPath{StreamPath: StreamPath{Stream: matches[0][2]}}, nil
-				} else if matches[0][4] == "videos" {
-					return &StreamVideosPath{StreamPath: StreamPath{Stream: matches[0][2]}}, nil
-				} else if matches[0][4] == "audios" {
-					return &StreamAudiosPath{StreamPath: StreamPath{Stream: matches[0][2]}}, nil
-				} else if matches[0][4] == "subscribers" {
-					return &StreamSubscribersPath{StreamPath: StreamPath{Stream: matches[0][2]}}, nil
-				}
-				return &StreamPath{Stream: matches[0][2]}, nil
-			}
-		}
-		return nil, errors.New("error parse streams")
-	} else if strings.HasPrefix(str, "@strategys") && regexStrategysPath.Match([]byte(str)) {
-		matches := regexStrategysPath.FindAllStringSubmatch(str, -1)
-		if matches != nil {
-			if matches[0][1] == "strategys" {
-				if matches[0][5] == "indicators" {
-					return &StrategyIndicatorsPath{StrategysPath: StrategysPath{Strategy: matches[0][3]}, Indicator: matches[0][7]}, nil
-				} else if matches[0][5] == "attributes" {
-					return &StrategyAttributesPath{StrategysPath: StrategysPath{Strategy: matches[0][3]}, Attribute: matches[0][7]}, nil
-				} else {
-					return &StrategysPath{Strategy: matches[0][3]}, nil
-				}
-			}
-		}
-		return nil, errors.New("error parse strategys")
-	} else if strings.HasPrefix(str, "@indicators") && regexIndicatorsPath.Match([]byte(str)) {
-		matches := regexIndicatorsPath.FindAllStringSubmatch(str, -1)
-		if matches != nil {
-			if matches[0][1] == "indicators" {
-				return &IndicatorsPath{Indicator: matches[0][3]}, nil
-			}
-		}
-		return nil, errors.New("error parse profiles")
-	} else if strings.HasPrefix(str, "@profiles") && regexProfilesPath.Match([]byte(str)) {
-		matches := regexProfilesPath.FindAllStringSubmatch(str, -1)
-		if matches != nil {
-			if matches[0][1] == "profiles" {
-				return &ProfilesPath{Profile: matches[0][3]}, nil
-			}
-		}
-		return nil, errors.New("error parse profiles")
-	} else if str == "@status" {
-		return &StatusPath{}, nil
-	} else if str == "@videos" {
-		return &VideosPath{}, nil
-	} else if str == "@audios" {
-		return &AudiosPath{}, nil
-	} else if str == "@subscribers" {
-		return &SubscribersPath{}, nil
-	} else if str == "@" {
-		return &RootPath{}, nil
+	e, _ := parseEntityPath(str)
+	return e != nil
+}
+
+// Validate reports names the grammar cannot represent, e.g. an empty thing
+// name or a property path ending in "timeseries": the string form of such a
+// path reads back as a different path, or as none. An empty name given to a
+// With* builder is reported too, as WithThingFeature("t1", "") would
+// otherwise address the features collection.
+func (p *Path) Validate() error {
+	if p.err != nil {
+		return p.err
 	}
-	return nil, nil
+	if p.Entity == nil {
+		return nil
+	}
+	str := p.String()
+	e, err := parseEntityPath(str)
+	if err != nil {
+		return err
+	}
+	if e == nil {
+		return fmt.Errorf("%w: %s %q is not a path", ErrInvalidPathName, p.Type(), str)
+	}
+	if e.Type() != p.Type() {
+		return fmt.Errorf("%w: %s %q reads back as %s", ErrInvalidPathName, p.Type(), str, e.Type())
+	}
+	if !reflect.DeepEqual(e, p.Entity) {
+		return fmt.Errorf("%w: %s %q reads back with other names", ErrInvalidPathName, p.Type(), str)
+	}
+	return nil
 }
 
 func (p *Path) HasWildCard() bool {
-	return strings.Contains(p.String(), "*")
+	return hasWildcard(p.String(), false)
 }
 
 func WildCardToRegexp(pattern string) string {
@@ -1072,9 +993,11 @@ func WildCardToRegexp(pattern string) string {
 	return result.String()
 }
 
+// Match reports whether the path matches pattern segment by segment, see
+// Matcher.
 func (p *Path) Match(pattern string) bool {
-	result, _ := regexp.MatchString(WildCardToRegexp(pattern), p.String())
-	return result
+	m, err := cachedMatcher(pattern)
+	return err == nil && m.MatchPath(p)
 }
 
 func (p *Path) HasPlaceHolders() bool {
@@ -1109,7 +1032,7 @@ func (t *RootPath) Name() string {
 }
 
 func (t *RootPath) IsParent(target *Path) bool {
-	return false
+	return isParentOf(t, target)
 }
 
 type ThingPath struct {
@@ -1146,7 +1069,7 @@ func (t *ThingPath) String() string {
 }
 
 func (t *ThingPath) IsParent(target *Path) bool {
-	return false
+	return isParentOf(t, target)
 }
 
 type ThingAttributesPath struct {
@@ -1176,7 +1099,7 @@ func (t *ThingAttributesPath) Name() string {
 }
 
 func (t *ThingAttributesPath) IsParent(target *Path) bool {
-	return false
+	return isParentOf(t, target)
 }
 
 func (t *ThingAttributesPath) GetAttribute() *AttributesPath {
@@ -1207,7 +1130,7 @@ func (t *ThingMessagesPath) Name() string {
 }
 
 func (t *ThingMessagesPath) IsParent(target *Path) bool {
-	return false
+	return isParentOf(t, target)
 }
 
 type ThingFeaturesPath struct {
@@ -1237,7 +1160,7 @@ func (t *ThingFeaturesPath) Name() string {
 }
 
 func (t *ThingFeaturesPath) IsParent(target *Path) bool {
-	return false
+	return isParentOf(t, target)
 }
 
 func (t *ThingFeaturesPath) GetFeatures() *FeaturesPath {
@@ -1266,7 +1189,7 @@ func (t *ThingFeatureMessagesPath) Name() string {
 }
 
 func (t *ThingFeatureMessagesPath) IsParent(target *Path) bool {
-	return false
+	return isParentOf(t, target)
 }
 
 type ThingFeaturePropertiesPath struct {
@@ -1303,7 +1226,7 @@ func (t *ThingFeaturePropertiesPath) Name() string {
 }
 
 func (t *ThingFeaturePropertiesPath) IsParent(target *Path) bool {
-	return false
+	return isParentOf(t, target)
 }
 
 func (t *ThingFeaturePropertiesPath) GetProperties() *PropertiesPath {
@@ -1344,7 +1267,7 @@ func (t *ThingFeatureDesiredPath) Name() string {
 }
 
 func (t *ThingFeatureDesiredPath) IsParent(target *Path) bool {
-	return false
+	return isParentOf(t, target)
 }
 
 func (t *ThingFeatureDesiredPath) GetDesired() *DesiredPath {
@@ -1385,7 +1308,7 @@ func (t *ThingFeatureAttributesPath) Name() string {
 }
 
 func (t *ThingFeatureAttributesPath) IsParent(target *Path) bool {
-	return false
+	return isParentOf(t, target)
 }
 
 func (t *ThingFeatureAttributesPath) GetAttribute() *AttributesPath {
@@ -1423,7 +1346,7 @@ func (t *DevicePath) Name() string {
 }
 
 func (t *DevicePath) IsParent(target *Path) bool {
-	return false
+	return isParentOf(t, target)
 }
 
 type DeviceStatusPath struct {
@@ -1446,7 +1369,7 @@ func (t *DeviceStatusPath) Name() string {
 }
 
 func (t *DeviceStatusPath) IsParent(target *Path) bool {
-	return false
+	return isParentOf(t, target)
 }
 
 func (t *DeviceStatusPath) GetStatus() *StatusPath {
@@ -1480,7 +1403,7 @@ func (t *DeviceAttributesPath) Name() string {
 }
 
 func (t *DeviceAttributesPath) IsParent(target *Path) bool {
-	return false
+	return isParentOf(t, target)
 }
 
 func (t *DeviceAttributesPath) GetAttribute() *AttributesPath {
@@ -1514,7 +1437,7 @@ func (t *DeviceStrategysPath) Name() string {
 }
 
 func (t *DeviceStrategysPath) IsParent(target *Path) bool {
-	return false
+	return isParentOf(t, target)
 }
 
 func (t *DeviceStrategysPath) GetStrategys() *StrategysPath {
@@ -1555,7 +1478,7 @@ func (t *DeviceStrategyIndicatorsPath) Name() string {
 }
 
 func (t *DeviceStrategyIndicatorsPath) IsParent(target *Path) bool {
-	return false
+	return isParentOf(t, target)
 }
 
 func (t *DeviceStrategyIndicatorsPath) GetIndicators() *IndicatorsPath {
@@ -1596,7 +1519,7 @@ func (t *DeviceStrategyAttributesPath) Name() string {
 }
 
 func (t *DeviceStrategyAttributesPath) IsParent(target *Path) bool {
-	return false
+	return isParentOf(t, target)
 }
 
 func (t *DeviceStrategyAttributesPath) GetStrategyAttributes() *StrategyAttributesPath {
@@ -1630,7 +1553,7 @@ func (t *DeviceProfilesPath) Name() string {
 }
 
 func (t *DeviceProfilesPath) IsParent(target *Path) bool {
-	return false
+	return isParentOf(t, target)
 }
 
 func (t *DeviceProfilesPath) GetProfile() *ProfilesPath {
@@ -1664,7 +1587,7 @@ func (t *ConnectionPath) Name() string {
 }
 
 func (t *ConnectionPath) IsParent(target *Path) bool {
-	return false
+	return isParentOf(t, target)
 }
 
 type ConnectionStatusPath struct {
@@ -1687,7 +1610,7 @@ func (t *ConnectionStatusPath) Name() string {
 }
 
 func (t *ConnectionStatusPath) IsParent(target *Path) bool {
-	return false
+	return isParentOf(t, target)
 }
 
 type StreamPath struct {
@@ -1717,7 +1640,7 @@ func (t *StreamPath) Name() string {
 }
 
 func (t *StreamPath) IsParent(target *Path) bool {
-	return false
+	return isParentOf(t, target)
 }
 
 type StreamStatusPath struct {
@@ -1740,7 +1663,7 @@ func (t *StreamStatusPath) Name() string {
 }
 
 func (t *StreamStatusPath) IsParent(target *Path) bool {
-	return false
+	return isParentOf(t, target)
 }
 
 type StreamVideosPath struct {
@@ -1763,7 +1686,7 @@ func (t *StreamVideosPath) Name() string {
 }
 
 func (t *StreamVideosPath) IsParent(target *Path) bool {
-	return false
+	return isParentOf(t, target)
 }
 
 type StreamAudiosPath struct {
@@ -1786,7 +1709,7 @@ func (t *StreamAudiosPath) Name() string {
 }
 
 func (t *StreamAudiosPath) IsParent(target *Path) bool {
-	return false
+	return isParentOf(t, target)
 }
 
 type StreamSubscribersPath struct {
@@ -1809,7 +1732,7 @@ func (t *StreamSubscribersPath) Name() string {
 }
 
 func (t *StreamSubscribersPath) IsParent(target *Path) bool {
-	return false
+	return isParentOf(t, target)
 }
 
 type FeaturesPath struct {
@@ -1839,7 +1762,7 @@ func (t *FeaturesPath) Name() string {
 }
 
 func (t *FeaturesPath) IsParent(target *Path) bool {
-	return false
+	return isParentOf(t, target)
 }
 
 type FeaturePropertiesPath struct {
@@ -1873,7 +1796,7 @@ func (t *FeaturePropertiesPath) Name() string {
 }
 
 func (t *FeaturePropertiesPath) IsParent(target *Path) bool {
-	return false
+	return isParentOf(t, target)
 }
 
 func (t *FeaturePropertiesPath) GetProperties() *PropertiesPath {
@@ -1911,7 +1834,7 @@ func (t *FeatureDesiredPath) Name() string {
 }
 
 func (t *FeatureDesiredPath) IsParent(target *Path) bool {
-	return false
+	return isParentOf(t, target)
 }
 
 func (t *FeatureDesiredPath) GetDesired() *DesiredPath {
@@ -1949,7 +1872,7 @@ func (t *FeatureAttributesPath) Name() string {
 }
 
 func (t *FeatureAttributesPath) IsParent(target *Path) bool {
-	return false
+	return isParentOf(t, target)
 }
 
 func (t *FeatureAttributesPath) GetAttribute() *AttributesPath {
@@ -1983,7 +1906,7 @@ func (t *PropertiesPath) Name() string {
 }
 
 func (t *PropertiesPath) IsParent(target *Path) bool {
-	return false
+	return isParentOf(t, target)
 }
 
 type DesiredPath struct {
@@ -2013,7 +1936,7 @@ func (t *DesiredPath) Name() string {
 }
 
 func (t *DesiredPath) IsParent(target *Path) bool {
-	return false
+	return isParentOf(t, target)
 }
 
 type AttributesPath struct {
@@ -2043,7 +1966,7 @@ func (t *AttributesPath) Name() string {
 }
 
 func (t *AttributesPath) IsParent(target *Path) bool {
-	return false
+	return isParentOf(t, target)
 }
 
 type StrategysPath struct {
@@ -2073,7 +1996,7 @@ func (t *StrategysPath) Name() string {
 }
 
 func (t *StrategysPath) IsParent(target *Path) bool {
-	return false
+	return isParentOf(t, target)
 }
 
 type StrategyIndicatorsPath struct {
@@ -2107,7 +2030,7 @@ func (t *StrategyIndicatorsPath) Name() string {
 }
 
 func (t *StrategyIndicatorsPath) IsParent(target *Path) bool {
-	return false
+	return isParentOf(t, target)
 }
 
 func (t *StrategyIndicatorsPath) GetIndicators() *IndicatorsPath {
@@ -2145,7 +2068,7 @@ func (t *StrategyAttributesPath) Name() string {
 }
 
 func (t *StrategyAttributesPath) IsParent(target *Path) bool {
-	return false
+	return isParentOf(t, target)
 }
 
 func (t *StrategyAttributesPath) GetAttribute() *AttributesPath {
@@ -2179,7 +2102,7 @@ func (t *IndicatorsPath) Name() string {
 }
 
 func (t *IndicatorsPath) IsParent(target *Path) bool {
-	return false
+	return isParentOf(t, target)
 }
 
 type ProfilesPath struct {
@@ -2209,7 +2132,7 @@ func (t *ProfilesPath) Name() string {
 }
 
 func (t *ProfilesPath) IsParent(target *Path) bool {
-	return false
+	return isParentOf(t, target)
 }
 
 type StatusPath struct {
@@ -2232,7 +2155,7 @@ func (t *StatusPath) Name() string {
 }
 
 func (t *StatusPath) IsParent(target *Path) bool {
-	return false
+	return isParentOf(t, target)
 }
 
 type VideosPath struct {
@@ -2255,7 +2178,7 @@ func (t *VideosPath) Name() string {
 }
 
 func (t *VideosPath) IsParent(target *Path) bool {
-	return false
+	return isParentOf(t, target)
 }
 
 type AudiosPath struct {
@@ -2278,7 +2201,7 @@ func (t *AudiosPath) Name() string {
 }
 
 func (t *AudiosPath) IsParent(target *Path) bool {
-	return false
+	return isParentOf(t, target)
 }
 
 type SubscribersPath struct {
@@ -2301,7 +2224,7 @@ func (t *SubscribersPath) Name() string {
 }
 
 func (t *SubscribersPath) IsParent(target *Path) bool {
-	return false
+	return isParentOf(t, target)
 }
 
 type PathList []Path
@@ -2332,65 +2255,127 @@ func (e *PathList) Scan(value interface{}) error {
 	return errors.New("received value is neither a byte slice nor string")
 }
 
-func isJoinOf(p, s PathType) bool {
-	switch p {
-	case PathTypeRoot:
-		switch s {
-		case PathTypeThing, PathTypeDevice, PathTypeStream, PathTypeConnection:
-			return true
-		}
-	case PathTypeThing:
-		switch s {
-		case PathTypeFeatures, PathTypeFeaturesProperties, PathTypeFeaturesDesiredProperties, PathTypeFeaturesAttributes, PathTypeAttributes:
-			return true
-		}
-	case PathTypeThingFeatures:
-		switch s {
-		case PathTypeProperties, PathTypeDesiredProperties, PathTypeAttributes:
-			return true
-		}
-	case PathTypeDevice:
-		switch s {
-		case PathTypeStrategys, PathTypeStrategyIndicators, PathTypeStrategyAttributes, PathTypeAttributes, PathTypeProfiles, PathTypeStatus:
-			return true
-		}
-	case PathTypeDeviceStrategys:
-		switch s {
-		case PathTypeIndicators, PathTypeAttributes:
-			return true
-		}
-	case PathTypeConnection:
-		switch s {
-		case PathTypeStatus:
-			return true
-		}
-	case PathTypeStream:
-		switch s {
-		case PathTypeStatus, PathTypeVideos, PathTypeAudios, PathTypeSubscribers:
-			return true
+func (p *Path) IsParent(target *Path) bool {
+	if p.Entity == nil || target.Entity == nil {
+		return false
+	}
+
+	return p.Entity.IsParent(target)
+}
+
+// RelativeOf is the inverse of Join: it returns the path r for which
+// target.Join(r) equals p, e.g. "@features/f1" for "@things/t1/features/f1"
+// relative of "@things/t1".
+func (p *Path) RelativeOf(target *Path) (*Path, error) {
+	if target.Empty() || target.Type() == PathTypeRoot {
+		return p.Clone(), nil
+	}
+	segments, base := p.Segments(), target.Segments()
+	if len(segments) <= len(base) || !hasSegmentPrefix(segments, base) {
+		return nil, fmt.Errorf("path %s is not below %s", p, target)
+	}
+	rel := pathRoot + strings.Join(segments[len(base):], "/")
+	r, err := NewPath(rel)
+	if err != nil {
+		return nil, fmt.Errorf("path %s relative of %s: %w", p, target, err)
+	}
+	return r, nil
+}
+
+// Segments returns the segments of the path without the leading "@"; the
+// root path has none.
+func (p *Path) Segments() []string {
+	s := strings.TrimPrefix(p.String(), pathRoot)
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "/")
+}
+
+// Parent returns the nearest enclosing path, skipping prefixes that are not
+// paths themselves such as "@things/t1/messages/incoming". The parent of a
+// top-level path is the root path; the root has none.
+func (p *Path) Parent() *Path {
+	if p.Empty() {
+		return nil
+	}
+	segments := p.Segments()
+	for n := len(segments) - 1; n > 0; n-- {
+		if parent, err := NewPath(pathRoot + strings.Join(segments[:n], "/")); err == nil {
+			return parent
 		}
-	case PathTypeFeatures:
-		switch s {
-		case PathTypeAttributes, PathTypeProperties, PathTypeDesiredProperties:
-			return true
+	}
+	if len(segments) > 0 {
+		return NewRootPath()
+	}
+	return nil
+}
+
+// Ancestors returns the parents of the path, nearest first, ending with
+// the root path.
+func (p *Path) Ancestors() []*Path {
+	var ancestors []*Path
+	for parent := p.Parent(); parent != nil; parent = parent.Parent() {
+		ancestors = append(ancestors, parent)
+	}
+	return ancestors
+}
+
+// IsAncestor reports whether target lies strictly below p, so that a change
+// at target affects a subscriber watching p.
+func (p *Path) IsAncestor(target *Path) bool {
+	if p.Empty() || target.Empty() {
+		return false
+	}
+	base, segments := p.Segments(), target.Segments()
+	return len(segments) > len(base) && hasSegmentPrefix(segments, base)
+}
+
+// Overlaps reports whether p and target are equal or one lies below the
+// other, i.e. whether a change at either affects the other.
+func (p *Path) Overlaps(target *Path) bool {
+	if p.Empty() || target.Empty() {
+		return false
+	}
+	return p.String() == target.String() || p.IsAncestor(target) || target.IsAncestor(p)
+}
+
+// CommonPrefix returns the deepest path that is equal to or an ancestor of
+// every given path, or nil when none are given.
+func CommonPrefix(paths ...*Path) *Path {
+	if len(paths) == 0 {
+		return nil
+	}
+	common := paths[0].Segments()
+	for _, p := range paths[1:] {
+		segments := p.Segments()
+		n := 0
+		for n < len(common) && n < len(segments) && common[n] == segments[n] {
+			n++
 		}
-	case PathTypeStrategys:
-		switch s {
-		case PathTypeIndicators, PathTypeAttributes:
-			return true
+		common = common[:n]
+	}
+	for n := len(common); n > 0; n-- {
+		if prefix, err := NewPath(pathRoot + strings.Join(common[:n], "/")); err == nil {
+			return prefix
 		}
 	}
-	return false
+	return NewRootPath()
 }
 
-func (p *Path) IsParent(target *Path) bool {
-	if p.Entity == nil || target.Entity == nil {
+func isParentOf(e EntityPath, target *Path) bool {
+	if target == nil {
 		return false
 	}
-
-	return p.Entity.IsParent(target)
+	parent := target.Parent()
+	return parent != nil && parent.String() == e.String()
 }
 
-func (p *Path) RelativeOf(target *Path) (*Path, error) {
-	return nil, nil
+func hasSegmentPrefix(segments, prefix []string) bool {
+	for i, segment := range prefix {
+		if segments[i] != segment {
+			return false
+		}
+	}
+	return true
 }
@@ -0,0 +1,395 @@
+package protocol
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// PathVars holds the variables captured by a path pattern.
+type PathVars map[string]string
+
+// PathDefinition declares a path type: the patterns its strings follow, how
+// to build it from the captured variables and which path types may be
+// joined onto it. A type listed in Joins only joins if the combined string
+// matches a registered pattern too, so declaring a join usually means
+// registering the combined path type as well.
+//
+// Patterns are "/"-separated, without the leading "@". A segment is a
+// literal or a variable in braces:
+//
+//	{name}      exactly one non-empty segment
+//	{name?}     zero or one segment
+//	{name*}     zero or more segments, captured joined by "/"
+//	{name+}     one or more segments
+//	{name:a|b}  one segment out of the listed values
+//
+// When several patterns match, the one with the most literal and listed
+// segments wins; ties go to the definition registered first.
+type PathDefinition struct {
+	Type     PathType
+	Patterns []string
+	New      func(vars PathVars) EntityPath
+	Joins    []PathType
+}
+
+var (
+	ErrInvalidPathPattern = errors.New("invalid path pattern")
+	ErrPathTypeRegistered = errors.New("path type already registered")
+	ErrInvalidPathEscape  = errors.New("invalid path escape")
+	ErrInvalidPathName    = errors.New("invalid path name")
+	ErrInvalidPathJoin    = errors.New("invalid path join")
+)
+
+// pathReserved are the characters that carry meaning in a path string:
+// segment separators, the root marker, wildcards and placeholder braces.
+// "_" is reserved only as a whole segment, where it is a wildcard too, so
+// that names such as "temp_sensor" keep their underscores.
+const (
+	pathReserved           = "%/@*{}+#"
+	pathPlaceholderEscaped = "%5F"
+)
+
+type segmentKind int
+
+const (
+	segmentLiteral segmentKind = iota
+	segmentOne
+	segmentOptional
+	segmentAny
+	segmentSome
+)
+
+type patternSegment struct {
+	kind    segmentKind
+	literal string
+	name    string
+	values  []string
+}
+
+type pathRule struct {
+	def         *PathDefinition
+	segments    []patternSegment
+	specificity int
+	order       int
+}
+
+type pathGrammar struct {
+	mu    sync.RWMutex
+	rules map[string][]*pathRule
+	defs  map[PathType]*PathDefinition
+	order int
+}
+
+var paths = &pathGrammar{
+	rules: make(map[string][]*pathRule),
+	defs:  make(map[PathType]*PathDefinition),
+}
+
+// RegisterPath adds a path type to the grammar used by NewPath, e.g. an
+// application's own "@assets/{asset}" entity.
+func RegisterPath(def *PathDefinition) error {
+	if def.Type == "" || def.New == nil || len(def.Patterns) == 0 {
+		return fmt.Errorf("%w: %s needs a type, patterns and a constructor", ErrInvalidPathPattern, def.Type)
+	}
+	rules := make([]*pathRule, 0, len(def.Patterns))
+	for _, pattern := range def.Patterns {
+		rule, err := compilePathPattern(pattern)
+		if err != nil {
+			return err
+		}
+		rule.def = def
+		rules = append(rules, rule)
+	}
+
+	paths.mu.Lock()
+	defer paths.mu.Unlock()
+	if _, ok := paths.defs[def.Type]; ok {
+		return fmt.Errorf("%w: %s", ErrPathTypeRegistered, def.Type)
+	}
+	paths.defs[def.Type] = def
+	for _, rule := range rules {
+		paths.order++
+		rule.order = paths.order
+		root := rule.segments[0].literal
+		paths.rules[root] = append(paths.rules[root], rule)
+	}
+	return nil
+}
+
+func MustRegisterPath(def *PathDefinition) {
+	if err := RegisterPath(def); err != nil {
+		panic(err)
+	}
+}
+
+func LookupPathDefinition(typ PathType) (*PathDefinition, bool) {
+	paths.mu.RLock()
+	defer paths.mu.RUnlock()
+	def, ok := paths.defs[typ]
+	return def, ok
+}
+
+func compilePathPattern(pattern string) (*pathRule, error) {
+	rule := &pathRule{}
+	for _, s := range strings.Split(pattern, "/") {
+		if !strings.HasPrefix(s, "{") {
+			if strings.ContainsAny(s, "{}") {
+				return nil, fmt.Errorf("%w: %q", ErrInvalidPathPattern, pattern)
+			}
+			rule.segments = append(rule.segments, patternSegment{kind: segmentLiteral, literal: s})
+			rule.specificity++
+			continue
+		}
+		if !strings.HasSuffix(s, "}") {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidPathPattern, pattern)
+		}
+		seg := patternSegment{kind: segmentOne}
+		spec := strings.TrimSuffix(strings.TrimPrefix(s, "{"), "}")
+		if name, values, ok := strings.Cut(spec, ":"); ok {
+			spec, seg.values = name, strings.Split(values, "|")
+			rule.specificity++
+		}
+		switch {
+		case strings.HasSuffix(spec, "?"):
+			seg.kind = segmentOptional
+		case strings.HasSuffix(spec, "*"):
+			seg.kind = segmentAny
+		case strings.HasSuffix(spec, "+"):
+			seg.kind = segmentSome
+		}
+		seg.name = strings.TrimRight(spec, "?*+")
+		if seg.name == "" {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidPathPattern, pattern)
+		}
+		rule.segments = append(rule.segments, seg)
+	}
+	if rule.segments[0].kind != segmentLiteral {
+		return nil, fmt.Errorf("%w: %q must start with a literal", ErrInvalidPathPattern, pattern)
+	}
+	return rule, nil
+}
+
+func (r *pathRule) match(segments []string, vars PathVars) bool {
+	if len(r.segments) == 0 {
+		return len(segments) == 0
+	}
+	seg, rest := r.segments[0], &pathRule{segments: r.segments[1:]}
+	switch seg.kind {
+	case segmentLiteral:
+		return len(segments) > 0 && segments[0] == seg.literal && rest.match(segments[1:], vars)
+	case segmentOne:
+		return len(segments) > 0 && seg.accepts(segments[0]) && rest.capture(seg.name, segments[:1], segments[1:], vars)
+	case segmentOptional:
+		if len(segments) > 0 && seg.accepts(segments[0]) && rest.capture(seg.name, segments[:1], segments[1:], vars) {
+			return true
+		}
+		return rest.match(segments, vars)
+	}
+	min := 0
+	if seg.kind == segmentSome {
+		min = 1
+	}
+	for n := len(segments); n >= min; n-- {
+		if n > 0 && segments[0] == "" {
+			continue
+		}
+		if rest.capture(seg.name, segments[:n], segments[n:], vars) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *pathRule) capture(name string, value, segments []string, vars PathVars) bool {
+	if !r.match(segments, vars) {
+		return false
+	}
+	if len(value) > 0 {
+		vars[name] = strings.Join(value, "/")
+	}
+	return true
+}
+
+func (s patternSegment) accepts(segment string) bool {
+	if segment == "" {
+		return false
+	}
+	if s.values == nil {
+		return true
+	}
+	for _, v := range s.values {
+		if v == segment {
+			return true
+		}
+	}
+	return false
+}
+
+func parseEntityPath(str string) (EntityPath, error) {
+	if !strings.HasPrefix(str, pathRoot) {
+		return nil, nil
+	}
+	segments := strings.Split(strings.TrimPrefix(str, pathRoot), "/")
+	for i, segment := range segments {
+		canonical, err := canonicalPathSegment(segment)
+		if err != nil {
+			return nil, err
+		}
+		segments[i] = canonical
+	}
+
+	paths.mu.RLock()
+	candidates := paths.rules[segments[0]]
+	paths.mu.RUnlock()
+
+	var (
+		best     *pathRule
+		bestVars PathVars
+	)
+	for _, rule := range candidates {
+		if best != nil && (rule.specificity < best.specificity || rule.specificity == best.specificity && rule.order > best.order) {
+			continue
+		}
+		vars := PathVars{}
+		if rule.match(segments, vars) {
+			best, bestVars = rule, vars
+		}
+	}
+	if best == nil {
+		return nil, nil
+	}
+	return best.def.New(bestVars), nil
+}
+
+func isJoinOf(p, s PathType) bool {
+	def, ok := LookupPathDefinition(p)
+	if !ok {
+		return false
+	}
+	for _, t := range def.Joins {
+		if t == s {
+			return true
+		}
+	}
+	return false
+}
+
+// EscapePathName percent-encodes the reserved characters of a name, so that
+// e.g. a thing called "a/b" reads back as one segment rather than two and a
+// feature called "*" is not taken for a wildcard. The With* builders escape
+// every name they are given; entity fields hold the escaped form.
+func EscapePathName(name string) string {
+	if name == TopicPlaceholder {
+		return pathPlaceholderEscaped
+	}
+	if !strings.ContainsAny(name, pathReserved) {
+		return name
+	}
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		writePathByte(&b, name[i])
+	}
+	return b.String()
+}
+
+// UnescapePathName returns the name an escaped path segment stands for.
+func UnescapePathName(segment string) (string, error) {
+	if !strings.Contains(segment, "%") {
+		return segment, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(segment); i++ {
+		if segment[i] != '%' {
+			b.WriteByte(segment[i])
+			continue
+		}
+		c, err := unhexPathEscape(segment, i)
+		if err != nil {
+			return "", err
+		}
+		b.WriteByte(c)
+		i += 2
+	}
+	return b.String(), nil
+}
+
+// escapePathNames escapes each segment of a "/"-separated property or
+// attribute path, keeping the separators.
+func escapePathNames(names string) string {
+	if !strings.ContainsAny(names, pathReserved+TopicPlaceholder) {
+		return names
+	}
+	segments := strings.Split(names, "/")
+	for i, segment := range segments {
+		segments[i] = EscapePathName(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// canonicalPathSegment decodes escapes of unreserved characters and upper
+// cases the rest, so that a parsed path equals the one the With* builders
+// produce for the same names. Unescaped reserved characters are kept as
+// they are: they are wildcards or placeholders, not part of a name.
+func canonicalPathSegment(segment string) (string, error) {
+	if !strings.Contains(segment, "%") {
+		return segment, nil
+	}
+	if strings.EqualFold(segment, pathPlaceholderEscaped) {
+		return pathPlaceholderEscaped, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(segment); i++ {
+		if segment[i] != '%' {
+			b.WriteByte(segment[i])
+			continue
+		}
+		c, err := unhexPathEscape(segment, i)
+		if err != nil {
+			return "", err
+		}
+		if strings.IndexByte(pathReserved, c) >= 0 {
+			writePathByte(&b, c)
+		} else {
+			b.WriteByte(c)
+		}
+		i += 2
+	}
+	return b.String(), nil
+}
+
+func writePathByte(b *strings.Builder, c byte) {
+	if strings.IndexByte(pathReserved, c) < 0 {
+		b.WriteByte(c)
+		return
+	}
+	const hex = "0123456789ABCDEF"
+	b.WriteByte('%')
+	b.WriteByte(hex[c>>4])
+	b.WriteByte(hex[c&0xf])
+}
+
+func unhexPathEscape(segment string, i int) (byte, error) {
+	if i+2 >= len(segment) {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidPathEscape, segment)
+	}
+	hi, ok1 := unhex(segment[i+1])
+	lo, ok2 := unhex(segment[i+2])
+	if !ok1 || !ok2 {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidPathEscape, segment)
+	}
+	return hi<<4 | lo, nil
+}
+
+func unhex(c byte) (byte, bool) {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0', true
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10, true
+	case 'A' <= c && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
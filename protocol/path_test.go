@@ -0,0 +1,273 @@
+package protocol
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type assetPath struct {
+	Asset string
+}
+
+func (a *assetPath) Name() string               { return a.Asset }
+func (a *assetPath) String() string             { return "@assets/" + a.Asset }
+func (a *assetPath) Type() PathType             { return "asset_path" }
+func (a *assetPath) EntityType() EntityType     { return EntityUnknown }
+func (a *assetPath) IsParent(target *Path) bool { return false }
+
+func TestPathJoin(t *testing.T) {
+	thing := (&Path{}).WithThing("t1")
+	joined, err := thing.Join((&Path{}).WithFeature("f1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if joined.String() != "@things/t1/features/f1" {
+		t.Fatalf("joined %s", joined)
+	}
+
+	// attributes are not declared as a join of a feature property
+	if _, err := (&Path{}).WithFeaturePropertie("f1", "p").Join((&Path{}).WithAttribute("a")); !errors.Is(err, ErrInvalidPathJoin) {
+		t.Fatalf("undeclared join: got %v", err)
+	}
+
+	// a declared join without a registered combined pattern
+	if err := RegisterPath(&PathDefinition{
+		Type:     "asset_path",
+		Patterns: []string{"assets/{asset}"},
+		Joins:    []PathType{PathTypeFeatures},
+		New:      func(v PathVars) EntityPath { return &assetPath{Asset: v["asset"]} },
+	}); err != nil {
+		t.Fatal(err)
+	}
+	asset, err := NewPath("@assets/a1")
+	if err != nil || asset.Empty() {
+		t.Fatalf("asset path: %v", err)
+	}
+	if _, err := asset.Join((&Path{}).WithFeature("f1")); !errors.Is(err, ErrInvalidPathJoin) {
+		t.Fatalf("unregistered combination: got %v", err)
+	}
+}
+
+func TestPlaceholderNameIsNotWildcard(t *testing.T) {
+	p := (&Path{}).WithThingFeature("t1", "_")
+	if p.String() != "@things/t1/features/%5F" {
+		t.Fatalf("escaped %s", p)
+	}
+	m := MustCompileMatcher(p.String())
+	if m.MatchPath((&Path{}).WithThingFeature("t1", "f1")) {
+		t.Fatal("feature _ matched another feature")
+	}
+	if !m.MatchPath(p) {
+		t.Fatal("feature _ did not match itself")
+	}
+	parsed, err := NewPath("@things/t1/features/%5f")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.String() != p.String() {
+		t.Fatalf("parsed %s, want %s", parsed, p)
+	}
+	if kept := (&Path{}).WithThingFeature("t1", "temp_sensor"); kept.String() != "@things/t1/features/temp_sensor" {
+		t.Fatalf("escaped %s", kept)
+	}
+}
+
+func TestPathNameRoundTrip(t *testing.T) {
+	for _, name := range []string{"a/b", "a@b", "@", "*", "a*", "{{ thing:id }}", "{{", "}}", "%", "100%", "%2F", "a+b#c", "temp_sensor"} {
+		p := (&Path{}).WithThingFeaturePropertie(name, name, "x/"+name)
+		if err := p.Validate(); err != nil {
+			t.Errorf("%q: %v", name, err)
+			continue
+		}
+		if p.HasWildCard() || p.HasPlaceHolders() {
+			t.Errorf("%q: escaped path %s reads as a pattern", name, p)
+		}
+		parsed, err := NewPath(p.String())
+		if err != nil {
+			t.Errorf("%q: %v", name, err)
+			continue
+		}
+		if !reflect.DeepEqual(parsed.Entity, p.Entity) {
+			t.Errorf("%q: %s parsed as %#v", name, p, parsed.Entity)
+		}
+		e := parsed.GetThingFeatureProperties()
+		thing, _ := UnescapePathName(e.Thing)
+		feature, _ := UnescapePathName(e.Feature)
+		if thing != name || feature != name {
+			t.Errorf("%q: names read back as %q and %q", name, thing, feature)
+		}
+
+		data, err := json.Marshal(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded Path
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Errorf("%q: %v", name, err)
+		} else if decoded.String() != p.String() {
+			t.Errorf("%q: JSON read back as %s, want %s", name, decoded.String(), p)
+		}
+	}
+}
+
+func TestPathEmptyNames(t *testing.T) {
+	for _, p := range []*Path{
+		(&Path{}).WithThing(""),
+		(&Path{}).WithThingFeature("t1", ""),
+		(&Path{}).WithThingFeature("", "f1"),
+		(&Path{}).WithThingFeaturePropertie("t1", "f1", ""),
+		(&Path{}).WithDeviceStrategy("d1", ""),
+		(&Path{}).WithFeature(""),
+	} {
+		if err := p.Validate(); !errors.Is(err, ErrInvalidPathName) {
+			t.Errorf("%s: got %v, want ErrInvalidPathName", p, err)
+		}
+		if _, _, err := EncodeEnvelope(nil, &Envelope{Path: p}); !errors.Is(err, ErrInvalidPathName) {
+			t.Errorf("%s: encoded with an empty name: %v", p, err)
+		}
+	}
+
+	// reusing a builder clears the error
+	p := (&Path{}).WithThingFeature("t1", "").WithThingFeature("t1", "f1")
+	if err := p.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := (&Path{}).WithThingFeatures("t1").Validate(); err != nil {
+		t.Fatalf("features collection: %v", err)
+	}
+}
+
+func TestNewPathRejectsUnknownPaths(t *testing.T) {
+	for _, s := range []string{"", "things/t1", "@unknown/x", "@things/t1/unknown"} {
+		if p, err := NewPath(s); !errors.Is(err, ErrInvalidPathName) {
+			t.Errorf("%q: got %v, %v, want ErrInvalidPathName", s, p, err)
+		}
+	}
+	if _, err := NewPath("@things/t1%2"); !errors.Is(err, ErrInvalidPathEscape) {
+		t.Errorf("malformed escape: %v", err)
+	}
+	var p Path
+	if err := json.Unmarshal([]byte(`"@unknown/x"`), &p); !errors.Is(err, ErrInvalidPathName) {
+		t.Errorf("JSON: got %v, want ErrInvalidPathName", err)
+	}
+	if err := json.Unmarshal([]byte(`""`), &p); err != nil || !p.Empty() {
+		t.Errorf("empty JSON path: %v", err)
+	}
+}
+
+func mustPath(t *testing.T, s string) *Path {
+	t.Helper()
+	p, err := NewPath(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestPathHierarchy(t *testing.T) {
+	p := mustPath(t, "@things/t1/features/f1/properties/x")
+	if got := p.Segments(); !reflect.DeepEqual(got, []string{"things", "t1", "features", "f1", "properties", "x"}) {
+		t.Fatalf("segments %v", got)
+	}
+	if NewRootPath().Segments() != nil {
+		t.Fatal("root path has segments")
+	}
+
+	var ancestors []string
+	for _, a := range p.Ancestors() {
+		ancestors = append(ancestors, a.String())
+	}
+	want := []string{"@things/t1/features/f1/properties", "@things/t1/features/f1", "@things/t1/features", "@things/t1", "@"}
+	if !reflect.DeepEqual(ancestors, want) {
+		t.Fatalf("ancestors %v, want %v", ancestors, want)
+	}
+	if parent := mustPath(t, "@things/t1/messages/incoming/s").Parent(); parent.String() != "@things/t1" {
+		t.Fatalf("parent skipped to %s, want @things/t1", parent)
+	}
+	if NewRootPath().Parent() != nil || (&Path{}).Parent() != nil {
+		t.Fatal("root or empty path has a parent")
+	}
+	if len(NewRootPath().Ancestors()) != 0 {
+		t.Fatal("root path has ancestors")
+	}
+
+	thing := mustPath(t, "@things/t1")
+	if !thing.IsAncestor(p) || !NewRootPath().IsAncestor(p) {
+		t.Fatal("a change below @things/t1 does not affect it")
+	}
+	if p.IsAncestor(thing) || thing.IsAncestor(thing) {
+		t.Fatal("IsAncestor is not strict")
+	}
+	if mustPath(t, "@things/t10").IsAncestor(p) {
+		t.Fatal("@things/t10 is taken for an ancestor of @things/t1/...")
+	}
+	if !thing.Overlaps(p) || !p.Overlaps(thing) || !p.Overlaps(p) {
+		t.Fatal("nested paths do not overlap")
+	}
+	if thing.Overlaps(mustPath(t, "@things/t2")) || thing.Overlaps(&Path{}) || (&Path{}).IsAncestor(thing) {
+		t.Fatal("unrelated or empty paths overlap")
+	}
+
+	if !thing.IsParent(mustPath(t, "@things/t1/features")) || thing.IsParent(p) || (&Path{}).IsParent(thing) {
+		t.Fatal("IsParent is not the nearest enclosing path")
+	}
+}
+
+func TestPathRelativeOfInvertsJoin(t *testing.T) {
+	for _, tt := range []struct{ base, target string }{
+		{"@things/t1", "@features/f1"},
+		{"@things/t1", "@features/f1/properties/a/b"},
+		{"@devices/d1", "@strategys/s1/indicators/i1"},
+	} {
+		base, target := mustPath(t, tt.base), mustPath(t, tt.target)
+		joined, err := base.Join(target)
+		if err != nil {
+			t.Fatalf("%s + %s: %v", tt.base, tt.target, err)
+		}
+		rel, err := joined.RelativeOf(base)
+		if err != nil {
+			t.Fatalf("%s relative of %s: %v", joined, base, err)
+		}
+		if rel.String() != target.String() || rel.Type() != target.Type() {
+			t.Fatalf("%s relative of %s = %s, want %s", joined, base, rel, target)
+		}
+	}
+
+	p := mustPath(t, "@things/t1/features/f1")
+	if rel, err := p.RelativeOf(NewRootPath()); err != nil || rel.String() != p.String() {
+		t.Fatalf("relative of root: %v, %v", rel, err)
+	}
+	for _, base := range []string{"@things/t1/features/f1", "@things/t2", "@things/t1/features/f1/properties"} {
+		if _, err := p.RelativeOf(mustPath(t, base)); err == nil {
+			t.Fatalf("%s relative of %s succeeded", p, base)
+		}
+	}
+}
+
+func TestCommonPrefix(t *testing.T) {
+	for _, tt := range []struct {
+		paths []string
+		want  string
+	}{
+		{[]string{"@things/t1/features/f1", "@things/t1/features/f2"}, "@things/t1/features"},
+		{[]string{"@things/t1/features/f1/properties/a", "@things/t1/features/f1/properties/b"}, "@things/t1/features/f1/properties"},
+		{[]string{"@things/t1/features/f1", "@things/t1/attributes/a"}, "@things/t1"},
+		{[]string{"@things/t1/messages/incoming/a", "@things/t1/messages/incoming/b"}, "@things/t1"},
+		{[]string{"@things/t1", "@devices/d1"}, "@"},
+		{[]string{"@things/t1/features/f1"}, "@things/t1/features/f1"},
+		{[]string{"@things/t1", "@"}, "@"},
+	} {
+		var paths []*Path
+		for _, s := range tt.paths {
+			paths = append(paths, mustPath(t, s))
+		}
+		if got := CommonPrefix(paths...); got.String() != tt.want {
+			t.Errorf("CommonPrefix(%v) = %s, want %s", tt.paths, got, tt.want)
+		}
+	}
+	if CommonPrefix() != nil {
+		t.Fatal("common prefix of no paths")
+	}
+}
@@ -0,0 +1,43 @@
+package pb
+
+import (
+	"github.com/flywave/go-twins/protocol"
+	"google.golang.org/protobuf/proto"
+)
+
+const ContentTypeProtobuf = "application/protobuf"
+
+// Importing the package registers the protobuf codec with the protocol
+// codec registry.
+func init() {
+	protocol.RegisterCodec(Codec{})
+	protocol.RegisterCodecAlias("application/x-protobuf", ContentTypeProtobuf)
+	protocol.RegisterCodecAlias("application/vnd.google.protobuf", ContentTypeProtobuf)
+}
+
+type Codec struct{}
+
+func (Codec) ContentType() string {
+	return ContentTypeProtobuf
+}
+
+func (Codec) Marshal(message *protocol.Envelope) ([]byte, error) {
+	m, err := FromEnvelope(message)
+	if err != nil {
+		return nil, err
+	}
+	return proto.Marshal(m)
+}
+
+func (Codec) Unmarshal(data []byte, message *protocol.Envelope) error {
+	m := &Envelope{}
+	if err := proto.Unmarshal(data, m); err != nil {
+		return err
+	}
+	res, err := ToEnvelope(m)
+	if err != nil {
+		return err
+	}
+	*message = *res
+	return nil
+}
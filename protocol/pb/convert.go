@@ -0,0 +1,335 @@
+package pb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative envelope.proto service.proto
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/flywave/go-twins/protocol"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func FromTopic(topic *protocol.Topic) *Topic {
+	if topic == nil {
+		return nil
+	}
+	return &Topic{
+		Tenant:    topic.TenantName,
+		Channel:   topic.ChannelName,
+		Entity:    string(topic.Entity),
+		Criterion: string(topic.Criterion),
+		Action:    string(topic.Action),
+	}
+}
+
+func ToTopic(topic *Topic) *protocol.Topic {
+	if topic == nil {
+		return nil
+	}
+	return &protocol.Topic{
+		TenantName:  topic.Tenant,
+		ChannelName: topic.Channel,
+		Entity:      protocol.EntityType(topic.Entity),
+		Criterion:   protocol.TopicCriterion(topic.Criterion),
+		Action:      protocol.TopicAction(topic.Action),
+	}
+}
+
+func FromHeaders(headers *protocol.Headers) (map[string]*HeaderValue, error) {
+	if headers == nil || headers.Values == nil {
+		return nil, nil
+	}
+	res := make(map[string]*HeaderValue, len(headers.Values))
+	for k, v := range headers.Values {
+		hv, err := FromHeaderValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("pb: header %s: %v", k, err)
+		}
+		res[k] = hv
+	}
+	return res, nil
+}
+
+func ToHeaders(headers map[string]*HeaderValue) *protocol.Headers {
+	if headers == nil {
+		return nil
+	}
+	values := make(map[string]interface{}, len(headers))
+	for k, v := range headers {
+		values[k] = ToHeaderValue(v)
+	}
+	return protocol.NewHeadersWithValues(values)
+}
+
+func FromHeaderValue(v interface{}) (*HeaderValue, error) {
+	switch t := v.(type) {
+	case string:
+		return &HeaderValue{Kind: &HeaderValue_StringValue{StringValue: t}}, nil
+	case bool:
+		return &HeaderValue{Kind: &HeaderValue_BoolValue{BoolValue: t}}, nil
+	case int:
+		return &HeaderValue{Kind: &HeaderValue_IntValue{IntValue: int64(t)}}, nil
+	case int8:
+		return &HeaderValue{Kind: &HeaderValue_IntValue{IntValue: int64(t)}}, nil
+	case int16:
+		return &HeaderValue{Kind: &HeaderValue_IntValue{IntValue: int64(t)}}, nil
+	case int32:
+		return &HeaderValue{Kind: &HeaderValue_IntValue{IntValue: int64(t)}}, nil
+	case int64:
+		return &HeaderValue{Kind: &HeaderValue_IntValue{IntValue: t}}, nil
+	case uint8:
+		return &HeaderValue{Kind: &HeaderValue_IntValue{IntValue: int64(t)}}, nil
+	case uint16:
+		return &HeaderValue{Kind: &HeaderValue_IntValue{IntValue: int64(t)}}, nil
+	case uint32:
+		return &HeaderValue{Kind: &HeaderValue_IntValue{IntValue: int64(t)}}, nil
+	case uint:
+		return &HeaderValue{Kind: &HeaderValue_UintValue{UintValue: uint64(t)}}, nil
+	case uint64:
+		return &HeaderValue{Kind: &HeaderValue_UintValue{UintValue: t}}, nil
+	case float32:
+		return &HeaderValue{Kind: &HeaderValue_DoubleValue{DoubleValue: float64(t)}}, nil
+	case float64:
+		return &HeaderValue{Kind: &HeaderValue_DoubleValue{DoubleValue: t}}, nil
+	}
+	value, integers, err := FromValue(v)
+	if err != nil {
+		return nil, err
+	}
+	return &HeaderValue{Kind: &HeaderValue_JsonValue{JsonValue: value}, JsonIntegers: integers}, nil
+}
+
+func ToHeaderValue(v *HeaderValue) interface{} {
+	switch t := v.GetKind().(type) {
+	case *HeaderValue_StringValue:
+		return t.StringValue
+	case *HeaderValue_BoolValue:
+		return t.BoolValue
+	case *HeaderValue_IntValue:
+		return t.IntValue
+	case *HeaderValue_UintValue:
+		return t.UintValue
+	case *HeaderValue_DoubleValue:
+		return t.DoubleValue
+	case *HeaderValue_JsonValue:
+		return ToValue(t.JsonValue, v.JsonIntegers)
+	}
+	return nil
+}
+
+// maxExactInteger bounds the integers a double holds exactly.
+const maxExactInteger = 1 << 53
+
+// FromValue converts an envelope value. Values structpb cannot take
+// directly, such as model structs, go through their JSON form. Integers a
+// double cannot hold exactly are returned in integers as well, which is nil
+// when there are none.
+func FromValue(v interface{}) (*structpb.Value, *ExactIntegers, error) {
+	integers := &ExactIntegers{}
+	generic, err := genericValue(v, "", integers)
+	if err != nil {
+		return nil, nil, err
+	}
+	value, err := structpb.NewValue(generic)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(integers.Ints) == 0 && len(integers.Uints) == 0 {
+		integers = nil
+	}
+	return value, integers, nil
+}
+
+// ToValue converts a value back, restoring the exact integers.
+func ToValue(value *structpb.Value, integers *ExactIntegers) interface{} {
+	v := value.AsInterface()
+	for pointer, i := range integers.GetInts() {
+		v = setPointer(v, pointer, i)
+	}
+	for pointer, u := range integers.GetUints() {
+		v = setPointer(v, pointer, u)
+	}
+	return v
+}
+
+// genericValue returns v in a form structpb takes, recording integers
+// beyond maxExactInteger under their JSON pointer.
+func genericValue(v interface{}, pointer string, integers *ExactIntegers) (interface{}, error) {
+	switch t := v.(type) {
+	case nil, bool, string, []byte, float32, float64, int8, int16, int32, uint8, uint16, uint32:
+		return v, nil
+	case int:
+		return exactInt(int64(t), pointer, integers), nil
+	case int64:
+		return exactInt(t, pointer, integers), nil
+	case uint:
+		return exactUint(uint64(t), pointer, integers), nil
+	case uint64:
+		return exactUint(t, pointer, integers), nil
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return exactInt(i, pointer, integers), nil
+		}
+		if u, err := strconv.ParseUint(t.String(), 10, 64); err == nil {
+			return exactUint(u, pointer, integers), nil
+		}
+		return t.Float64()
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(t))
+		for k, e := range t {
+			g, err := genericValue(e, pointer+"/"+pointerEscaper.Replace(k), integers)
+			if err != nil {
+				return nil, err
+			}
+			res[k] = g
+		}
+		return res, nil
+	case []interface{}:
+		res := make([]interface{}, len(t))
+		for i, e := range t {
+			g, err := genericValue(e, pointer+"/"+strconv.Itoa(i), integers)
+			if err != nil {
+				return nil, err
+			}
+			res[i] = g
+		}
+		return res, nil
+	}
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(buf))
+	dec.UseNumber()
+	var generic interface{}
+	if err := dec.Decode(&generic); err != nil {
+		return nil, err
+	}
+	return genericValue(generic, pointer, integers)
+}
+
+func exactInt(i int64, pointer string, integers *ExactIntegers) interface{} {
+	if i > maxExactInteger || i < -maxExactInteger {
+		if integers.Ints == nil {
+			integers.Ints = make(map[string]int64)
+		}
+		integers.Ints[pointer] = i
+	}
+	return i
+}
+
+func exactUint(u uint64, pointer string, integers *ExactIntegers) interface{} {
+	if u > maxExactInteger {
+		if integers.Uints == nil {
+			integers.Uints = make(map[string]uint64)
+		}
+		integers.Uints[pointer] = u
+	}
+	return u
+}
+
+var (
+	pointerEscaper   = strings.NewReplacer("~", "~0", "/", "~1")
+	pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+)
+
+// setPointer replaces the value at pointer within v; pointers that do not
+// resolve leave v unchanged.
+func setPointer(v interface{}, pointer string, x interface{}) interface{} {
+	if pointer == "" {
+		return x
+	}
+	tokens := strings.Split(strings.TrimPrefix(pointer, "/"), "/")
+	parent := v
+	for i, token := range tokens {
+		token = pointerUnescaper.Replace(token)
+		last := i == len(tokens)-1
+		switch t := parent.(type) {
+		case map[string]interface{}:
+			if last {
+				if _, ok := t[token]; ok {
+					t[token] = x
+				}
+				return v
+			}
+			parent = t[token]
+		case []interface{}:
+			n, err := strconv.Atoi(token)
+			if err != nil || n < 0 || n >= len(t) {
+				return v
+			}
+			if last {
+				t[n] = x
+				return v
+			}
+			parent = t[n]
+		default:
+			return v
+		}
+	}
+	return v
+}
+
+// FromEnvelope converts an envelope. A nil value stays unset, while an
+// explicit JSON null becomes a null Value, so both survive the round trip.
+func FromEnvelope(message *protocol.Envelope) (*Envelope, error) {
+	if message == nil {
+		return nil, nil
+	}
+	headers, err := FromHeaders(message.Headers)
+	if err != nil {
+		return nil, err
+	}
+	res := &Envelope{
+		Topic:    FromTopic(message.Topic),
+		Headers:  headers,
+		Status:   int32(message.Status),
+		Revision: message.Revision,
+	}
+	if message.Path != nil {
+		res.Path = message.Path.String()
+	}
+	if message.Value != nil {
+		value, integers, err := FromValue(message.Value)
+		if err != nil {
+			return nil, err
+		}
+		res.Value = value
+		res.ValueIntegers = integers
+	}
+	if !message.Time.IsZero() {
+		res.Time = timestamppb.New(message.Time)
+	}
+	return res, nil
+}
+
+func ToEnvelope(message *Envelope) (*protocol.Envelope, error) {
+	if message == nil {
+		return nil, nil
+	}
+	res := &protocol.Envelope{
+		Topic:    ToTopic(message.Topic),
+		Headers:  ToHeaders(message.Headers),
+		Status:   int(message.Status),
+		Revision: message.Revision,
+	}
+	if message.Path != "" {
+		path, err := protocol.NewPath(message.Path)
+		if err != nil {
+			return nil, err
+		}
+		res.Path = path
+	}
+	if message.Value != nil {
+		res.Value = ToValue(message.Value, message.ValueIntegers)
+	}
+	if message.Time != nil {
+		res.Time = message.Time.AsTime()
+	}
+	return res, nil
+}
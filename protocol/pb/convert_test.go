@@ -0,0 +1,74 @@
+package pb
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/flywave/go-twins/protocol"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestLargeIntegersRoundTrip(t *testing.T) {
+	type reading struct {
+		Counter uint64 `json:"counter"`
+	}
+	message := &protocol.Envelope{
+		Topic: &protocol.Topic{TenantName: "t", ChannelName: "c", Entity: protocol.EntityThings, Criterion: protocol.CriterionEvents, Action: protocol.ActionModified},
+		Headers: protocol.NewHeadersWithValues(map[string]interface{}{
+			"x-big":    int64(math.MaxInt64),
+			"x-ubig":   uint64(math.MaxUint64),
+			"x-nested": map[string]interface{}{"ids": []interface{}{int64(1<<53 + 1)}},
+		}),
+		Value: map[string]interface{}{
+			"small":    int64(42),
+			"big":      int64(-1<<62 - 1),
+			"a/b~c":    uint64(1<<63 + 1),
+			"readings": []interface{}{reading{Counter: 1<<60 + 3}},
+		},
+	}
+
+	m, err := FromEnvelope(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf, err := proto.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := &Envelope{}
+	if err := proto.Unmarshal(buf, decoded); err != nil {
+		t.Fatal(err)
+	}
+	res, err := ToEnvelope(decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	value := res.Value.(map[string]interface{})
+	for key, want := range map[string]interface{}{
+		"small": float64(42),
+		"big":   int64(-1<<62 - 1),
+		"a/b~c": uint64(1<<63 + 1),
+	} {
+		if value[key] != want {
+			t.Errorf("value %s = %#v, want %#v", key, value[key], want)
+		}
+	}
+	// structs go through JSON, which keeps the number but not its Go type
+	counter := value["readings"].([]interface{})[0].(map[string]interface{})["counter"]
+	if counter != int64(1<<60+3) {
+		t.Errorf("nested counter = %#v", counter)
+	}
+
+	for name, want := range map[string]interface{}{
+		"x-big":    int64(math.MaxInt64),
+		"x-ubig":   uint64(math.MaxUint64),
+		"x-nested": map[string]interface{}{"ids": []interface{}{int64(1<<53 + 1)}},
+	} {
+		got, _ := res.Headers.Get(name)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("header %s = %#v, want %#v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,577 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: envelope.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Topic struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tenant        string                 `protobuf:"bytes,1,opt,name=tenant,proto3" json:"tenant,omitempty"`
+	Channel       string                 `protobuf:"bytes,2,opt,name=channel,proto3" json:"channel,omitempty"`
+	Entity        string                 `protobuf:"bytes,3,opt,name=entity,proto3" json:"entity,omitempty"`
+	Criterion     string                 `protobuf:"bytes,4,opt,name=criterion,proto3" json:"criterion,omitempty"`
+	Action        string                 `protobuf:"bytes,5,opt,name=action,proto3" json:"action,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Topic) Reset() {
+	*x = Topic{}
+	mi := &file_envelope_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Topic) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Topic) ProtoMessage() {}
+
+func (x *Topic) ProtoReflect() protoreflect.Message {
+	mi := &file_envelope_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Topic.ProtoReflect.Descriptor instead.
+func (*Topic) Descriptor() ([]byte, []int) {
+	return file_envelope_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Topic) GetTenant() string {
+	if x != nil {
+		return x.Tenant
+	}
+	return ""
+}
+
+func (x *Topic) GetChannel() string {
+	if x != nil {
+		return x.Channel
+	}
+	return ""
+}
+
+func (x *Topic) GetEntity() string {
+	if x != nil {
+		return x.Entity
+	}
+	return ""
+}
+
+func (x *Topic) GetCriterion() string {
+	if x != nil {
+		return x.Criterion
+	}
+	return ""
+}
+
+func (x *Topic) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+// ExactIntegers carries the integers of a google.protobuf.Value that a
+// double cannot hold exactly, keyed by their JSON pointer into the value
+// ("" for the value itself). Readers that ignore it see the nearest double.
+type ExactIntegers struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ints          map[string]int64       `protobuf:"bytes,1,rep,name=ints,proto3" json:"ints,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"zigzag64,2,opt,name=value"`
+	Uints         map[string]uint64      `protobuf:"bytes,2,rep,name=uints,proto3" json:"uints,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExactIntegers) Reset() {
+	*x = ExactIntegers{}
+	mi := &file_envelope_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExactIntegers) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExactIntegers) ProtoMessage() {}
+
+func (x *ExactIntegers) ProtoReflect() protoreflect.Message {
+	mi := &file_envelope_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExactIntegers.ProtoReflect.Descriptor instead.
+func (*ExactIntegers) Descriptor() ([]byte, []int) {
+	return file_envelope_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ExactIntegers) GetInts() map[string]int64 {
+	if x != nil {
+		return x.Ints
+	}
+	return nil
+}
+
+func (x *ExactIntegers) GetUints() map[string]uint64 {
+	if x != nil {
+		return x.Uints
+	}
+	return nil
+}
+
+type HeaderValue struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Kind:
+	//
+	//	*HeaderValue_StringValue
+	//	*HeaderValue_BoolValue
+	//	*HeaderValue_IntValue
+	//	*HeaderValue_DoubleValue
+	//	*HeaderValue_JsonValue
+	//	*HeaderValue_UintValue
+	Kind          isHeaderValue_Kind `protobuf_oneof:"kind"`
+	JsonIntegers  *ExactIntegers     `protobuf:"bytes,7,opt,name=json_integers,json=jsonIntegers,proto3" json:"json_integers,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HeaderValue) Reset() {
+	*x = HeaderValue{}
+	mi := &file_envelope_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HeaderValue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HeaderValue) ProtoMessage() {}
+
+func (x *HeaderValue) ProtoReflect() protoreflect.Message {
+	mi := &file_envelope_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HeaderValue.ProtoReflect.Descriptor instead.
+func (*HeaderValue) Descriptor() ([]byte, []int) {
+	return file_envelope_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *HeaderValue) GetKind() isHeaderValue_Kind {
+	if x != nil {
+		return x.Kind
+	}
+	return nil
+}
+
+func (x *HeaderValue) GetStringValue() string {
+	if x != nil {
+		if x, ok := x.Kind.(*HeaderValue_StringValue); ok {
+			return x.StringValue
+		}
+	}
+	return ""
+}
+
+func (x *HeaderValue) GetBoolValue() bool {
+	if x != nil {
+		if x, ok := x.Kind.(*HeaderValue_BoolValue); ok {
+			return x.BoolValue
+		}
+	}
+	return false
+}
+
+func (x *HeaderValue) GetIntValue() int64 {
+	if x != nil {
+		if x, ok := x.Kind.(*HeaderValue_IntValue); ok {
+			return x.IntValue
+		}
+	}
+	return 0
+}
+
+func (x *HeaderValue) GetDoubleValue() float64 {
+	if x != nil {
+		if x, ok := x.Kind.(*HeaderValue_DoubleValue); ok {
+			return x.DoubleValue
+		}
+	}
+	return 0
+}
+
+func (x *HeaderValue) GetJsonValue() *structpb.Value {
+	if x != nil {
+		if x, ok := x.Kind.(*HeaderValue_JsonValue); ok {
+			return x.JsonValue
+		}
+	}
+	return nil
+}
+
+func (x *HeaderValue) GetUintValue() uint64 {
+	if x != nil {
+		if x, ok := x.Kind.(*HeaderValue_UintValue); ok {
+			return x.UintValue
+		}
+	}
+	return 0
+}
+
+func (x *HeaderValue) GetJsonIntegers() *ExactIntegers {
+	if x != nil {
+		return x.JsonIntegers
+	}
+	return nil
+}
+
+type isHeaderValue_Kind interface {
+	isHeaderValue_Kind()
+}
+
+type HeaderValue_StringValue struct {
+	StringValue string `protobuf:"bytes,1,opt,name=string_value,json=stringValue,proto3,oneof"`
+}
+
+type HeaderValue_BoolValue struct {
+	BoolValue bool `protobuf:"varint,2,opt,name=bool_value,json=boolValue,proto3,oneof"`
+}
+
+type HeaderValue_IntValue struct {
+	IntValue int64 `protobuf:"varint,3,opt,name=int_value,json=intValue,proto3,oneof"`
+}
+
+type HeaderValue_DoubleValue struct {
+	DoubleValue float64 `protobuf:"fixed64,4,opt,name=double_value,json=doubleValue,proto3,oneof"`
+}
+
+type HeaderValue_JsonValue struct {
+	JsonValue *structpb.Value `protobuf:"bytes,5,opt,name=json_value,json=jsonValue,proto3,oneof"`
+}
+
+type HeaderValue_UintValue struct {
+	UintValue uint64 `protobuf:"varint,6,opt,name=uint_value,json=uintValue,proto3,oneof"`
+}
+
+func (*HeaderValue_StringValue) isHeaderValue_Kind() {}
+
+func (*HeaderValue_BoolValue) isHeaderValue_Kind() {}
+
+func (*HeaderValue_IntValue) isHeaderValue_Kind() {}
+
+func (*HeaderValue_DoubleValue) isHeaderValue_Kind() {}
+
+func (*HeaderValue_JsonValue) isHeaderValue_Kind() {}
+
+func (*HeaderValue_UintValue) isHeaderValue_Kind() {}
+
+type Envelope struct {
+	state         protoimpl.MessageState  `protogen:"open.v1"`
+	Topic         *Topic                  `protobuf:"bytes,1,opt,name=topic,proto3" json:"topic,omitempty"`
+	Headers       map[string]*HeaderValue `protobuf:"bytes,2,rep,name=headers,proto3" json:"headers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Path          string                  `protobuf:"bytes,3,opt,name=path,proto3" json:"path,omitempty"`
+	Value         *structpb.Value         `protobuf:"bytes,4,opt,name=value,proto3" json:"value,omitempty"`
+	Status        int32                   `protobuf:"varint,5,opt,name=status,proto3" json:"status,omitempty"`
+	Revision      int64                   `protobuf:"varint,6,opt,name=revision,proto3" json:"revision,omitempty"`
+	Time          *timestamppb.Timestamp  `protobuf:"bytes,7,opt,name=time,proto3" json:"time,omitempty"`
+	ValueIntegers *ExactIntegers          `protobuf:"bytes,8,opt,name=value_integers,json=valueIntegers,proto3" json:"value_integers,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Envelope) Reset() {
+	*x = Envelope{}
+	mi := &file_envelope_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Envelope) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Envelope) ProtoMessage() {}
+
+func (x *Envelope) ProtoReflect() protoreflect.Message {
+	mi := &file_envelope_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Envelope.ProtoReflect.Descriptor instead.
+func (*Envelope) Descriptor() ([]byte, []int) {
+	return file_envelope_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Envelope) GetTopic() *Topic {
+	if x != nil {
+		return x.Topic
+	}
+	return nil
+}
+
+func (x *Envelope) GetHeaders() map[string]*HeaderValue {
+	if x != nil {
+		return x.Headers
+	}
+	return nil
+}
+
+func (x *Envelope) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *Envelope) GetValue() *structpb.Value {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *Envelope) GetStatus() int32 {
+	if x != nil {
+		return x.Status
+	}
+	return 0
+}
+
+func (x *Envelope) GetRevision() int64 {
+	if x != nil {
+		return x.Revision
+	}
+	return 0
+}
+
+func (x *Envelope) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *Envelope) GetValueIntegers() *ExactIntegers {
+	if x != nil {
+		return x.ValueIntegers
+	}
+	return nil
+}
+
+type EnvelopeJournal struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Envelopes     []*Envelope            `protobuf:"bytes,1,rep,name=envelopes,proto3" json:"envelopes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EnvelopeJournal) Reset() {
+	*x = EnvelopeJournal{}
+	mi := &file_envelope_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EnvelopeJournal) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EnvelopeJournal) ProtoMessage() {}
+
+func (x *EnvelopeJournal) ProtoReflect() protoreflect.Message {
+	mi := &file_envelope_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EnvelopeJournal.ProtoReflect.Descriptor instead.
+func (*EnvelopeJournal) Descriptor() ([]byte, []int) {
+	return file_envelope_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *EnvelopeJournal) GetEnvelopes() []*Envelope {
+	if x != nil {
+		return x.Envelopes
+	}
+	return nil
+}
+
+var File_envelope_proto protoreflect.FileDescriptor
+
+const file_envelope_proto_rawDesc = "" +
+	"\n" +
+	"\x0eenvelope.proto\x12\x0etwins.protocol\x1a\x1cgoogle/protobuf/struct.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\x87\x01\n" +
+	"\x05Topic\x12\x16\n" +
+	"\x06tenant\x18\x01 \x01(\tR\x06tenant\x12\x18\n" +
+	"\achannel\x18\x02 \x01(\tR\achannel\x12\x16\n" +
+	"\x06entity\x18\x03 \x01(\tR\x06entity\x12\x1c\n" +
+	"\tcriterion\x18\x04 \x01(\tR\tcriterion\x12\x16\n" +
+	"\x06action\x18\x05 \x01(\tR\x06action\"\xff\x01\n" +
+	"\rExactIntegers\x12;\n" +
+	"\x04ints\x18\x01 \x03(\v2'.twins.protocol.ExactIntegers.IntsEntryR\x04ints\x12>\n" +
+	"\x05uints\x18\x02 \x03(\v2(.twins.protocol.ExactIntegers.UintsEntryR\x05uints\x1a7\n" +
+	"\tIntsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x12R\x05value:\x028\x01\x1a8\n" +
+	"\n" +
+	"UintsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x04R\x05value:\x028\x01\"\xbd\x02\n" +
+	"\vHeaderValue\x12#\n" +
+	"\fstring_value\x18\x01 \x01(\tH\x00R\vstringValue\x12\x1f\n" +
+	"\n" +
+	"bool_value\x18\x02 \x01(\bH\x00R\tboolValue\x12\x1d\n" +
+	"\tint_value\x18\x03 \x01(\x03H\x00R\bintValue\x12#\n" +
+	"\fdouble_value\x18\x04 \x01(\x01H\x00R\vdoubleValue\x127\n" +
+	"\n" +
+	"json_value\x18\x05 \x01(\v2\x16.google.protobuf.ValueH\x00R\tjsonValue\x12\x1f\n" +
+	"\n" +
+	"uint_value\x18\x06 \x01(\x04H\x00R\tuintValue\x12B\n" +
+	"\rjson_integers\x18\a \x01(\v2\x1d.twins.protocol.ExactIntegersR\fjsonIntegersB\x06\n" +
+	"\x04kind\"\xbd\x03\n" +
+	"\bEnvelope\x12+\n" +
+	"\x05topic\x18\x01 \x01(\v2\x15.twins.protocol.TopicR\x05topic\x12?\n" +
+	"\aheaders\x18\x02 \x03(\v2%.twins.protocol.Envelope.HeadersEntryR\aheaders\x12\x12\n" +
+	"\x04path\x18\x03 \x01(\tR\x04path\x12,\n" +
+	"\x05value\x18\x04 \x01(\v2\x16.google.protobuf.ValueR\x05value\x12\x16\n" +
+	"\x06status\x18\x05 \x01(\x05R\x06status\x12\x1a\n" +
+	"\brevision\x18\x06 \x01(\x03R\brevision\x12.\n" +
+	"\x04time\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12D\n" +
+	"\x0evalue_integers\x18\b \x01(\v2\x1d.twins.protocol.ExactIntegersR\rvalueIntegers\x1aW\n" +
+	"\fHeadersEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x121\n" +
+	"\x05value\x18\x02 \x01(\v2\x1b.twins.protocol.HeaderValueR\x05value:\x028\x01\"I\n" +
+	"\x0fEnvelopeJournal\x126\n" +
+	"\tenvelopes\x18\x01 \x03(\v2\x18.twins.protocol.EnvelopeR\tenvelopesB,Z*github.com/flywave/go-twins/protocol/pb;pbb\x06proto3"
+
+var (
+	file_envelope_proto_rawDescOnce sync.Once
+	file_envelope_proto_rawDescData []byte
+)
+
+func file_envelope_proto_rawDescGZIP() []byte {
+	file_envelope_proto_rawDescOnce.Do(func() {
+		file_envelope_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_envelope_proto_rawDesc), len(file_envelope_proto_rawDesc)))
+	})
+	return file_envelope_proto_rawDescData
+}
+
+var file_envelope_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_envelope_proto_goTypes = []any{
+	(*Topic)(nil),                 // 0: twins.protocol.Topic
+	(*ExactIntegers)(nil),         // 1: twins.protocol.ExactIntegers
+	(*HeaderValue)(nil),           // 2: twins.protocol.HeaderValue
+	(*Envelope)(nil),              // 3: twins.protocol.Envelope
+	(*EnvelopeJournal)(nil),       // 4: twins.protocol.EnvelopeJournal
+	nil,                           // 5: twins.protocol.ExactIntegers.IntsEntry
+	nil,                           // 6: twins.protocol.ExactIntegers.UintsEntry
+	nil,                           // 7: twins.protocol.Envelope.HeadersEntry
+	(*structpb.Value)(nil),        // 8: google.protobuf.Value
+	(*timestamppb.Timestamp)(nil), // 9: google.protobuf.Timestamp
+}
+var file_envelope_proto_depIdxs = []int32{
+	5,  // 0: twins.protocol.ExactIntegers.ints:type_name -> twins.protocol.ExactIntegers.IntsEntry
+	6,  // 1: twins.protocol.ExactIntegers.uints:type_name -> twins.protocol.ExactIntegers.UintsEntry
+	8,  // 2: twins.protocol.HeaderValue.json_value:type_name -> google.protobuf.Value
+	1,  // 3: twins.protocol.HeaderValue.json_integers:type_name -> twins.protocol.ExactIntegers
+	0,  // 4: twins.protocol.Envelope.topic:type_name -> twins.protocol.Topic
+	7,  // 5: twins.protocol.Envelope.headers:type_name -> twins.protocol.Envelope.HeadersEntry
+	8,  // 6: twins.protocol.Envelope.value:type_name -> google.protobuf.Value
+	9,  // 7: twins.protocol.Envelope.time:type_name -> google.protobuf.Timestamp
+	1,  // 8: twins.protocol.Envelope.value_integers:type_name -> twins.protocol.ExactIntegers
+	3,  // 9: twins.protocol.EnvelopeJournal.envelopes:type_name -> twins.protocol.Envelope
+	2,  // 10: twins.protocol.Envelope.HeadersEntry.value:type_name -> twins.protocol.HeaderValue
+	11, // [11:11] is the sub-list for method output_type
+	11, // [11:11] is the sub-list for method input_type
+	11, // [11:11] is the sub-list for extension type_name
+	11, // [11:11] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
+}
+
+func init() { file_envelope_proto_init() }
+func file_envelope_proto_init() {
+	if File_envelope_proto != nil {
+		return
+	}
+	file_envelope_proto_msgTypes[2].OneofWrappers = []any{
+		(*HeaderValue_StringValue)(nil),
+		(*HeaderValue_BoolValue)(nil),
+		(*HeaderValue_IntValue)(nil),
+		(*HeaderValue_DoubleValue)(nil),
+		(*HeaderValue_JsonValue)(nil),
+		(*HeaderValue_UintValue)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_envelope_proto_rawDesc), len(file_envelope_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_envelope_proto_goTypes,
+		DependencyIndexes: file_envelope_proto_depIdxs,
+		MessageInfos:      file_envelope_proto_msgTypes,
+	}.Build()
+	File_envelope_proto = out.File
+	file_envelope_proto_goTypes = nil
+	file_envelope_proto_depIdxs = nil
+}
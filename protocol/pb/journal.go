@@ -0,0 +1,27 @@
+package pb
+
+import "github.com/flywave/go-twins/protocol"
+
+func FromEnvelopes(messages []*protocol.Envelope) (*EnvelopeJournal, error) {
+	res := &EnvelopeJournal{Envelopes: make([]*Envelope, 0, len(messages))}
+	for _, message := range messages {
+		m, err := FromEnvelope(message)
+		if err != nil {
+			return nil, err
+		}
+		res.Envelopes = append(res.Envelopes, m)
+	}
+	return res, nil
+}
+
+func ToEnvelopes(journal *EnvelopeJournal) ([]*protocol.Envelope, error) {
+	res := make([]*protocol.Envelope, 0, len(journal.GetEnvelopes()))
+	for _, m := range journal.GetEnvelopes() {
+		message, err := ToEnvelope(m)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, message)
+	}
+	return res, nil
+}
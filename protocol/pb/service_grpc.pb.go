@@ -0,0 +1,192 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: service.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	EnvelopeService_Send_FullMethodName    = "/twins.protocol.EnvelopeService/Send"
+	EnvelopeService_Request_FullMethodName = "/twins.protocol.EnvelopeService/Request"
+	EnvelopeService_Stream_FullMethodName  = "/twins.protocol.EnvelopeService/Stream"
+)
+
+// EnvelopeServiceClient is the client API for EnvelopeService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type EnvelopeServiceClient interface {
+	Send(ctx context.Context, in *Envelope, opts ...grpc.CallOption) (*SendResponse, error)
+	Request(ctx context.Context, in *Envelope, opts ...grpc.CallOption) (*Envelope, error)
+	Stream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[Envelope, Envelope], error)
+}
+
+type envelopeServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewEnvelopeServiceClient(cc grpc.ClientConnInterface) EnvelopeServiceClient {
+	return &envelopeServiceClient{cc}
+}
+
+func (c *envelopeServiceClient) Send(ctx context.Context, in *Envelope, opts ...grpc.CallOption) (*SendResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SendResponse)
+	err := c.cc.Invoke(ctx, EnvelopeService_Send_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *envelopeServiceClient) Request(ctx context.Context, in *Envelope, opts ...grpc.CallOption) (*Envelope, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Envelope)
+	err := c.cc.Invoke(ctx, EnvelopeService_Request_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *envelopeServiceClient) Stream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[Envelope, Envelope], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &EnvelopeService_ServiceDesc.Streams[0], EnvelopeService_Stream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[Envelope, Envelope]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type EnvelopeService_StreamClient = grpc.BidiStreamingClient[Envelope, Envelope]
+
+// EnvelopeServiceServer is the server API for EnvelopeService service.
+// All implementations must embed UnimplementedEnvelopeServiceServer
+// for forward compatibility.
+type EnvelopeServiceServer interface {
+	Send(context.Context, *Envelope) (*SendResponse, error)
+	Request(context.Context, *Envelope) (*Envelope, error)
+	Stream(grpc.BidiStreamingServer[Envelope, Envelope]) error
+	mustEmbedUnimplementedEnvelopeServiceServer()
+}
+
+// UnimplementedEnvelopeServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedEnvelopeServiceServer struct{}
+
+func (UnimplementedEnvelopeServiceServer) Send(context.Context, *Envelope) (*SendResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Send not implemented")
+}
+func (UnimplementedEnvelopeServiceServer) Request(context.Context, *Envelope) (*Envelope, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Request not implemented")
+}
+func (UnimplementedEnvelopeServiceServer) Stream(grpc.BidiStreamingServer[Envelope, Envelope]) error {
+	return status.Errorf(codes.Unimplemented, "method Stream not implemented")
+}
+func (UnimplementedEnvelopeServiceServer) mustEmbedUnimplementedEnvelopeServiceServer() {}
+func (UnimplementedEnvelopeServiceServer) testEmbeddedByValue()                         {}
+
+// UnsafeEnvelopeServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to EnvelopeServiceServer will
+// result in compilation errors.
+type UnsafeEnvelopeServiceServer interface {
+	mustEmbedUnimplementedEnvelopeServiceServer()
+}
+
+func RegisterEnvelopeServiceServer(s grpc.ServiceRegistrar, srv EnvelopeServiceServer) {
+	// If the following call pancis, it indicates UnimplementedEnvelopeServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&EnvelopeService_ServiceDesc, srv)
+}
+
+func _EnvelopeService_Send_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Envelope)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EnvelopeServiceServer).Send(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EnvelopeService_Send_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EnvelopeServiceServer).Send(ctx, req.(*Envelope))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EnvelopeService_Request_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Envelope)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EnvelopeServiceServer).Request(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EnvelopeService_Request_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EnvelopeServiceServer).Request(ctx, req.(*Envelope))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EnvelopeService_Stream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(EnvelopeServiceServer).Stream(&grpc.GenericServerStream[Envelope, Envelope]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type EnvelopeService_StreamServer = grpc.BidiStreamingServer[Envelope, Envelope]
+
+// EnvelopeService_ServiceDesc is the grpc.ServiceDesc for EnvelopeService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var EnvelopeService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "twins.protocol.EnvelopeService",
+	HandlerType: (*EnvelopeServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Send",
+			Handler:    _EnvelopeService_Send_Handler,
+		},
+		{
+			MethodName: "Request",
+			Handler:    _EnvelopeService_Request_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Stream",
+			Handler:       _EnvelopeService_Stream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "service.proto",
+}
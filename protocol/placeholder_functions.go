@@ -0,0 +1,174 @@
+package protocol
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+const PLACE_HOLDERS_FUNCTION_PREFIX = "fn:"
+
+var (
+	ErrDeletedPlaceHolder         = errors.New("placeholder deleted")
+	ErrInvalidPlaceHolderFunction = errors.New("invalid placeholder function")
+)
+
+var regexPlaceHolderFunction = regexp.MustCompile(`^fn:([a-z-]+)\((.*)\)$`)
+
+// pipelineElement is the value flowing through a placeholder pipeline such
+// as {{ header:device-id | fn:substring-before(':') | fn:lower() }}. A
+// deleted element stays deleted: later functions do not see it.
+type pipelineElement struct {
+	value    string
+	resolved bool
+	deleted  bool
+}
+
+type placeHolderFunction struct {
+	minArgs, maxArgs int
+	apply            func(in pipelineElement, args []string) (pipelineElement, error)
+}
+
+var placeHolderFunctions = map[string]placeHolderFunction{
+	"default": {1, 1, func(in pipelineElement, args []string) (pipelineElement, error) {
+		if in.resolved {
+			return in, nil
+		}
+		return resolvedElement(args[0]), nil
+	}},
+	"upper": {0, 0, mapElement(strings.ToUpper)},
+	"lower": {0, 0, mapElement(strings.ToLower)},
+	"substring-before": {1, 1, func(in pipelineElement, args []string) (pipelineElement, error) {
+		if i := strings.Index(in.value, args[0]); in.resolved && i >= 0 {
+			return resolvedElement(in.value[:i]), nil
+		}
+		return pipelineElement{}, nil
+	}},
+	"substring-after": {1, 1, func(in pipelineElement, args []string) (pipelineElement, error) {
+		if i := strings.Index(in.value, args[0]); in.resolved && i >= 0 {
+			return resolvedElement(in.value[i+len(args[0]):]), nil
+		}
+		return pipelineElement{}, nil
+	}},
+	"filter": {2, 3, func(in pipelineElement, args []string) (pipelineElement, error) {
+		var compared string
+		if len(args) == 3 {
+			compared = args[2]
+		}
+		var keep bool
+		switch args[1] {
+		case "eq":
+			keep = args[0] == compared
+		case "ne":
+			keep = args[0] != compared
+		case "like":
+			keep, _ = path.Match(compared, args[0])
+		case "exists":
+			keep = (args[0] != "") == (compared == "" || compared == "true")
+		default:
+			return in, fmt.Errorf("%w: unknown filter function %s", ErrInvalidPlaceHolderFunction, args[1])
+		}
+		if keep {
+			return in, nil
+		}
+		return pipelineElement{}, nil
+	}},
+	"delete": {0, 0, func(in pipelineElement, args []string) (pipelineElement, error) {
+		return pipelineElement{deleted: true}, nil
+	}},
+}
+
+func resolvedElement(value string) pipelineElement {
+	return pipelineElement{value: value, resolved: true}
+}
+
+func mapElement(f func(string) string) func(pipelineElement, []string) (pipelineElement, error) {
+	return func(in pipelineElement, args []string) (pipelineElement, error) {
+		if !in.resolved {
+			return in, nil
+		}
+		return resolvedElement(f(in.value)), nil
+	}
+}
+
+// resolveExpression evaluates a placeholder expression: a placeholder name
+// followed by any number of "| fn:name(args)" stages. Quoted arguments are
+// literals, unquoted ones are placeholder names.
+func resolveExpression(expression string, finder ValueFinder) (pipelineElement, error) {
+	var element pipelineElement
+	for i, stage := range splitOutsideQuotes(expression, '|') {
+		stage = strings.TrimSpace(stage)
+		if !strings.HasPrefix(stage, PLACE_HOLDERS_FUNCTION_PREFIX) {
+			if i > 0 {
+				return element, fmt.Errorf("%w: %s", ErrInvalidPlaceHolderFunction, stage)
+			}
+			if value := finder.GetValue(stage); value != "" {
+				element = resolvedElement(value)
+			}
+			continue
+		}
+		if element.deleted {
+			continue
+		}
+		matches := regexPlaceHolderFunction.FindStringSubmatch(stage)
+		if matches == nil {
+			return element, fmt.Errorf("%w: %s", ErrInvalidPlaceHolderFunction, stage)
+		}
+		function, ok := placeHolderFunctions[matches[1]]
+		if !ok {
+			return element, fmt.Errorf("%w: unknown function %s", ErrInvalidPlaceHolderFunction, matches[1])
+		}
+		args, err := parseFunctionArgs(matches[2], finder)
+		if err != nil {
+			return element, fmt.Errorf("%w: %s: %v", ErrInvalidPlaceHolderFunction, stage, err)
+		}
+		if len(args) < function.minArgs || len(args) > function.maxArgs {
+			return element, fmt.Errorf("%w: %s: wrong number of arguments", ErrInvalidPlaceHolderFunction, stage)
+		}
+		if element, err = function.apply(element, args); err != nil {
+			return element, err
+		}
+	}
+	return element, nil
+}
+
+func parseFunctionArgs(s string, finder ValueFinder) ([]string, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	var args []string
+	for _, arg := range splitOutsideQuotes(s, ',') {
+		arg = strings.TrimSpace(arg)
+		switch {
+		case len(arg) >= 2 && (arg[0] == '\'' || arg[0] == '"') && arg[len(arg)-1] == arg[0]:
+			args = append(args, arg[1:len(arg)-1])
+		case arg == "" || strings.ContainsAny(arg, `'"`):
+			return nil, errors.New("malformed argument " + arg)
+		default:
+			args = append(args, finder.GetValue(arg))
+		}
+	}
+	return args, nil
+}
+
+func splitOutsideQuotes(s string, sep byte) []string {
+	var parts []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == sep:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
@@ -1,7 +1,14 @@
 package protocol
 
 import (
+	"errors"
+	"fmt"
 	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/flywave/go-twins"
 )
 
 type PlaceHolders string
@@ -30,11 +37,215 @@ const (
 	PLACE_HOLDERS_TEMPLATE_REGEXP = `{{(.*?)}}`
 )
 
+var regexPlaceHolders = regexp.MustCompile(PLACE_HOLDERS_TEMPLATE_REGEXP)
+
+// regexEscapedPlaceHolders matches an expression in a path built with the
+// With* builders, which escape its braces.
+var regexEscapedPlaceHolders = regexp.MustCompile(`(?i)%7B%7B(.*?)%7D%7D`)
+
+var (
+	ErrUnresolvedPlaceHolder   = errors.New("unresolved placeholder")
+	ErrInvalidPlaceHolderValue = errors.New("invalid placeholder value")
+)
+
+// ValueFinder returns the value of a placeholder name such as
+// "header:correlation-id", or "" when it cannot be resolved.
 type ValueFinder interface {
 	GetValue(name string) string
 }
 
 func HasPlaceHolders(prop string) bool {
-	re := regexp.MustCompile(PLACE_HOLDERS_TEMPLATE_REGEXP)
-	return re.Match([]byte(prop))
+	return regexPlaceHolders.MatchString(prop)
+}
+
+// EnvelopeValueFinder resolves placeholders from the topic, path and headers
+// of an envelope and from its clock. Values holds anything the envelope does
+// not carry, e.g. source:address or device:serial-number, and takes
+// precedence. time:now is written with TimeEncoding.
+type EnvelopeValueFinder struct {
+	Envelope     *Envelope
+	Values       map[string]string
+	Now          func() time.Time
+	TimeEncoding twins.TimeEncoding
+}
+
+func NewEnvelopeValueFinder(msg *Envelope) *EnvelopeValueFinder {
+	return &EnvelopeValueFinder{Envelope: msg, Values: make(map[string]string), Now: time.Now}
+}
+
+func (f *EnvelopeValueFinder) WithValue(name, value string) *EnvelopeValueFinder {
+	f.Values[name] = value
+	return f
+}
+
+func (f *EnvelopeValueFinder) WithClock(now func() time.Time) *EnvelopeValueFinder {
+	f.Now = now
+	return f
+}
+
+func (f *EnvelopeValueFinder) WithTimeEncoding(encoding twins.TimeEncoding) *EnvelopeValueFinder {
+	f.TimeEncoding = encoding
+	return f
+}
+
+func (f *EnvelopeValueFinder) GetValue(name string) string {
+	if value, ok := f.Values[name]; ok {
+		return value
+	}
+	switch name {
+	case PLACE_HOLDERS_TIME_NOW:
+		return twins.FormatTime(f.now(), f.TimeEncoding)
+	case PLACE_HOLDERS_TIME_NOW_EPOCH_MILLIS:
+		return strconv.FormatInt(f.now().UnixMilli(), 10)
+	}
+	msg := f.Envelope
+	if msg == nil {
+		return ""
+	}
+	if header := strings.TrimPrefix(name, "header:"); header != name {
+		value, _ := msg.Headers.Get(header)
+		if value == nil {
+			return ""
+		}
+		return fmt.Sprint(value)
+	}
+	if msg.Topic != nil {
+		switch name {
+		case PLACE_HOLDERS_TOPIC_CHANNEL:
+			return msg.Topic.ChannelName
+		case PLACE_HOLDERS_TOPIC_CRITERION:
+			return string(msg.Topic.Criterion)
+		case PLACE_HOLDERS_TOPIC_ACTION:
+			return string(msg.Topic.Action)
+		}
+	}
+	if msg.Path == nil {
+		return ""
+	}
+	segments := strings.Split(msg.Path.String(), "/")
+	segment := func(i int) string {
+		if i >= len(segments) {
+			return ""
+		}
+		name, err := UnescapePathName(segments[i])
+		if err != nil {
+			return segments[i]
+		}
+		return name
+	}
+	switch name {
+	case PLACE_HOLDERS_THING_ID:
+		if segments[0] != pathThings || segment(1) == "" {
+			return ""
+		}
+		if msg.Topic != nil && msg.Topic.TenantName != "" && msg.Topic.TenantName != TopicPlaceholder &&
+			!strings.HasPrefix(segment(1), msg.Topic.TenantName+":") {
+			return msg.Topic.TenantName + ":" + segment(1)
+		}
+		return segment(1)
+	case PLACE_HOLDERS_THING_NAME:
+		if segments[0] == pathThings {
+			return segment(1)
+		}
+	case PLACE_HOLDERS_FEATURE_ID, PLACE_HOLDERS_FEATURE_NAME:
+		if segments[0] == pathThings && segment(2) == "features" {
+			return segment(3)
+		}
+		if segments[0] == pathFeatures {
+			return segment(1)
+		}
+	case PLACE_HOLDERS_DEVICE_ID:
+		if segments[0] == pathDevices {
+			return segment(1)
+		}
+	}
+	return ""
+}
+
+func (f *EnvelopeValueFinder) now() time.Time {
+	if f.Now == nil {
+		return time.Now()
+	}
+	return f.Now()
+}
+
+// ResolvePlaceHolders substitutes every {{ expression }} in template with
+// the value found for the expression's placeholder, passed through its
+// function pipeline. It fails on the first expression without a value and
+// returns ErrDeletedPlaceHolder when a pipeline ends in fn:delete().
+func ResolvePlaceHolders(template string, finder ValueFinder) (string, error) {
+	return resolvePlaceHolders(template, finder, nil)
+}
+
+// resolvePlaceHolders is ResolvePlaceHolders with every substituted value
+// passed through escape, when given, to fit the syntax of the template.
+func resolvePlaceHolders(template string, finder ValueFinder, escape func(string) (string, error)) (string, error) {
+	var err error
+	resolved := regexPlaceHolders.ReplaceAllStringFunc(template, func(match string) string {
+		if err != nil {
+			return match
+		}
+		expression := strings.TrimSpace(regexPlaceHolders.FindStringSubmatch(match)[1])
+		var element pipelineElement
+		if element, err = resolveExpression(expression, finder); err != nil {
+			return match
+		}
+		switch {
+		case element.deleted:
+			err = fmt.Errorf("%w: %s", ErrDeletedPlaceHolder, expression)
+		case !element.resolved:
+			err = fmt.Errorf("%w: %s", ErrUnresolvedPlaceHolder, expression)
+		case escape != nil:
+			var escaped string
+			if escaped, err = escape(element.value); err != nil {
+				return match
+			}
+			return escaped
+		}
+		return element.value
+	})
+	if err != nil {
+		return "", err
+	}
+	return resolved, nil
+}
+
+func ResolveString(template string, msg *Envelope) (string, error) {
+	return ResolvePlaceHolders(template, NewEnvelopeValueFinder(msg))
+}
+
+// ResolveTopic resolves the placeholders in the segments of topic. Topic
+// segments cannot be escaped, so a value containing "/" is rejected.
+func ResolveTopic(topic *Topic, msg *Envelope) (*Topic, error) {
+	s, err := resolvePlaceHolders(topic.String(), NewEnvelopeValueFinder(msg), func(value string) (string, error) {
+		if strings.Contains(value, "/") {
+			return "", fmt.Errorf("%w: %q in a topic segment", ErrInvalidPlaceHolderValue, value)
+		}
+		return value, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return NewTopic(s)
+}
+
+// ResolvePath resolves the placeholders in path, whether it was parsed from
+// a template string or built with the With* builders, which escape the
+// braces. Substituted values are escaped like names, so a value such as
+// "a/b" stays one segment.
+func ResolvePath(path *Path, msg *Envelope) (*Path, error) {
+	template := regexEscapedPlaceHolders.ReplaceAllStringFunc(path.String(), func(match string) string {
+		expression, err := UnescapePathName(match)
+		if err != nil {
+			return match
+		}
+		return expression
+	})
+	s, err := resolvePlaceHolders(template, NewEnvelopeValueFinder(msg), func(value string) (string, error) {
+		return EscapePathName(value), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return NewPath(s)
 }
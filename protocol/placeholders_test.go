@@ -0,0 +1,192 @@
+package protocol
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/flywave/go-twins"
+)
+
+func TestPlaceholderValuesAreUnescaped(t *testing.T) {
+	f := NewEnvelopeValueFinder(&Envelope{Path: (&Path{}).WithThingFeature("a/b", "c*d")})
+	if name := f.GetValue(PLACE_HOLDERS_THING_NAME); name != "a/b" {
+		t.Fatalf("thing:name %q, want a/b", name)
+	}
+	if id := f.GetValue(PLACE_HOLDERS_FEATURE_ID); id != "c*d" {
+		t.Fatalf("feature:id %q, want c*d", id)
+	}
+}
+
+func TestResolvePath(t *testing.T) {
+	msg := &Envelope{Headers: NewHeadersWithValues(map[string]interface{}{"device-id": "a/b"})}
+	raw, err := NewPath("@things/{{ header:device-id }}/features/f")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, template := range map[string]*Path{
+		"raw":     raw,
+		"builder": (&Path{}).WithThingFeature("{{ header:device-id | fn:default('x/y') }}", "f"),
+	} {
+		p, err := ResolvePath(template, msg)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if p.String() != "@things/a%2Fb/features/f" || p.Type() != PathTypeThingFeatures {
+			t.Fatalf("%s: resolved %s", name, p)
+		}
+	}
+
+	if _, err := ResolvePath(raw, &Envelope{}); !errors.Is(err, ErrUnresolvedPlaceHolder) {
+		t.Fatalf("missing header: %v", err)
+	}
+	notPath := (&Path{}).WithThingMessages("t1", DirectionType("{{ header:device-id }}"), "s")
+	if _, err := ResolvePath(notPath, msg); !errors.Is(err, ErrInvalidPathName) {
+		t.Fatal("resolved a string that is not a path")
+	}
+}
+
+func TestResolveTopicRejectsSeparator(t *testing.T) {
+	template := &Topic{TenantName: "{{ header:device-id }}", ChannelName: "c", Entity: EntityThings, Criterion: CriterionEvents, Action: ActionModified}
+	msg := &Envelope{Headers: NewHeadersWithValues(map[string]interface{}{"device-id": "a/b"})}
+	if _, err := ResolveTopic(template, msg); !errors.Is(err, ErrInvalidPlaceHolderValue) {
+		t.Fatalf("got %v, want ErrInvalidPlaceHolderValue", err)
+	}
+	msg.Headers.Set("device-id", "t1")
+	topic, err := ResolveTopic(template, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if topic.String() != "@topic/t1/c/things/events/modified" {
+		t.Fatalf("resolved %s", topic)
+	}
+}
+
+func TestResolvePlaceHolders(t *testing.T) {
+	topic, _ := NewTopic("@topic/acme/c1/things/events/modified")
+	msg := &Envelope{
+		Topic:   topic,
+		Path:    (&Path{}).WithThingFeature("t1", "f1"),
+		Headers: NewHeadersWithValues(map[string]interface{}{"device-id": "d1", "qos": 1}),
+	}
+	now := time.Date(2024, 5, 6, 7, 8, 9, 10, time.UTC)
+	clock := func() time.Time { return now }
+
+	tests := []struct {
+		template string
+		encoding twins.TimeEncoding
+		want     string
+	}{
+		{"plain", twins.TimeEncodingLegacy, "plain"},
+		{"{{ thing:id }}", twins.TimeEncodingLegacy, "acme:t1"},
+		{"{{thing:name}}/{{ feature:id }}", twins.TimeEncodingLegacy, "t1/f1"},
+		{"{{ topic:channel }}.{{ topic:criterion }}.{{ topic:action }}", twins.TimeEncodingLegacy, "c1.events.modified"},
+		{"dev-{{ header:device-id }}-{{ header:qos }}", twins.TimeEncodingLegacy, "dev-d1-1"},
+		{"{{ source:address }}", twins.TimeEncodingLegacy, "10.0.0.1"},
+		{"{{ time:now }}", twins.TimeEncodingRFC3339Nano, "2024-05-06T07:08:09.00000001Z"},
+		{"{{ time:now }}", twins.TimeEncodingEpochMillis, "1714979289000"},
+		{"{{ time:now_epoch_millis }}", twins.TimeEncodingLegacy, "1714979289000"},
+	}
+	for _, tt := range tests {
+		finder := NewEnvelopeValueFinder(msg).WithClock(clock).WithTimeEncoding(tt.encoding).
+			WithValue(PLACE_HOLDERS_SOURCE_ADDRESS, "10.0.0.1")
+		got, err := ResolvePlaceHolders(tt.template, finder)
+		if err != nil {
+			t.Errorf("%s: %v", tt.template, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.template, got, tt.want)
+		}
+	}
+
+	for _, template := range []string{"{{ header:missing }}", "{{ device:id }}", "{{ unknown }}", "a-{{ thing:id }}-{{ header:missing }}"} {
+		if _, err := ResolveString(template, msg); !errors.Is(err, ErrUnresolvedPlaceHolder) {
+			t.Errorf("%s: got %v, want ErrUnresolvedPlaceHolder", template, err)
+		}
+	}
+}
+
+type mapFinder map[string]string
+
+func (m mapFinder) GetValue(name string) string { return m[name] }
+
+func TestPlaceHolderFunctions(t *testing.T) {
+	finder := mapFinder{"header:device-id": "Acme:Sensor-1", "header:other": "x", "header:glob": "Sensor-*"}
+	tests := []struct {
+		expression string
+		want       string
+	}{
+		{"header:missing | fn:default('fallback')", "fallback"},
+		{"header:device-id | fn:default('fallback')", "Acme:Sensor-1"},
+		{"header:missing | fn:default(header:other)", "x"},
+		{"header:device-id | fn:upper()", "ACME:SENSOR-1"},
+		{"header:device-id | fn:lower()", "acme:sensor-1"},
+		{"header:device-id | fn:substring-before(':')", "Acme"},
+		{"header:device-id | fn:substring-after(\":\")", "Sensor-1"},
+		{"header:device-id | fn:substring-before('|') | fn:default('none')", "none"},
+		{"header:device-id | fn:substring-after(':') | fn:substring-before('-') | fn:lower()", "sensor"},
+		{"header:device-id | fn:filter(header:other, 'eq', 'x')", "Acme:Sensor-1"},
+		{"header:device-id | fn:filter(header:other, 'eq', 'y') | fn:default('dropped')", "dropped"},
+		{"header:device-id | fn:filter(header:other, 'ne', 'y')", "Acme:Sensor-1"},
+		{"header:device-id | fn:filter(header:other, 'ne', 'x') | fn:default('dropped')", "dropped"},
+		{"header:device-id | fn:filter(header:device-id, 'like', 'Acme:*')", "Acme:Sensor-1"},
+		{"header:device-id | fn:filter(header:device-id, 'like', 'Other*') | fn:default('dropped')", "dropped"},
+		{"header:device-id | fn:filter(header:other, 'exists')", "Acme:Sensor-1"},
+		{"header:device-id | fn:filter(header:missing, 'exists') | fn:default('dropped')", "dropped"},
+		{"header:device-id | fn:filter(header:missing, 'exists', 'false')", "Acme:Sensor-1"},
+		{"header:missing | fn:default('a|b,c')", "a|b,c"},
+	}
+	for _, tt := range tests {
+		got, err := ResolvePlaceHolders("{{ "+tt.expression+" }}", finder)
+		if err != nil {
+			t.Errorf("%s: %v", tt.expression, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.expression, got, tt.want)
+		}
+	}
+
+	for _, expression := range []string{
+		"header:device-id | fn:delete()",
+		"header:device-id | fn:delete() | fn:default('back')",
+		"header:missing | fn:delete()",
+	} {
+		if _, err := ResolvePlaceHolders("{{ "+expression+" }}", finder); !errors.Is(err, ErrDeletedPlaceHolder) {
+			t.Errorf("%s: got %v, want ErrDeletedPlaceHolder", expression, err)
+		}
+	}
+	if _, err := ResolvePlaceHolders("{{ header:device-id | fn:filter(header:other, 'eq', 'y') }}", finder); !errors.Is(err, ErrUnresolvedPlaceHolder) {
+		t.Errorf("filtered out: got %v, want ErrUnresolvedPlaceHolder", err)
+	}
+}
+
+func TestMalformedPlaceHolderFunctions(t *testing.T) {
+	finder := mapFinder{"header:device-id": "d1"}
+	for _, expression := range []string{
+		"header:device-id | fn:upper",
+		"header:device-id | fn:upper(",
+		"header:device-id | upper()",
+		"header:device-id | header:other",
+		"header:device-id | fn:unknown()",
+		"header:device-id | fn:",
+		"header:device-id | fn:default('unterminated)",
+		"header:device-id | fn:default(it's)",
+		"header:device-id | fn:default('a',)",
+		"header:device-id | fn:filter(header:device-id, 'gt', 'x')",
+		"header:device-id | fn:upper('x')",
+		"header:device-id | fn:lower('x')",
+		"header:device-id | fn:delete('x')",
+		"header:device-id | fn:default()",
+		"header:device-id | fn:default('a', 'b')",
+		"header:device-id | fn:substring-before()",
+		"header:device-id | fn:substring-after(':', ':')",
+		"header:device-id | fn:filter(header:device-id)",
+		"header:device-id | fn:filter(header:device-id, 'eq', 'x', 'y')",
+	} {
+		if _, err := ResolvePlaceHolders("{{ "+expression+" }}", finder); !errors.Is(err, ErrInvalidPlaceHolderFunction) {
+			t.Errorf("%s: got %v, want ErrInvalidPlaceHolderFunction", expression, err)
+		}
+	}
+}
@@ -14,8 +14,7 @@ func applyOptsHeader(headers *protocol.Headers, opts ...HeaderOpt) error {
 }
 
 func NewHeaders(opts ...HeaderOpt) *protocol.Headers {
-	res := &protocol.Headers{}
-	res.Values = make(map[string]interface{})
+	res := protocol.NewHeadersWithValues(nil)
 	if err := applyOptsHeader(res, opts...); err != nil {
 		return nil
 	}
@@ -26,11 +25,9 @@ func NewHeadersFrom(orig *protocol.Headers, opts ...HeaderOpt) *protocol.Headers
 	if orig == nil {
 		return NewHeaders(opts...)
 	}
-	res := &protocol.Headers{
-		Values: make(map[string]interface{}),
-	}
-	for key, value := range orig.Values {
-		res.Values[key] = value
+	res := orig.Clone()
+	if res.Values == nil {
+		res.Values = make(map[string]interface{})
 	}
 	if err := applyOptsHeader(res, opts...); err != nil {
 		return nil
@@ -40,105 +37,105 @@ func NewHeadersFrom(orig *protocol.Headers, opts ...HeaderOpt) *protocol.Headers
 
 func WithCorrelationId(correlationId string) HeaderOpt {
 	return func(headers *protocol.Headers) error {
-		headers.Values[protocol.HeaderCorrelationId] = correlationId
+		headers.Set(protocol.HeaderCorrelationId, correlationId)
 		return nil
 	}
 }
 
 func WithReplyTo(replyTo string) HeaderOpt {
 	return func(headers *protocol.Headers) error {
-		headers.Values[protocol.HeaderReplyTo] = replyTo
+		headers.Set(protocol.HeaderReplyTo, replyTo)
 		return nil
 	}
 }
 
-func WithReplyTarget(replyTarget string) HeaderOpt {
+func WithReplyTarget(replyTarget int64) HeaderOpt {
 	return func(headers *protocol.Headers) error {
-		headers.Values[protocol.HeaderReplyTarget] = replyTarget
+		headers.Set(protocol.HeaderReplyTarget, replyTarget)
 		return nil
 	}
 }
 
 func WithChannel(channel string) HeaderOpt {
 	return func(headers *protocol.Headers) error {
-		headers.Values[protocol.HeaderChannel] = channel
+		headers.Set(protocol.HeaderChannel, channel)
 		return nil
 	}
 }
 
 func WithResponseRequired(isResponseRequired bool) HeaderOpt {
 	return func(headers *protocol.Headers) error {
-		headers.Values[protocol.HeaderResponseRequired] = isResponseRequired
+		headers.Set(protocol.HeaderResponseRequired, isResponseRequired)
 		return nil
 	}
 }
 
 func WithOriginator(dittoOriginator string) HeaderOpt {
 	return func(headers *protocol.Headers) error {
-		headers.Values[protocol.HeaderOriginator] = dittoOriginator
+		headers.Set(protocol.HeaderOriginator, dittoOriginator)
 		return nil
 	}
 }
 
 func WithOrigin(origin string) HeaderOpt {
 	return func(headers *protocol.Headers) error {
-		headers.Values[protocol.HeaderOrigin] = origin
+		headers.Set(protocol.HeaderOrigin, origin)
 		return nil
 	}
 }
 
 func WithDryRun(isDryRun bool) HeaderOpt {
 	return func(headers *protocol.Headers) error {
-		headers.Values[protocol.HeaderDryRun] = isDryRun
+		headers.Set(protocol.HeaderDryRun, isDryRun)
 		return nil
 	}
 }
 
 func WithETag(eTag string) HeaderOpt {
 	return func(headers *protocol.Headers) error {
-		headers.Values[protocol.HeaderETag] = eTag
+		headers.Set(protocol.HeaderETag, eTag)
 		return nil
 	}
 }
 
 func WithIfMatch(ifMatch string) HeaderOpt {
 	return func(headers *protocol.Headers) error {
-		headers.Values[protocol.HeaderIfMatch] = ifMatch
+		headers.Set(protocol.HeaderIfMatch, ifMatch)
 		return nil
 	}
 }
 
 func WithIfNoneMatch(ifNoneMatch string) HeaderOpt {
 	return func(headers *protocol.Headers) error {
-		headers.Values[protocol.HeaderIfNoneMatch] = ifNoneMatch
+		headers.Set(protocol.HeaderIfNoneMatch, ifNoneMatch)
 		return nil
 	}
 }
 
 func WithTimeout(timeout string) HeaderOpt {
 	return func(headers *protocol.Headers) error {
-		headers.Values[protocol.HeaderTimeout] = timeout
+		headers.Set(protocol.HeaderTimeout, timeout)
 		return nil
 	}
 }
 
-func WithSchemaVersion(schemaVersion string) HeaderOpt {
+func WithSchemaVersion(schemaVersion int64) HeaderOpt {
 	return func(headers *protocol.Headers) error {
-		headers.Values[protocol.HeaderSchemaVersion] = schemaVersion
+		headers.Set(protocol.HeaderSchemaVersion, schemaVersion)
 		return nil
 	}
 }
 
 func WithContentType(contentType string) HeaderOpt {
 	return func(headers *protocol.Headers) error {
-		headers.Values[protocol.HeaderContentType] = contentType
+		headers.Set(protocol.HeaderContentType, contentType)
 		return nil
 	}
 }
 
 func WithGeneric(headerId string, value interface{}) HeaderOpt {
 	return func(headers *protocol.Headers) error {
-		headers.Values[headerId] = value
+		headers.Set(headerId, value)
 		return nil
 	}
 }
@@ -6,7 +6,6 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
-	"strings"
 )
 
 type TopicCriterion string
@@ -39,6 +38,8 @@ const (
 	ActionDeleted        TopicAction = "deleted"
 	ActionMerge          TopicAction = "merge"
 	ActionMerged         TopicAction = "merged"
+	ActionRetrieve       TopicAction = "retrieve"
+	ActionRetrieved      TopicAction = "retrieved"
 	ActionTrigger        TopicAction = "trigger"
 	ActionTriggered      TopicAction = "triggered"
 	ActionClear          TopicAction = "clear"
@@ -93,7 +94,7 @@ func parseTopic(tp string, topic *Topic) error {
 	topic.TenantName = elements[1]
 	topic.ChannelName = elements[2]
 
-	topic.Criterion = TopicCriterion(elements[3])
+	topic.Entity = EntityType(elements[3])
 	topic.Criterion = TopicCriterion(elements[4])
 
 	if elements[6] != "" {
@@ -218,12 +219,26 @@ func (topic *Topic) WithEntity(entity EntityType) *Topic {
 }
 
 func (topic *Topic) HasWildCard() bool {
-	return strings.Contains(topic.String(), "*")
+	return hasWildcard(topic.String(), true)
 }
 
+// Match reports whether the topic matches pattern segment by segment, see
+// Matcher.
 func (topic *Topic) Match(pattern string) bool {
-	result, _ := regexp.MatchString(WildCardToRegexp(pattern), topic.String())
-	return result
+	m, err := cachedMatcher(pattern)
+	return err == nil && m.MatchTopic(topic)
+}
+
+func (topic *Topic) MatchTopic(pattern *Topic) bool {
+	return matchTopicSegment(pattern.TenantName, topic.TenantName) &&
+		matchTopicSegment(pattern.ChannelName, topic.ChannelName) &&
+		matchTopicSegment(string(pattern.Entity), string(topic.Entity)) &&
+		matchTopicSegment(string(pattern.Criterion), string(topic.Criterion)) &&
+		matchTopicSegment(string(pattern.Action), string(topic.Action))
+}
+
+func matchTopicSegment(pattern, value string) bool {
+	return pattern == "" || pattern == TopicPlaceholder || pattern == pathWillCard || pattern == value
 }
 
 func (topic *Topic) HasPlaceHolders() bool {
@@ -0,0 +1,24 @@
+package protocol
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestTopicEntityRoundTrip(t *testing.T) {
+	const s = "@topic/t/c/devices/events/modified"
+	topic, err := NewTopic(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if topic.Entity != EntityDevices || topic.Criterion != CriterionEvents || topic.String() != s {
+		t.Fatalf("parsed %#v", topic)
+	}
+	decoded := &Topic{}
+	if err := json.Unmarshal([]byte(`"`+s+`"`), decoded); err != nil {
+		t.Fatal(err)
+	}
+	if *decoded != *topic {
+		t.Fatalf("unmarshaled %#v, want %#v", decoded, topic)
+	}
+}
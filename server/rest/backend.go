@@ -0,0 +1,22 @@
+package rest
+
+import (
+	"context"
+
+	"github.com/flywave/go-twins/client"
+	"github.com/flywave/go-twins/protocol"
+)
+
+// Backend executes a command envelope and returns its response. A
+// *client.Requester is a Backend, so any transport can serve the gateway.
+type Backend interface {
+	Request(ctx context.Context, message *protocol.Envelope) (*protocol.Envelope, error)
+}
+
+type BackendFunc func(ctx context.Context, message *protocol.Envelope) (*protocol.Envelope, error)
+
+func (f BackendFunc) Request(ctx context.Context, message *protocol.Envelope) (*protocol.Envelope, error) {
+	return f(ctx, message)
+}
+
+var _ Backend = (*client.Requester)(nil)
@@ -0,0 +1,238 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/flywave/go-twins/client"
+	"github.com/flywave/go-twins/protocol"
+	"github.com/flywave/go-twins/protocol/signals"
+)
+
+// ErrNoScope is reported when a request resolves to no tenant or channel:
+// a server error when Options fixes none, a bad request when the Scope
+// found none.
+var ErrNoScope = errors.New("rest: no tenant and channel to address")
+
+var methodActions = map[string]protocol.TopicAction{
+	http.MethodGet:    protocol.ActionRetrieve,
+	http.MethodPut:    protocol.ActionCreateOrModify,
+	http.MethodPatch:  protocol.ActionMerge,
+	http.MethodDelete: protocol.ActionDelete,
+}
+
+const (
+	allowedMethods     = "GET, PUT, PATCH, DELETE"
+	acceptedPatchTypes = "application/merge-patch+json, application/json"
+)
+
+// Handler exposes the path hierarchy as REST resources: the request path
+// "/things/t1/features/f1" addresses "@things/t1/features/f1", and the method
+// selects the command action. Mount it with http.StripPrefix when it does not
+// serve the root.
+type Handler struct {
+	backend Backend
+	opts    *Options
+}
+
+var _ http.Handler = (*Handler)(nil)
+
+func NewHandler(backend Backend, opts *Options) *Handler {
+	if opts == nil {
+		opts = NewOptions()
+	}
+	return &Handler{backend: backend, opts: opts}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	action, ok := methodActions[r.Method]
+	if !ok {
+		w.Header().Set("Allow", allowedMethods)
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed: "+r.Method)
+		return
+	}
+	// the escaped path keeps names such as "a%2Fb" in one segment
+	path, err := resourcePath(r.URL.EscapedPath())
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	tenant, channel, err := h.opts.scope(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if tenant == "" || channel == "" {
+		status := http.StatusBadRequest
+		if h.opts.Scope == nil {
+			status = http.StatusInternalServerError
+		}
+		writeError(w, status, ErrNoScope.Error())
+		return
+	}
+	message := &protocol.Envelope{
+		Topic: &protocol.Topic{
+			TenantName:  tenant,
+			ChannelName: channel,
+			Entity:      path.EntityType(),
+			Criterion:   protocol.CriterionCommands,
+			Action:      action,
+		},
+		Headers: requestHeaders(r),
+		Path:    path,
+	}
+	if action == protocol.ActionCreateOrModify || action == protocol.ActionMerge {
+		if !isJSON(r.Header.Get("Content-Type")) {
+			if action == protocol.ActionMerge {
+				w.Header().Set("Accept-Patch", acceptedPatchTypes)
+			}
+			writeError(w, http.StatusUnsupportedMediaType, "rest: request body must be JSON")
+			return
+		}
+		value, err := h.readBody(w, r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		message.Value = value
+	}
+
+	ctx := r.Context()
+	if h.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.opts.Timeout)
+		defer cancel()
+	}
+	response, err := h.backend.Request(ctx, message)
+	if err != nil {
+		var re *client.ResponseError
+		if !errors.As(err, &re) {
+			writeError(w, backendStatus(err), err.Error())
+			return
+		}
+		response = re.Response
+	}
+	writeResponse(w, response)
+}
+
+func resourcePath(urlPath string) (*protocol.Path, error) {
+	p := strings.Trim(urlPath, "/")
+	if p == "" {
+		return nil, errors.New("rest: no resource addressed")
+	}
+	path, err := protocol.NewPath("@" + p)
+	if errors.Is(err, protocol.ErrInvalidPathName) {
+		return nil, errors.New("rest: unknown resource /" + p)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if path.EntityType() == protocol.EntityUnknown {
+		return nil, errors.New("rest: unknown resource /" + p)
+	}
+	return path, nil
+}
+
+func requestHeaders(r *http.Request) *protocol.Headers {
+	opts := []signals.HeaderOpt{
+		signals.WithCorrelationId(client.NewRequestId()),
+		signals.WithResponseRequired(true),
+	}
+	if v := r.Header.Get("If-Match"); v != "" {
+		opts = append(opts, signals.WithIfMatch(v))
+	}
+	if v := r.Header.Get("If-None-Match"); v != "" {
+		opts = append(opts, signals.WithIfNoneMatch(v))
+	}
+	return signals.NewHeaders(opts...)
+}
+
+// isJSON accepts application/json and its +json variants, such as
+// application/merge-patch+json. A body without a content type is taken to
+// be JSON.
+func isJSON(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mt == ContentTypeJSON || strings.HasPrefix(mt, "application/") && strings.HasSuffix(mt, "+json")
+}
+
+func (h *Handler) readBody(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	body := r.Body
+	if h.opts.MaxBodySize > 0 {
+		body = http.MaxBytesReader(w, r.Body, h.opts.MaxBodySize)
+	}
+	buf, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	if len(strings.TrimSpace(string(buf))) == 0 {
+		return nil, errors.New("rest: request body required")
+	}
+	var value interface{}
+	if err := json.Unmarshal(buf, &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func backendStatus(err error) int {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, context.Canceled), errors.Is(err, client.ErrRequesterClosed):
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusBadGateway
+}
+
+func writeResponse(w http.ResponseWriter, response *protocol.Envelope) {
+	if response == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if response.Headers != nil {
+		if etag := response.Headers.ETag(); etag != "" {
+			w.Header().Set("ETag", etag)
+		}
+	}
+	status := response.Status
+	if status == 0 && response.Topic != nil && response.Topic.IsError() {
+		status = http.StatusInternalServerError
+	}
+	if status == 0 {
+		status = http.StatusOK
+		if response.Value == nil {
+			status = http.StatusNoContent
+		}
+	}
+	if response.Value == nil || status == http.StatusNoContent || status == http.StatusNotModified {
+		w.WriteHeader(status)
+		return
+	}
+	writeJSON(w, status, response.Value)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, &signals.ErrorPayload{Status: int64(status), Error: message})
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	buf, err := json.Marshal(value)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", ContentTypeJSON)
+	w.WriteHeader(status)
+	w.Write(buf)
+}
@@ -0,0 +1,92 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/flywave/go-twins/protocol"
+)
+
+func recordingBackend(got **protocol.Envelope) Backend {
+	return BackendFunc(func(ctx context.Context, message *protocol.Envelope) (*protocol.Envelope, error) {
+		*got = message
+		return &protocol.Envelope{Topic: message.Topic, Status: http.StatusNoContent}, nil
+	})
+}
+
+func TestEscapedNameStaysOneSegment(t *testing.T) {
+	var got *protocol.Envelope
+	h := NewHandler(recordingBackend(&got), NewOptions().WithTenant("t").WithChannel("c"))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/things/a%2Fb/attributes/location", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	if got.Path.String() != "@things/a%2Fb/attributes/location" {
+		t.Fatalf("path %s", got.Path)
+	}
+	if got.Topic.String() != "@topic/t/c/things/commands/retrieve" {
+		t.Fatalf("topic %s", got.Topic)
+	}
+}
+
+func TestMissingScope(t *testing.T) {
+	var got *protocol.Envelope
+	for _, tc := range []struct {
+		name   string
+		opts   *Options
+		status int
+	}{
+		{"unconfigured", NewOptions(), http.StatusInternalServerError},
+		{"scope", NewOptions().WithScope(func(r *http.Request) (string, string, error) {
+			return r.Header.Get("X-Tenant"), "c", nil
+		}), http.StatusBadRequest},
+	} {
+		rec := httptest.NewRecorder()
+		NewHandler(recordingBackend(&got), tc.opts).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/things/t1", nil))
+		if rec.Code != tc.status {
+			t.Fatalf("%s: status %d, want %d", tc.name, rec.Code, tc.status)
+		}
+	}
+	if got != nil {
+		t.Fatal("request without scope reached the backend")
+	}
+}
+
+func TestRequestContentType(t *testing.T) {
+	for _, tc := range []struct {
+		method, contentType string
+		status              int
+	}{
+		{http.MethodPut, "", http.StatusNoContent},
+		{http.MethodPut, "application/json; charset=utf-8", http.StatusNoContent},
+		{http.MethodPatch, "application/merge-patch+json", http.StatusNoContent},
+		{http.MethodPut, "text/plain", http.StatusUnsupportedMediaType},
+		{http.MethodPatch, "application/cbor", http.StatusUnsupportedMediaType},
+	} {
+		var got *protocol.Envelope
+		h := NewHandler(recordingBackend(&got), NewOptions().WithTenant("t").WithChannel("c"))
+		req := httptest.NewRequest(tc.method, "/things/t1/attributes/location", strings.NewReader(`"here"`))
+		if tc.contentType != "" {
+			req.Header.Set("Content-Type", tc.contentType)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != tc.status {
+			t.Fatalf("%s %q: status %d, want %d", tc.method, tc.contentType, rec.Code, tc.status)
+		}
+		if got == nil {
+			continue
+		}
+		if ct := got.Headers.ContentType(); ct != "" {
+			t.Fatalf("%s %q: envelope content-type %q, want none", tc.method, tc.contentType, ct)
+		}
+		// the envelope still encodes on a transport
+		if _, _, err := protocol.EncodeEnvelope(nil, got); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
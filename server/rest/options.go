@@ -0,0 +1,65 @@
+package rest
+
+import (
+	"net/http"
+	"time"
+)
+
+const (
+	DefaultTimeout     = 10 * time.Second
+	DefaultMaxBodySize = 1 << 20
+	ContentTypeJSON    = "application/json"
+)
+
+// ScopeFunc resolves the tenant and channel a request is addressed to.
+type ScopeFunc func(r *http.Request) (tenant string, channel string, err error)
+
+// Options must name the tenant and channel commands are addressed to,
+// either fixed with WithTenant and WithChannel or per request with
+// WithScope; requests fail with ErrNoScope otherwise.
+type Options struct {
+	Tenant      string
+	Channel     string
+	Scope       ScopeFunc
+	Timeout     time.Duration
+	MaxBodySize int64
+}
+
+func NewOptions() *Options {
+	return &Options{
+		Timeout:     DefaultTimeout,
+		MaxBodySize: DefaultMaxBodySize,
+	}
+}
+
+func (o *Options) WithTenant(tenant string) *Options {
+	o.Tenant = tenant
+	return o
+}
+
+func (o *Options) WithChannel(channel string) *Options {
+	o.Channel = channel
+	return o
+}
+
+func (o *Options) WithScope(scope ScopeFunc) *Options {
+	o.Scope = scope
+	return o
+}
+
+func (o *Options) WithTimeout(timeout time.Duration) *Options {
+	o.Timeout = timeout
+	return o
+}
+
+func (o *Options) WithMaxBodySize(size int64) *Options {
+	o.MaxBodySize = size
+	return o
+}
+
+func (o *Options) scope(r *http.Request) (string, string, error) {
+	if o.Scope != nil {
+		return o.Scope(r)
+	}
+	return o.Tenant, o.Channel, nil
+}
@@ -0,0 +1,64 @@
+package sse
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/flywave/go-twins/protocol"
+)
+
+type filter struct {
+	tenant     string
+	criteria   []protocol.TopicCriterion
+	pathPrefix string
+}
+
+func parseFilter(r *http.Request) *filter {
+	q := r.URL.Query()
+	f := &filter{tenant: q.Get("tenant")}
+	for _, v := range q["criterion"] {
+		for _, c := range strings.Split(v, ",") {
+			if c = strings.TrimSpace(c); c != "" {
+				f.criteria = append(f.criteria, protocol.TopicCriterion(c))
+			}
+		}
+	}
+	if p := strings.TrimSuffix(q.Get("path"), "/"); p != "" {
+		if !strings.HasPrefix(p, "@") {
+			p = "@" + strings.TrimPrefix(p, "/")
+		}
+		f.pathPrefix = p
+	}
+	return f
+}
+
+func (f *filter) match(message *protocol.Envelope) bool {
+	if message.Topic == nil {
+		return false
+	}
+	if f.tenant != "" && message.Topic.TenantName != f.tenant {
+		return false
+	}
+	if len(f.criteria) > 0 {
+		found := false
+		for _, c := range f.criteria {
+			if message.Topic.Criterion == c {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if f.pathPrefix != "" {
+		if message.Path == nil {
+			return false
+		}
+		p := message.Path.String()
+		if p != f.pathPrefix && !strings.HasPrefix(p, f.pathPrefix+"/") {
+			return false
+		}
+	}
+	return true
+}
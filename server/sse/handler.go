@@ -0,0 +1,197 @@
+package sse
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/flywave/go-twins/protocol"
+)
+
+// Handler streams event and alarm envelopes as Server-Sent Events. Clients
+// narrow the stream with the "tenant", "criterion" and "path" query
+// parameters, and resume after a reconnect with Last-Event-ID. Event ids
+// number the published envelopes in order, so they are unique across all
+// the things a stream carries; revisions are not.
+type Handler struct {
+	opts    *Options
+	mu      sync.Mutex
+	seq     uint64
+	streams map[*stream]struct{}
+	history []*event
+}
+
+type event struct {
+	id      uint64
+	message *protocol.Envelope
+	data    []byte
+}
+
+type stream struct {
+	filter *filter
+	out    chan *event
+	done   chan struct{}
+	once   sync.Once
+}
+
+var _ http.Handler = (*Handler)(nil)
+
+func NewHandler(opts *Options) *Handler {
+	if opts == nil {
+		opts = NewOptions()
+	}
+	return &Handler{opts: opts, streams: make(map[*stream]struct{})}
+}
+
+// Handle has the signature of client.Handler so the SSE handler can be
+// subscribed to any client directly. Envelopes Publish rejects are
+// dropped; call Publish to see why.
+func (h *Handler) Handle(requestId string, message *protocol.Envelope) {
+	h.Publish(message)
+}
+
+// Publish queues an event or alarm envelope for the matching streams and
+// ignores other envelopes. It fails if the envelope cannot be encoded.
+func (h *Handler) Publish(message *protocol.Envelope) error {
+	if message == nil || message.Topic == nil || !(message.Topic.IsEvent() || message.Topic.IsAlarm()) {
+		return nil
+	}
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.seq++
+	ev := &event{id: h.seq, message: message, data: data}
+	if h.opts.HistorySize > 0 {
+		if len(h.history) >= h.opts.HistorySize {
+			copy(h.history, h.history[1:])
+			h.history = h.history[:len(h.history)-1]
+		}
+		h.history = append(h.history, ev)
+	}
+	for s := range h.streams {
+		if !s.filter.match(message) {
+			continue
+		}
+		select {
+		case s.out <- ev:
+		default:
+			// slow consumer; the client resumes with Last-Event-ID
+			delete(h.streams, s)
+			s.close()
+		}
+	}
+	return nil
+}
+
+func (h *Handler) Streams() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.streams)
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	lastId, resume := lastEventId(r)
+
+	s := &stream{
+		filter: parseFilter(r),
+		out:    make(chan *event, h.opts.SendBuffer),
+		done:   make(chan struct{}),
+	}
+	var backlog []*event
+	h.mu.Lock()
+	if resume {
+		for _, ev := range h.history {
+			if ev.id > lastId && s.filter.match(ev.message) {
+				backlog = append(backlog, ev)
+			}
+		}
+	}
+	h.streams[s] = struct{}{}
+	h.mu.Unlock()
+	defer func() {
+		h.mu.Lock()
+		delete(h.streams, s)
+		h.mu.Unlock()
+	}()
+
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if h.opts.Retry > 0 {
+		fmt.Fprintf(w, "retry: %d\n\n", h.opts.Retry.Milliseconds())
+	}
+	for _, ev := range backlog {
+		if err := writeEvent(w, ev); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	var keepAlive <-chan time.Time
+	if h.opts.KeepAlive > 0 {
+		ticker := time.NewTicker(h.opts.KeepAlive)
+		defer ticker.Stop()
+		keepAlive = ticker.C
+	}
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.done:
+			return
+		case ev := <-s.out:
+			if err := writeEvent(w, ev); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-keepAlive:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func (s *stream) close() {
+	s.once.Do(func() { close(s.done) })
+}
+
+func lastEventId(r *http.Request) (uint64, bool) {
+	v := r.Header.Get("Last-Event-ID")
+	if v == "" {
+		v = r.URL.Query().Get("lastEventId")
+	}
+	if v == "" {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return id, true
+}
+
+func writeEvent(w http.ResponseWriter, ev *event) error {
+	_, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", ev.id, ev.message.Topic.Criterion, ev.data)
+	return err
+}
@@ -0,0 +1,76 @@
+package sse
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/flywave/go-twins/protocol"
+)
+
+func thingEvent(thing string, revision int64) *protocol.Envelope {
+	return &protocol.Envelope{
+		Topic:    &protocol.Topic{TenantName: "t", ChannelName: "c", Entity: protocol.EntityThings, Criterion: protocol.CriterionEvents, Action: protocol.ActionModified},
+		Path:     (&protocol.Path{}).WithThing(thing),
+		Revision: revision,
+	}
+}
+
+// readIds reads the ids of the next n events.
+func readIds(t *testing.T, r *bufio.Reader, n int) []string {
+	t.Helper()
+	var ids []string
+	for len(ids) < n {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id, ok := strings.CutPrefix(strings.TrimSpace(line), "id: "); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func TestResumeAcrossThings(t *testing.T) {
+	h := NewHandler(nil)
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	// revisions are per thing and may be 0; the stream ids are not
+	for _, ev := range []*protocol.Envelope{thingEvent("a", 5), thingEvent("b", 1), thingEvent("b", 2), thingEvent("c", 0)} {
+		if err := h.Publish(ev); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	req.Header.Set("Last-Event-ID", "1")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	ids := readIds(t, bufio.NewReader(res.Body), 3)
+	if strings.Join(ids, ",") != "2,3,4" {
+		t.Fatalf("resumed with ids %v, want 2,3,4", ids)
+	}
+}
+
+func TestPublishRejectsUnencodableEnvelope(t *testing.T) {
+	h := NewHandler(nil)
+	ev := thingEvent("a", 1)
+	ev.Value = make(chan int)
+	if err := h.Publish(ev); err == nil {
+		t.Fatal("unencodable envelope was published")
+	}
+	if len(h.history) != 0 {
+		t.Fatal("unencodable envelope was kept for resume")
+	}
+}
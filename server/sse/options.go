@@ -0,0 +1,44 @@
+package sse
+
+import "time"
+
+const (
+	DefaultHistorySize = 1024
+	DefaultKeepAlive   = 15 * time.Second
+	DefaultSendBuffer  = 64
+)
+
+type Options struct {
+	HistorySize int
+	KeepAlive   time.Duration
+	SendBuffer  int
+	Retry       time.Duration
+}
+
+func NewOptions() *Options {
+	return &Options{
+		HistorySize: DefaultHistorySize,
+		KeepAlive:   DefaultKeepAlive,
+		SendBuffer:  DefaultSendBuffer,
+	}
+}
+
+func (o *Options) WithHistorySize(size int) *Options {
+	o.HistorySize = size
+	return o
+}
+
+func (o *Options) WithKeepAlive(interval time.Duration) *Options {
+	o.KeepAlive = interval
+	return o
+}
+
+func (o *Options) WithSendBuffer(size int) *Options {
+	o.SendBuffer = size
+	return o
+}
+
+func (o *Options) WithRetry(retry time.Duration) *Options {
+	o.Retry = retry
+	return o
+}